@@ -3,7 +3,12 @@ package accrual
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/hashicorp/go-retryablehttp"
 	"github.com/shopspring/decimal"
+	"github.com/soundrussian/go-practicum-diploma/pkg/events"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
 	"github.com/soundrussian/go-practicum-diploma/storage"
 	"sync"
 	"time"
@@ -13,12 +18,47 @@ import (
 type Accrual struct {
 	// storage is storage service to read and write from DB
 	storage storage.Storage
+	// events receives orders that changed status and balances changed by accruals
+	events events.Publisher
+	// address is base URL of external accrual service
+	address string
+	// client makes requests to external accrual service
+	client *retryablehttp.Client
+	// circuit stops requests to accrual service while it keeps failing
+	circuit *circuit
+	// settingsMu guards settings that can be changed by Reconfigure while running
+	settingsMu sync.RWMutex
 	// batch - how many records to process in one tick
 	batch int
 	// interval - how often to check for new records to process
 	interval time.Duration
+	// concurrency - how many records are processed at the same time
+	concurrency int
+	// reconfigured notifies running processor that interval may have changed
+	reconfigured chan struct{}
+
+	// stop is closed by Stop to stop taking new batches
+	stop     chan struct{}
+	stopOnce sync.Once
+	// done is closed once processing loop has exited and in-flight jobs have finished
+	done chan struct{}
+	// cancelJobs cancels context of in-flight jobs when they do not finish in time
+	cancelJobs context.CancelFunc
+
+	// pendingMu guards pending
+	pendingMu sync.Mutex
+	// pending holds orders which subscribers have seen as PROCESSING, but which have
+	// no final result yet. Their retries are not published again.
+	pending map[string]struct{}
 }
 
+const (
+	// circuitThreshold is the number of consecutive failed requests that open the circuit
+	circuitThreshold = 5
+	// circuitCooldown is how long requests are not made once the circuit is open
+	circuitCooldown = 30 * time.Second
+)
+
 // result contains order status received from external service
 type result struct {
 	Order   string          `json:"order"`
@@ -26,45 +66,142 @@ type result struct {
 	Accrual decimal.Decimal `json:"accrual"`
 }
 
-func New(store storage.Storage) (*Accrual, error) {
+func New(cfg Config, store storage.Storage, publisher events.Publisher) (*Accrual, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid accrual config: %w", err)
+	}
+
 	if store == nil {
 		return nil, errors.New("nil storage passed to Processor constructor")
 	}
 
-	if accrualAddress == nil {
-		return nil, errors.New("accrualAddress has not been configured")
+	if publisher == nil {
+		return nil, errors.New("nil event publisher passed to Processor constructor")
 	}
 
-	// batch and interval are not configurable in the current version
-	return &Accrual{storage: store, batch: 10, interval: time.Second}, nil
+	return &Accrual{
+		storage:      store,
+		events:       publisher,
+		address:      cfg.Address,
+		client:       newClient(),
+		circuit:      newCircuit(circuitThreshold, circuitCooldown),
+		batch:        cfg.BatchSize,
+		interval:     cfg.Interval,
+		concurrency:  cfg.Concurrency,
+		reconfigured: make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+		pending:      make(map[string]struct{}),
+	}, nil
 }
 
-// Run spins up timer ticking every acc.interval.
+// Reconfigure changes batch size, interval and concurrency of running processor.
+// New batch size and concurrency are used from the next tick. Other settings
+// of cfg cannot be changed without restart and are ignored.
+func (acc *Accrual) Reconfigure(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid accrual config: %w", err)
+	}
+
+	acc.settingsMu.Lock()
+	acc.batch, acc.interval, acc.concurrency = cfg.BatchSize, cfg.Interval, cfg.Concurrency
+	acc.settingsMu.Unlock()
+
+	// Previous notification has not been handled yet, and it will pick the new interval
+	select {
+	case acc.reconfigured <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// settings returns current batch size, interval and concurrency
+func (acc *Accrual) settings() (batch int, interval time.Duration, concurrency int) {
+	acc.settingsMu.RLock()
+	defer acc.settingsMu.RUnlock()
+
+	return acc.batch, acc.interval, acc.concurrency
+}
+
+// Run spins up timer ticking every acc.interval, which can be changed with Reconfigure.
 // On each tick processor fetches new batch of records from storage,
 // makes async requests to external service and updates accrual data
-// accordingly. It is stopped if passed context is done.
+// accordingly. It runs until Stop is called. Jobs do not run on ctx,
+// so that they are not interrupted as soon as shutdown begins.
 func (acc *Accrual) Run(ctx context.Context) {
-	timer := time.NewTicker(acc.interval)
+	_, interval, _ := acc.settings()
+	timer := time.NewTicker(interval)
+
+	jobsCtx, cancel := context.WithCancel(context.Background())
+	acc.cancelJobs = cancel
 
 	go func() {
+		defer close(acc.done)
+		defer timer.Stop()
+
 		for {
+			// Stop has priority over a tick that is due at the same time
+			select {
+			case <-acc.stop:
+				acc.log(ctx).Info().Msg("processor stopped taking new orders")
+				return
+			default:
+			}
+
 			select {
 			case <-timer.C:
-				// Each tick runs with its own context
-				tickCtx := context.Background()
+				// Each tick runs with its own context and correlation ID
+				tickCtx, _ := logging.NewCtxLogger(jobsCtx)
 				if err := acc.tick(tickCtx); err != nil {
 					acc.log(tickCtx).Err(err).Msg("error during processor tick")
 				}
-			case <-ctx.Done():
-				timer.Stop()
-				acc.log(ctx).Info().Msg("shutting down processor")
+			case <-acc.reconfigured:
+				_, interval, _ := acc.settings()
+				timer.Reset(interval)
+				acc.log(ctx).Info().Msgf("processor interval is set to %s", interval)
+			case <-acc.stop:
+				acc.log(ctx).Info().Msg("processor stopped taking new orders")
 				return
 			}
 		}
 	}()
 }
 
-func (acc *Accrual) tick(ctx context.Context) error {
+// Stop stops taking new orders and waits for in-flight jobs to finish.
+// If ctx is done first, jobs are cancelled, and their orders are rolled back
+// to NEW to be processed after restart. Stop waits for rollbacks in any case,
+// so storage can be closed once it returns.
+func (acc *Accrual) Stop(ctx context.Context) error {
+	acc.stopOnce.Do(func() { close(acc.stop) })
+
+	// Processor has never been run
+	if acc.cancelJobs == nil {
+		return nil
+	}
+
+	select {
+	case <-acc.done:
+		acc.log(ctx).Info().Msg("all accrual jobs have finished")
+		return nil
+	case <-ctx.Done():
+		acc.log(ctx).Warn().Msg("accrual jobs have not finished in time, cancelling them")
+		acc.cancelJobs()
+		<-acc.done
+		return ctx.Err()
+	}
+}
+
+func (acc *Accrual) tick(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "accrual.tick")
+	defer func() { tracing.End(span, err) }()
+	ctx = tracing.LinkLogger(ctx, span)
+
+	if !acc.circuit.Allow() {
+		acc.log(ctx).Warn().Msg("accrual service keeps failing, skipping tick until circuit cooldown ends")
+		return nil
+	}
+
 	orders, err := acc.nextBatch(ctx)
 	if err != nil {
 		acc.log(ctx).Err(err).Msg("failed to get next batch of records to process")
@@ -72,17 +209,30 @@ func (acc *Accrual) tick(ctx context.Context) error {
 	}
 
 	if len(orders) == 0 {
-		acc.log(ctx).Info().Msg("no orders to process")
+		acc.log(ctx).Debug().Msg("no orders to process")
 		return nil
 	}
 
+	_, _, concurrency := acc.settings()
+	// slots limits the number of orders processed at the same time
+	slots := make(chan struct{}, concurrency)
+
 	var wg sync.WaitGroup
 	for _, order := range orders {
 		wg.Add(1)
+		slots <- struct{}{}
 		go func(order string) {
 			defer wg.Done()
-			if err := acc.process(ctx, order); err != nil {
-				acc.log(ctx).Err(err).Msgf("error processing order <%s>", order)
+			defer func() { <-slots }()
+
+			// Each job gets its own correlation ID, which is also sent to accrual service,
+			// so that processing of one order could be traced across both systems
+			jobCtx, _ := logging.NewCtxLogger(ctx)
+			jobID, _ := logging.CorrelationID(jobCtx)
+			acc.log(ctx).Info().Msgf("processing order <%s> in job %s", order, jobID)
+
+			if err := acc.process(jobCtx, order); err != nil {
+				acc.log(jobCtx).Err(err).Msgf("error processing order <%s>", order)
 			}
 		}(order)
 	}
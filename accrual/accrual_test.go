@@ -0,0 +1,239 @@
+package accrual
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/shopspring/decimal"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/events"
+	storageMock "github.com/soundrussian/go-practicum-diploma/storage/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrual_Stop(t *testing.T) {
+	t.Run("it waits for in-flight jobs to finish", func(t *testing.T) {
+		fetched := make(chan struct{})
+		release := make(chan struct{})
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(fetched)
+			<-release
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"order": "79927398713", "status": "PROCESSED", "accrual": 500}`))
+		}))
+		defer ts.Close()
+
+		store := new(storageMock.Storage)
+		store.On("OrdersWithStatus", mock.Anything, model.OrderNew, 10).Return([]string{"79927398713"}, nil).Once()
+		store.On("UpdateOrderStatus", mock.Anything, "79927398713", model.OrderProcessing).Return(nil)
+		store.On("AddAccrual", mock.Anything, "79927398713", model.OrderProcessed, decimal.NewFromInt(500)).Return(nil)
+		store.On("FetchOrder", mock.Anything, "79927398713").Return(&model.Order{UserID: 100, OrderID: "79927398713", Status: model.OrderProcessing}, nil).Once()
+		store.On("FetchOrder", mock.Anything, "79927398713").Return(&model.Order{UserID: 100, OrderID: "79927398713", Status: model.OrderProcessed}, nil).Once()
+		store.On("UserBalance", mock.Anything, uint64(100)).Return(&model.UserBalance{Current: decimal.NewFromInt(500)}, nil)
+
+		acc := newTestAccrual(t, store, ts.URL)
+		_, sub := acc.events.(*events.Bus).Subscribe(100, 0)
+		defer sub.Close()
+
+		acc.Run(context.Background())
+		<-fetched
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			close(release)
+		}()
+
+		require.NoError(t, acc.Stop(context.Background()))
+		store.AssertExpectations(t)
+
+		order := <-sub.Events()
+		assert.Equal(t, events.TypeOrder, order.Type)
+		assert.Equal(t, model.OrderProcessing, order.Data.(model.Order).Status)
+		order = <-sub.Events()
+		assert.Equal(t, events.TypeOrder, order.Type)
+		assert.Equal(t, model.OrderProcessed, order.Data.(model.Order).Status)
+		balance := <-sub.Events()
+		assert.Equal(t, events.TypeBalance, balance.Type)
+		assert.Equal(t, model.UserBalance{Current: decimal.NewFromInt(500)}, balance.Data)
+	})
+
+	t.Run("it cancels jobs on deadline and returns their orders to NEW", func(t *testing.T) {
+		fetched := make(chan struct{})
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(fetched)
+			<-r.Context().Done()
+		}))
+		defer ts.Close()
+
+		store := new(storageMock.Storage)
+		store.On("OrdersWithStatus", mock.Anything, model.OrderNew, 10).Return([]string{"79927398713"}, nil).Once()
+		store.On("UpdateOrderStatus", mock.Anything, "79927398713", model.OrderProcessing).Return(nil)
+		store.On("UpdateOrderStatus", mock.MatchedBy(func(ctx context.Context) bool {
+			return ctx.Err() == nil
+		}), "79927398713", model.OrderNew).Return(nil)
+		store.On("FetchOrder", mock.Anything, "79927398713").Return(&model.Order{UserID: 100, OrderID: "79927398713", Status: model.OrderProcessing}, nil).Once()
+
+		acc := newTestAccrual(t, store, ts.URL)
+		_, sub := acc.events.(*events.Bus).Subscribe(100, 0)
+		defer sub.Close()
+
+		acc.Run(context.Background())
+		<-fetched
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		err := acc.Stop(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		store.AssertExpectations(t)
+
+		order := <-sub.Events()
+		assert.Equal(t, model.OrderProcessing, order.Data.(model.Order).Status)
+		select {
+		case order := <-sub.Events():
+			t.Errorf("order returned to NEW must not be published, got %+v", order)
+		default:
+		}
+	})
+
+	t.Run("it does nothing if processor has not been run", func(t *testing.T) {
+		acc := newTestAccrual(t, new(storageMock.Storage), "http://localhost")
+
+		assert.NoError(t, acc.Stop(context.Background()))
+	})
+}
+
+func newTestAccrual(t *testing.T, store *storageMock.Storage, address string) *Accrual {
+	cfg := DefaultConfig()
+	cfg.Address = address
+	cfg.Interval = 10 * time.Millisecond
+
+	acc, err := New(cfg, store, events.NewBus(0))
+	require.NoError(t, err)
+
+	acc.client = retryablehttp.NewClient()
+	acc.client.RetryMax = 0
+
+	return acc
+}
+
+func TestAccrual_Reconfigure(t *testing.T) {
+	t.Run("it applies new interval and batch size to running processor", func(t *testing.T) {
+		polled := make(chan struct{})
+		store := new(storageMock.Storage)
+		store.On("OrdersWithStatus", mock.Anything, model.OrderNew, 3).Return([]string{}, nil).Run(func(mock.Arguments) {
+			select {
+			case polled <- struct{}{}:
+			default:
+			}
+		})
+
+		acc := newTestAccrual(t, store, "http://localhost")
+		acc.interval = time.Hour
+		acc.Run(context.Background())
+		defer acc.Stop(context.Background())
+
+		cfg := DefaultConfig()
+		cfg.BatchSize = 3
+		cfg.Interval = 10 * time.Millisecond
+		require.NoError(t, acc.Reconfigure(cfg))
+
+		select {
+		case <-polled:
+		case <-time.After(time.Second):
+			t.Fatal("processor has not picked up new interval")
+		}
+	})
+
+	t.Run("it rejects invalid config", func(t *testing.T) {
+		acc := newTestAccrual(t, new(storageMock.Storage), "http://localhost")
+
+		cfg := DefaultConfig()
+		cfg.Concurrency = 0
+
+		assert.Error(t, acc.Reconfigure(cfg))
+		_, _, concurrency := acc.settings()
+		assert.Equal(t, defaultConcurrency, concurrency)
+	})
+}
+
+func TestAccrual_tick_LimitsConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	var running, maxRunning int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status": "PROCESSING"}`))
+	}))
+	defer ts.Close()
+
+	orders := []string{"1", "2", "3", "4", "5"}
+	store := new(storageMock.Storage)
+	store.On("OrdersWithStatus", mock.Anything, model.OrderNew, mock.Anything).Return(orders, nil)
+	store.On("UpdateOrderStatus", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	store.On("FetchOrder", mock.Anything, mock.Anything).Return(&model.Order{UserID: 100}, nil)
+
+	acc := newTestAccrual(t, store, ts.URL)
+	acc.concurrency = 2
+
+	require.NoError(t, acc.tick(context.Background()))
+
+	assert.Equal(t, 2, maxRunning)
+}
+
+func TestAccrual_process_PublishesOnlyStatusChanges(t *testing.T) {
+	responses := []string{
+		`{"order": "79927398713", "status": "REGISTERED"}`,
+		`{"order": "79927398713", "status": "PROCESSING"}`,
+		`{"order": "79927398713", "status": "PROCESSED", "accrual": 500}`,
+	}
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(responses[requests]))
+		requests++
+	}))
+	defer ts.Close()
+
+	store := new(storageMock.Storage)
+	store.On("UpdateOrderStatus", mock.Anything, "79927398713", mock.Anything).Return(nil)
+	store.On("AddAccrual", mock.Anything, "79927398713", model.OrderProcessed, decimal.NewFromInt(500)).Return(nil)
+	store.On("FetchOrder", mock.Anything, "79927398713").Return(&model.Order{UserID: 100, OrderID: "79927398713", Status: model.OrderProcessing}, nil).Once()
+	store.On("FetchOrder", mock.Anything, "79927398713").Return(&model.Order{UserID: 100, OrderID: "79927398713", Status: model.OrderProcessed}, nil).Once()
+	store.On("UserBalance", mock.Anything, uint64(100)).Return(&model.UserBalance{Current: decimal.NewFromInt(500)}, nil)
+
+	acc := newTestAccrual(t, store, ts.URL)
+	_, sub := acc.events.(*events.Bus).Subscribe(100, 0)
+	defer sub.Close()
+
+	for range responses {
+		require.NoError(t, acc.process(context.Background(), "79927398713"))
+	}
+	store.AssertExpectations(t)
+
+	order := <-sub.Events()
+	assert.Equal(t, model.OrderProcessing, order.Data.(model.Order).Status)
+	order = <-sub.Events()
+	assert.Equal(t, model.OrderProcessed, order.Data.(model.Order).Status, "retries of pending order are not published")
+	balance := <-sub.Events()
+	assert.Equal(t, events.TypeBalance, balance.Type)
+	assert.Empty(t, acc.pending)
+}
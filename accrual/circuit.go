@@ -0,0 +1,69 @@
+package accrual
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit states reported by health checks
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half-open"
+)
+
+// circuit stops requests to accrual service after threshold consecutive failures,
+// so that an unavailable service is not hammered by every tick. After cooldown
+// requests are allowed again: success closes the circuit, failure opens it again.
+type circuit struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+func newCircuit(threshold int, cooldown time.Duration) *circuit {
+	return &circuit{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether requests can be made
+func (c *circuit) Allow() bool {
+	return c.State() != circuitOpen
+}
+
+// State returns current state of the circuit
+func (c *circuit) State() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failures < c.threshold {
+		return circuitClosed
+	}
+
+	if c.now().Sub(c.openedAt) < c.cooldown {
+		return circuitOpen
+	}
+
+	return circuitHalfOpen
+}
+
+// Success records successful request and closes the circuit
+func (c *circuit) Success() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures = 0
+}
+
+// Failure records failed request and opens the circuit once threshold is reached
+func (c *circuit) Failure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures++
+	if c.failures >= c.threshold {
+		c.openedAt = c.now()
+	}
+}
@@ -0,0 +1,34 @@
+package accrual
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuit(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newCircuit(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	assert.Equal(t, circuitClosed, c.State())
+
+	c.Failure()
+	assert.Equal(t, circuitClosed, c.State(), "stays closed below threshold")
+
+	c.Failure()
+	assert.Equal(t, circuitOpen, c.State(), "opens at threshold")
+	assert.False(t, c.Allow())
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, circuitHalfOpen, c.State(), "lets requests through after cooldown")
+	assert.True(t, c.Allow())
+
+	c.Failure()
+	assert.Equal(t, circuitOpen, c.State(), "opens again if request fails when half-open")
+
+	now = now.Add(time.Minute)
+	c.Success()
+	assert.Equal(t, circuitClosed, c.State(), "closes after successful request")
+}
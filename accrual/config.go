@@ -1,37 +1,61 @@
 package accrual
 
 import (
-	"flag"
-	"os"
+	"errors"
+	"net/url"
+	"time"
 )
 
-var accrualAddress *string
-
-const defaultAccrualAddress = "http://localhost:1337"
-
-const accrualAddressEnvKey = "ACCRUAL_SYSTEM_ADDRESS"
-const accrualAddressFlag = "r"
+// Config contains settings of accrual processor
+type Config struct {
+	// Address is base URL of external accrual service
+	Address string `yaml:"address" toml:"address"`
+	// BatchSize is how many orders are processed on one tick
+	BatchSize int `yaml:"batch_size" toml:"batch_size"`
+	// Interval is how often new orders are checked for
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Concurrency is how many orders are sent to accrual service at the same time
+	Concurrency int `yaml:"concurrency" toml:"concurrency"`
+	// DrainTimeout is how long in-flight jobs may take to finish on shutdown
+	DrainTimeout time.Duration `yaml:"drain_timeout" toml:"drain_timeout"`
+}
 
-func init() {
-	// Config from env is read by a separate function so that we could call it once
-	// again in tests, after setting env variable inside the test
-	readConfig()
+const (
+	defaultAccrualAddress = "http://localhost:1337"
+	defaultBatchSize      = 10
+	defaultInterval       = time.Second
+	defaultConcurrency    = 10
+	defaultDrainTimeout   = 10 * time.Second
+)
 
-	flag.StringVar(accrualAddress, accrualAddressFlag, *accrualAddress, "accrual system address")
+// DefaultConfig returns accrual settings used unless they are overridden
+func DefaultConfig() Config {
+	return Config{
+		Address:      defaultAccrualAddress,
+		BatchSize:    defaultBatchSize,
+		Interval:     defaultInterval,
+		Concurrency:  defaultConcurrency,
+		DrainTimeout: defaultDrainTimeout,
+	}
 }
 
-func readConfig() {
-	if accrualAddress == nil {
-		defaultAddress := defaultAccrualAddress
-		accrualAddress = &defaultAddress
+// Validate returns error if any of the settings is invalid
+func (c Config) Validate() error {
+	if u, err := url.Parse(c.Address); err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New("accrual address must be an absolute URL")
 	}
-
-	// Here we check for two things:
-	// 1. There is accrualAddressEnvKey environment variable
-	// 2. accrualAddress equals defaultAccrualAddress, which means
-	// that it has just been initialized and was not overwritten previously
-	// by flag.Parse(). This is required for tests.
-	if address, ok := os.LookupEnv(accrualAddressEnvKey); ok && *accrualAddress == defaultAccrualAddress {
-		accrualAddress = &address
+	if c.BatchSize <= 0 {
+		return errors.New("batch size must be positive")
+	}
+	if c.Concurrency <= 0 {
+		return errors.New("concurrency must be positive")
+	}
+	if c.Interval <= 0 {
+		return errors.New("interval must be positive")
 	}
+	if c.DrainTimeout <= 0 {
+		return errors.New("drain timeout must be positive")
+	}
+
+	return nil
 }
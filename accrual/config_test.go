@@ -0,0 +1,58 @@
+package accrual
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{
+			name:   "accepts default config",
+			modify: func(c *Config) {},
+		},
+		{
+			name:    "rejects address without scheme",
+			modify:  func(c *Config) { c.Address = "localhost:1337" },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero batch size",
+			modify:  func(c *Config) { c.BatchSize = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero concurrency",
+			modify:  func(c *Config) { c.Concurrency = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero interval",
+			modify:  func(c *Config) { c.Interval = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero drain timeout",
+			modify:  func(c *Config) { c.DrainTimeout = 0 },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			tt.modify(&c)
+
+			err := c.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -5,20 +5,58 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/go-retryablehttp"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/metrics"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"strconv"
+	"time"
 )
 
-func (acc *Accrual) fetch(ctx context.Context, orderID string) (*result, error) {
+func (acc *Accrual) fetch(ctx context.Context, orderID string) (res *result, err error) {
 	var result result
 
+	ctx, span := tracing.Tracer().Start(ctx, "accrual.fetch",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(tracing.OrderNumber(orderID)),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	acc.log(ctx).Info().Msgf("getting accrual for order <%s>", orderID)
 
-	resp, err := retryablehttp.Get(fmt.Sprintf("%s/api/orders/%s", *accrualAddress, orderID))
+	req, err := retryablehttp.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/orders/%s", acc.address, orderID), nil)
 	if err != nil {
+		acc.log(ctx).Err(err).Msgf("failed to build accrual request for order <%s>", orderID)
+		return nil, err
+	}
+
+	if correlationID, err := logging.CorrelationID(ctx); err == nil {
+		req.Header.Set(logging.RequestIDHeader, correlationID)
+	}
+	tracing.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	metrics.AccrualFetchesInFlight.Inc()
+	start := time.Now()
+	resp, err := acc.client.Do(req.WithContext(ctx))
+	metrics.AccrualFetchesInFlight.Dec()
+
+	if err != nil {
+		metrics.AccrualFetchDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
+		// Retries have been exhausted, so the service is considered failing
+		acc.circuit.Failure()
 		acc.log(ctx).Err(err).Msgf("failed to fetch accrual for order <%s>", orderID)
 		return nil, err
 	}
 
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+	// Any response other than one retried by the client means the service is up,
+	// e.g. 204 just says the order is not registered there
+	acc.circuit.Success()
+	metrics.AccrualFetchDuration.WithLabelValues(strconv.Itoa(resp.StatusCode)).Observe(time.Since(start).Seconds())
+
 	if resp.StatusCode != http.StatusOK {
 		acc.log(ctx).Error().Msgf("fetching order <%s> responded with status %d", orderID, resp.StatusCode)
 		return nil, ErrFailedToFetch
@@ -34,3 +72,16 @@ func (acc *Accrual) fetch(ctx context.Context, orderID string) (*result, error)
 
 	return &result, nil
 }
+
+// newClient returns client for accrual service. Requests are retried by the client,
+// so 429 responses are counted on every attempt rather than by fetch.
+func newClient() *retryablehttp.Client {
+	client := retryablehttp.NewClient()
+	client.ResponseLogHook = func(_ retryablehttp.Logger, resp *http.Response) {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			metrics.AccrualRateLimited.Inc()
+		}
+	}
+
+	return client
+}
@@ -0,0 +1,57 @@
+package accrual
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/shopspring/decimal"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrual_fetch(t *testing.T) {
+	t.Run("sends correlation id of the job to accrual service", func(t *testing.T) {
+		var gotRequestID string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotRequestID = r.Header.Get("X-Request-ID")
+			assert.Equal(t, "/api/orders/79927398713", r.URL.Path)
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"order": "79927398713", "status": "PROCESSED", "accrual": 500}`))
+		}))
+		defer ts.Close()
+
+		acc := &Accrual{address: ts.URL, client: retryablehttp.NewClient(), circuit: newCircuit(circuitThreshold, circuitCooldown)}
+		ctx := logging.SetCorrelationID(context.Background(), "job-correlation-id")
+
+		res, err := acc.fetch(ctx, "79927398713")
+		require.NoError(t, err)
+
+		assert.Equal(t, "job-correlation-id", gotRequestID)
+		assert.Equal(t, "PROCESSED", res.Status)
+		assert.True(t, decimal.NewFromInt(500).Equal(res.Accrual))
+	})
+}
+
+func TestAccrual_CheckReachable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	acc := &Accrual{address: ts.URL, client: retryablehttp.NewClient(), circuit: newCircuit(1, time.Minute)}
+
+	assert.NoError(t, acc.CheckReachable(context.Background()), "any response means service is reachable")
+
+	acc.circuit.Failure()
+	assert.Error(t, acc.CheckReachable(context.Background()), "open circuit fails the check")
+
+	ts.Close()
+	acc.circuit.Success()
+	assert.Error(t, acc.CheckReachable(context.Background()), "closed server fails the check")
+}
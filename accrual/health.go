@@ -0,0 +1,29 @@
+package accrual
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// CheckReachable returns error if accrual service does not respond
+// or if requests to it are stopped by open circuit
+func (acc *Accrual) CheckReachable(ctx context.Context) error {
+	if state := acc.circuit.State(); state == circuitOpen {
+		return fmt.Errorf("circuit is %s after repeated failures", state)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, acc.address, nil)
+	if err != nil {
+		return err
+	}
+
+	// Any response means the service is reachable, so the request is not retried
+	resp, err := acc.client.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("accrual service is unreachable: %w", err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package accrual
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/soundrussian/go-practicum-diploma/model"
+)
+
+// queueCollectTimeout limits time spent counting orders on each scrape
+const queueCollectTimeout = 5 * time.Second
+
+var queueDepthDesc = prometheus.NewDesc(
+	"gophermart_accrual_orders",
+	"Number of orders by status.",
+	[]string{"status"},
+	nil,
+)
+
+// queueCollector reports number of orders in each status when metrics are scraped,
+// so that orders are counted once per scrape rather than on every tick
+type queueCollector struct {
+	acc *Accrual
+}
+
+// QueueCollector returns Prometheus collector of accrual queue depth by order status
+func (acc *Accrual) QueueCollector() prometheus.Collector {
+	return &queueCollector{acc: acc}
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueDepthDesc
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueCollectTimeout)
+	defer cancel()
+
+	counts, err := c.acc.storage.OrderCountsByStatus(ctx)
+	if err != nil {
+		c.acc.log(ctx).Err(err).Msg("failed to count orders for metrics")
+		ch <- prometheus.NewInvalidMetric(queueDepthDesc, err)
+		return
+	}
+
+	for _, status := range []model.OrderStatus{model.OrderNew, model.OrderProcessing, model.OrderInvalid, model.OrderProcessed} {
+		ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(counts[status]), status.String())
+	}
+}
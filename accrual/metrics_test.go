@@ -0,0 +1,44 @@
+package accrual
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/storage/mock"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+)
+
+func TestAccrual_QueueCollector(t *testing.T) {
+	t.Run("reports number of orders in every status", func(t *testing.T) {
+		store := new(mock.Storage)
+		store.On("OrderCountsByStatus", testifyMock.Anything).Return(map[model.OrderStatus]int64{
+			model.OrderNew:       3,
+			model.OrderProcessed: 10,
+		}, nil)
+
+		acc := &Accrual{storage: store}
+
+		expected := `
+# HELP gophermart_accrual_orders Number of orders by status.
+# TYPE gophermart_accrual_orders gauge
+gophermart_accrual_orders{status="INVALID"} 0
+gophermart_accrual_orders{status="NEW"} 3
+gophermart_accrual_orders{status="PROCESSED"} 10
+gophermart_accrual_orders{status="PROCESSING"} 0
+`
+		assert.NoError(t, testutil.CollectAndCompare(acc.QueueCollector(), strings.NewReader(expected)))
+	})
+
+	t.Run("reports error if orders cannot be counted", func(t *testing.T) {
+		store := new(mock.Storage)
+		store.On("OrderCountsByStatus", testifyMock.Anything).Return(nil, errors.New("connection refused"))
+
+		acc := &Accrual{storage: store}
+
+		assert.Error(t, testutil.CollectAndCompare(acc.QueueCollector(), strings.NewReader("")))
+	})
+}
@@ -4,10 +4,33 @@ import (
 	"context"
 	"github.com/soundrussian/go-practicum-diploma/accrual/status"
 	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/events"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/metrics"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"time"
 )
 
+// rollbackTimeout limits time to return order to NEW status after failed processing
+const rollbackTimeout = 5 * time.Second
+
+// detach returns context that carries logger and trace of ctx, but is not cancelled with it
+func detach(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	_, logger := logging.CtxLogger(ctx)
+
+	detached := logging.SetCtxLogger(context.Background(), logger)
+	if correlationID, err := logging.CorrelationID(ctx); err == nil {
+		detached = logging.SetCorrelationID(detached, correlationID)
+	}
+	detached = trace.ContextWithSpan(detached, trace.SpanFromContext(ctx))
+
+	return context.WithTimeout(detached, timeout)
+}
+
 func (acc *Accrual) nextBatch(ctx context.Context) ([]string, error) {
-	orders, err := acc.storage.OrdersWithStatus(ctx, model.OrderNew, acc.batch)
+	batch, _, _ := acc.settings()
+	orders, err := acc.storage.OrdersWithStatus(ctx, model.OrderNew, batch)
 	if err != nil {
 		acc.log(ctx).Err(err).Msg("failed to fetch next portion of orders to process")
 		return nil, err
@@ -16,20 +39,33 @@ func (acc *Accrual) nextBatch(ctx context.Context) ([]string, error) {
 	return orders, nil
 }
 
-func (acc *Accrual) process(ctx context.Context, orderID string) error {
+func (acc *Accrual) process(ctx context.Context, orderID string) (err error) {
 	var finalResult bool
 
+	ctx, span := tracing.Start(ctx, "accrual.process", tracing.OrderNumber(orderID))
+	defer func() { tracing.End(span, err) }()
+	ctx = tracing.LinkLogger(ctx, span)
+
 	// Mark order as processing so that it won't go into next batch
 	// while being processed
 	if err := acc.storage.UpdateOrderStatus(ctx, orderID, model.OrderProcessing); err != nil {
 		acc.log(ctx).Err(err).Msgf("failed to mark order <%s> as processing", orderID)
 		return err
 	}
+	if acc.startPending(orderID) {
+		acc.publish(ctx, orderID, false)
+	}
 
-	// Check if we got final result in defer. If not, make order NEW again
+	// Check if we got final result in defer. If not, make order NEW again.
+	// Rollback must happen even if the job has been cancelled on shutdown.
+	// It is not published: order will be PROCESSING again on the next tick,
+	// so subscribers keep seeing PROCESSING until the final result.
 	defer func() {
 		if !finalResult {
-			if err := acc.storage.UpdateOrderStatus(ctx, orderID, model.OrderNew); err != nil {
+			rollbackCtx, cancel := detach(ctx, rollbackTimeout)
+			defer cancel()
+
+			if err := acc.storage.UpdateOrderStatus(rollbackCtx, orderID, model.OrderNew); err != nil {
 				acc.log(ctx).Err(err).Msgf("failed to mark order <%s> as new", orderID)
 			}
 		}
@@ -42,6 +78,7 @@ func (acc *Accrual) process(ctx context.Context, orderID string) error {
 	}
 
 	acc.log(ctx).Info().Msgf("got response from accrual service: %+v", res)
+	span.SetAttributes(tracing.OrderStatus(res.Status))
 
 	switch status.New(res.Status) {
 	case status.Invalid:
@@ -51,6 +88,8 @@ func (acc *Accrual) process(ctx context.Context, orderID string) error {
 			return err
 		}
 		finalResult = true
+		acc.finishPending(orderID)
+		acc.publish(ctx, orderID, false)
 		return nil
 	case status.Processed:
 		acc.log(ctx).Info().Msgf("order <%s> has been processed with accrual %s", orderID, res.Accrual)
@@ -58,9 +97,59 @@ func (acc *Accrual) process(ctx context.Context, orderID string) error {
 			acc.log(ctx).Err(err).Msgf("failed to mark order <%s> as processed", orderID)
 			return err
 		}
+		metrics.PointsCredited.Add(res.Accrual.InexactFloat64())
 		finalResult = true
+		acc.finishPending(orderID)
+		acc.publish(ctx, orderID, true)
 		return nil
 	}
 
 	return nil
 }
+
+// startPending reports whether order becomes PROCESSING for subscribers,
+// i.e. it is not being retried after earlier attempt has not got final result
+func (acc *Accrual) startPending(orderID string) bool {
+	acc.pendingMu.Lock()
+	defer acc.pendingMu.Unlock()
+
+	if _, ok := acc.pending[orderID]; ok {
+		return false
+	}
+	acc.pending[orderID] = struct{}{}
+
+	return true
+}
+
+// finishPending forgets order that has got final result
+func (acc *Accrual) finishPending(orderID string) {
+	acc.pendingMu.Lock()
+	defer acc.pendingMu.Unlock()
+
+	delete(acc.pending, orderID)
+}
+
+// publish notifies owner of the order that its status has changed, along with
+// new balance if accrual has been credited. Status has been stored by then,
+// so failures are only logged.
+func (acc *Accrual) publish(ctx context.Context, orderID string, credited bool) {
+	order, err := acc.storage.FetchOrder(ctx, orderID)
+	if err != nil {
+		acc.log(ctx).Err(err).Msgf("failed to fetch order <%s> to publish", orderID)
+		return
+	}
+
+	acc.events.Publish(order.UserID, events.TypeOrder, *order)
+
+	if !credited {
+		return
+	}
+
+	balance, err := acc.storage.UserBalance(ctx, order.UserID)
+	if err != nil {
+		acc.log(ctx).Err(err).Msgf("failed to fetch balance of user %d to publish", order.UserID)
+		return
+	}
+
+	acc.events.Publish(order.UserID, events.TypeBalance, *balance)
+}
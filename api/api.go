@@ -2,18 +2,59 @@ package api
 
 import (
 	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/soundrussian/go-practicum-diploma/pkg/events"
+	"github.com/soundrussian/go-practicum-diploma/pkg/health"
+	"github.com/soundrussian/go-practicum-diploma/pkg/ratelimit"
 	"github.com/soundrussian/go-practicum-diploma/service/auth"
 	"github.com/soundrussian/go-practicum-diploma/service/balance"
 	"github.com/soundrussian/go-practicum-diploma/service/order"
+	"github.com/soundrussian/go-practicum-diploma/service/webhook"
+	"net/http"
+	"sync"
+	"sync/atomic"
 )
 
 type API struct {
+	config Config
+	// tokenAuth verifies session tokens issued by auth service
+	tokenAuth      *jwtauth.JWTAuth
 	authService    auth.Auth
 	balanceService balance.Balance
 	orderService   order.Order
+	webhookService webhook.Webhook
+	// readinessChecks are run by /readyz
+	readinessChecks []health.Check
+	// shuttingDown is set to 1 once server starts shutting down, failing readiness
+	shuttingDown int32
+	// server is set by Start
+	server *http.Server
+	// spec is OpenAPI document served at /openapi.json,
+	// specRouter matches requests to its operations for validation
+	spec       *openapi3.T
+	specRouter routers.Router
+	// events is bus /api/user/events subscribes to, see SetEventBus
+	events *events.Bus
+	// streamsDone is closed on shutdown to end event streams,
+	// which would otherwise keep server from shutting down
+	streamsDone      chan struct{}
+	closeStreamsOnce sync.Once
+	// rateLimits keeps buckets of rate limits, see SetRateLimitStore
+	rateLimits ratelimit.Store
+	// limits holds RateLimitConfig routes are limited by, see SetRateLimits
+	limits atomic.Value
 }
 
-func New(auth auth.Auth, balance balance.Balance, order order.Order) (*API, error) {
+func New(cfg Config, tokenAuth *jwtauth.JWTAuth, auth auth.Auth, balance balance.Balance, order order.Order, webhook webhook.Webhook) (*API, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid API config: %w", err)
+	}
+	if tokenAuth == nil {
+		return nil, errors.New("nil token auth passed to API constructor")
+	}
 	if auth == nil {
 		return nil, errors.New("nil auth service passed to API constructor")
 	}
@@ -23,12 +64,69 @@ func New(auth auth.Auth, balance balance.Balance, order order.Order) (*API, erro
 	if order == nil {
 		return nil, errors.New("nil order service passed to API constructor")
 	}
+	if webhook == nil {
+		return nil, errors.New("nil webhook service passed to API constructor")
+	}
+
+	spec, specRouter, err := loadSpec()
+	if err != nil {
+		return nil, err
+	}
 
 	api := &API{
+		config:         cfg,
+		tokenAuth:      tokenAuth,
 		authService:    auth,
 		balanceService: balance,
 		orderService:   order,
+		webhookService: webhook,
+		spec:           spec,
+		specRouter:     specRouter,
+		events:         events.NewBus(events.DefaultHistorySize),
+		streamsDone:    make(chan struct{}),
+		rateLimits:     ratelimit.NewMemoryStore(),
 	}
+	api.limits.Store(cfg.RateLimit)
 
 	return api, nil
 }
+
+// SetEventBus replaces bus that event streams of users are fed from with the one services publish to
+func (api *API) SetEventBus(bus *events.Bus) {
+	api.events = bus
+}
+
+// SetRateLimitStore replaces in-memory store of rate limit buckets, e.g. with one shared by all instances.
+// It must be called before Start.
+func (api *API) SetRateLimitStore(store ratelimit.Store) {
+	api.rateLimits = store
+}
+
+// SetRateLimits replaces limits of routes while the server is running.
+// Store of buckets is chosen on start and is not changed.
+func (api *API) SetRateLimits(limits RateLimitConfig) error {
+	limits.Store = api.config.RateLimit.Store
+	if err := limits.Validate(); err != nil {
+		return fmt.Errorf("invalid rate limits: %w", err)
+	}
+
+	api.limits.Store(limits)
+	return nil
+}
+
+// rateLimit returns function that reads limit from current limits, see SetRateLimits
+func (api *API) rateLimit(limit func(limits RateLimitConfig) ratelimit.Limit) func() ratelimit.Limit {
+	return func() ratelimit.Limit {
+		return limit(api.limits.Load().(RateLimitConfig))
+	}
+}
+
+// closeStreams ends all active event streams
+func (api *API) closeStreams() {
+	api.closeStreamsOnce.Do(func() { close(api.streamsDone) })
+}
+
+// AddReadinessCheck adds checks of dependencies API needs to serve requests
+func (api *API) AddReadinessCheck(checks ...health.Check) {
+	api.readinessChecks = append(api.readinessChecks, checks...)
+}
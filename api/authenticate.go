@@ -20,7 +20,7 @@ func (api *API) authenticate(user *model.User, w http.ResponseWriter, r *http.Re
 	token, err := api.authService.AuthToken(r.Context(), user)
 	if err != nil {
 		logger.Err(err).Msg("failed to get auth token for user")
-		http.Error(w, "user has been registered, but failed to log in", http.StatusInternalServerError)
+		respondWithError(w, r, errInternal)
 		return
 	}
 
@@ -35,8 +35,8 @@ func (api *API) authenticate(user *model.User, w http.ResponseWriter, r *http.Re
 	response := authenticateJSONResponse{Token: *token}
 	encoder := json.NewEncoder(w)
 	if err := encoder.Encode(&response); err != nil {
-		logger.Err(err).Msgf("failed to encode json response from %+v", response)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logger.Err(err).Msg("failed to encode json response")
+		respondWithError(w, r, err)
 		return
 	}
 }
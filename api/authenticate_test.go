@@ -0,0 +1,47 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_authenticate_DoesNotLogTokenIfResponseFails(t *testing.T) {
+	issued := token(100)
+	auth := new(authMock.Auth)
+	auth.On("AuthToken", mock.Anything, mock.Anything).Return(&issued, nil)
+
+	a, err := New(DefaultConfig(), testAuth.TokenAuth(), auth, new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	r := httptest.NewRequest(http.MethodPost, "/api/user/login", nil)
+	r = r.WithContext(logging.SetCtxLogger(r.Context(), zerolog.New(&out)))
+
+	a.authenticate(&model.User{ID: 100}, failingWriter{httptest.NewRecorder()}, r)
+
+	assert.Contains(t, out.String(), "failed to encode json response")
+	assert.NotContains(t, out.String(), issued)
+}
+
+// failingWriter fails to write response body, e.g. when client has gone
+type failingWriter struct {
+	http.ResponseWriter
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
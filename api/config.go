@@ -1,54 +1,194 @@
 package api
 
 import (
-	"flag"
-	"os"
+	"errors"
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/pkg/ratelimit"
 	"time"
 )
 
 // Config contains API settings needed to run gophermart server
 type Config struct {
 	// RunAddress contains host and port to run server on
-	RunAddress            string
-	ServerShutdownTimeout time.Duration
+	RunAddress string `yaml:"run_address" toml:"run_address"`
+	// ServerShutdownTimeout is how long active connections may take to finish on shutdown
+	ServerShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// ReadinessDrainDelay is how long readiness fails before server shutdown begins
+	ReadinessDrainDelay time.Duration `yaml:"readiness_drain_delay" toml:"readiness_drain_delay"`
+	// AccessLogSampleRate is the share of successful requests written to access log,
+	// from 0 (none) to 1 (all). Failed requests are always logged.
+	AccessLogSampleRate float64 `yaml:"access_log_sample_rate" toml:"access_log_sample_rate"`
+	// OrderBatchLimit is how many order numbers may be uploaded in a single batch
+	OrderBatchLimit int `yaml:"order_batch_limit" toml:"order_batch_limit"`
+	// ReadHeaderTimeout is how long clients may take to send request headers,
+	// so that slow clients cannot hold connections open
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	// IdleTimeout is how long keep-alive connections are kept open waiting for the next request
+	IdleTimeout time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// MaxHeaderBytes limits size of request line and headers
+	MaxHeaderBytes int `yaml:"max_header_bytes" toml:"max_header_bytes"`
+	// TLS makes server serve HTTPS and HTTP/2 once certificate is set
+	TLS TLSConfig `yaml:"tls" toml:"tls"`
+	// MaxBodySize limits size of request body, once decompressed if it is compressed
+	MaxBodySize int64 `yaml:"max_body_size" toml:"max_body_size"`
+	// RateLimit limits how often clients may call routes prone to abuse
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
 }
 
-// config is a pointer to API config.
-// It is a pointer to simplify testing setting config from env and command-line flags
-var config *Config
+// TLSConfig contains certificate server is run with
+type TLSConfig struct {
+	// CertFile is PEM encoded certificate, followed by intermediate ones if any. Server runs
+	// plain HTTP if it is empty.
+	CertFile string `yaml:"cert_file" toml:"cert_file"`
+	// KeyFile is PEM encoded key of the certificate
+	KeyFile string `yaml:"key_file" toml:"key_file"`
+	// ClientCAFile is PEM encoded CA certificates that issue client certificates. Once set,
+	// admin and metrics routes are only served to clients presenting a certificate it issued.
+	ClientCAFile string `yaml:"client_ca_file" toml:"client_ca_file"`
+	// ReloadInterval is how often certificate files are checked for changes
+	ReloadInterval time.Duration `yaml:"reload_interval" toml:"reload_interval"`
+}
 
-const defaultRunAddress = "localhost:8080"
-const defaultServerShutdown = 5 * time.Second
+// Enabled reports whether server runs HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
 
-const runAddressEnvKey = "RUN_ADDRESS"
-const runAddressFlagName = "a"
+// Validate returns error if certificate is set partially
+func (c TLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("TLS certificate and key files must be set together")
+	}
+	if c.ClientCAFile != "" && !c.Enabled() {
+		return errors.New("TLS client CA file requires certificate and key files")
+	}
+	if c.ReloadInterval <= 0 {
+		return errors.New("TLS reload interval must be positive")
+	}
+
+	return nil
+}
 
-func init() {
-	// Config from env is read by a separate function so that we could call it once
-	// again in tests, after setting env variable inside the test
-	readConfig()
+// Stores rate limit buckets can be kept in
+const (
+	// RateLimitStoreMemory keeps buckets in memory of each instance
+	RateLimitStoreMemory = "memory"
+	// RateLimitStorePostgres keeps buckets in database, so that limits hold across instances
+	RateLimitStorePostgres = "postgres"
+)
 
-	flag.StringVar(&config.RunAddress, runAddressFlagName, config.RunAddress, "address to run gophermart on")
+// RateLimitConfig contains limits of route groups, each turned off if its requests are zero
+type RateLimitConfig struct {
+	// Store is where buckets are kept, memory or postgres
+	Store string `yaml:"store" toml:"store"`
+	// Auth limits registration and login attempts made from an IP
+	Auth ratelimit.Limit `yaml:"auth" toml:"auth"`
+	// Orders limits uploads of orders by a user, single and batch ones together
+	Orders ratelimit.Limit `yaml:"orders" toml:"orders"`
+	// Withdraw limits withdrawals by a user
+	Withdraw ratelimit.Limit `yaml:"withdraw" toml:"withdraw"`
 }
 
-// readConfig initializes config if it is not yet set,
-// then reads env variable from runAddressEnvKey
-// if the variable is set and config has just been initialized
-func readConfig() {
-	// If config has not been set yet, initialize it
-	if config == nil {
-		config = &Config{
-			RunAddress:            defaultRunAddress,
-			ServerShutdownTimeout: defaultServerShutdown,
+// Validate returns error if any of the limits is invalid
+func (c RateLimitConfig) Validate() error {
+	if c.Store != RateLimitStoreMemory && c.Store != RateLimitStorePostgres {
+		return fmt.Errorf("rate limit store must be %s or %s", RateLimitStoreMemory, RateLimitStorePostgres)
+	}
+
+	limits := []struct {
+		name  string
+		limit ratelimit.Limit
+	}{
+		{"auth", c.Auth},
+		{"orders", c.Orders},
+		{"withdraw", c.Withdraw},
+	}
+	for _, l := range limits {
+		if err := l.limit.Validate(); err != nil {
+			return fmt.Errorf("%s rate limit: %w", l.name, err)
 		}
 	}
 
-	// Here we check for two things:
-	// 1. There is runAddressEnvKey environment variable
-	// 2. config.RunAddress equals defaultRunAddress, which means
-	// that it has just been initialized and was not overwritten previously
-	// by flag.Parse(). This is required for tests.
-	if address, ok := os.LookupEnv(runAddressEnvKey); ok && config.RunAddress == defaultRunAddress {
-		config.RunAddress = address
+	return nil
+}
+
+const defaultRunAddress = "localhost:8080"
+const defaultServerShutdown = 5 * time.Second
+
+const defaultAccessLogSampleRate = 1.0
+const defaultReadinessDrainDelay = 5 * time.Second
+const defaultOrderBatchLimit = 1000
+const defaultMaxBodySize = 1 << 20
+const defaultReadHeaderTimeout = 5 * time.Second
+const defaultIdleTimeout = 2 * time.Minute
+const defaultMaxHeaderBytes = 1 << 20
+const defaultTLSReloadInterval = time.Minute
+
+var (
+	defaultAuthRateLimit     = ratelimit.Limit{Requests: 20, Period: time.Minute}
+	defaultOrdersRateLimit   = ratelimit.Limit{Requests: 60, Period: time.Minute}
+	defaultWithdrawRateLimit = ratelimit.Limit{Requests: 10, Period: time.Minute}
+)
+
+// DefaultConfig returns API settings used unless they are overridden
+func DefaultConfig() Config {
+	return Config{
+		RunAddress:            defaultRunAddress,
+		ServerShutdownTimeout: defaultServerShutdown,
+		AccessLogSampleRate:   defaultAccessLogSampleRate,
+		ReadinessDrainDelay:   defaultReadinessDrainDelay,
+		OrderBatchLimit:       defaultOrderBatchLimit,
+		MaxBodySize:           defaultMaxBodySize,
+		ReadHeaderTimeout:     defaultReadHeaderTimeout,
+		IdleTimeout:           defaultIdleTimeout,
+		MaxHeaderBytes:        defaultMaxHeaderBytes,
+		TLS: TLSConfig{
+			ReloadInterval: defaultTLSReloadInterval,
+		},
+		RateLimit: RateLimitConfig{
+			Store:    RateLimitStoreMemory,
+			Auth:     defaultAuthRateLimit,
+			Orders:   defaultOrdersRateLimit,
+			Withdraw: defaultWithdrawRateLimit,
+		},
+	}
+}
+
+// Validate returns error if any of the settings is invalid
+func (c Config) Validate() error {
+	if c.RunAddress == "" {
+		return errors.New("run address is empty")
+	}
+	if c.ServerShutdownTimeout <= 0 {
+		return errors.New("shutdown timeout must be positive")
+	}
+	if c.ReadinessDrainDelay < 0 {
+		return errors.New("readiness drain delay must not be negative")
+	}
+	if c.AccessLogSampleRate < 0 || c.AccessLogSampleRate > 1 {
+		return errors.New("access log sample rate must be between 0 and 1")
 	}
+	if c.OrderBatchLimit <= 0 {
+		return errors.New("order batch limit must be positive")
+	}
+	if c.MaxBodySize <= 0 {
+		return errors.New("max body size must be positive")
+	}
+	if c.ReadHeaderTimeout <= 0 {
+		return errors.New("read header timeout must be positive")
+	}
+	if c.IdleTimeout <= 0 {
+		return errors.New("idle timeout must be positive")
+	}
+	if c.MaxHeaderBytes <= 0 {
+		return errors.New("max header bytes must be positive")
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return err
+	}
+	if err := c.RateLimit.Validate(); err != nil {
+		return err
+	}
+
+	return nil
 }
@@ -1,65 +1,125 @@
 package api
 
 import (
-	"flag"
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 	"testing"
-)
+	"time"
 
-func TestAPIConfig_RunAddress(t *testing.T) {
-	type args struct {
-		Env  string
-		Flag string
-	}
+	"github.com/stretchr/testify/assert"
+)
 
+func TestConfig_Validate(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
-		want string
+		name    string
+		modify  func(c *Config)
+		wantErr bool
 	}{
 		{
-			name: "sets defaultRunAddress by default",
-			args: args{},
-			want: defaultRunAddress,
+			name:   "accepts default config",
+			modify: func(c *Config) {},
 		},
 		{
-			name: "sets value from env if it is set",
-			args: args{
-				Env: "localhost:3333",
-			},
-			want: "localhost:3333",
+			name:    "rejects empty run address",
+			modify:  func(c *Config) { c.RunAddress = "" },
+			wantErr: true,
 		},
 		{
-			name: "sets value from flag if it is set",
-			args: args{
-				Flag: "localhost:4444",
-			},
-			want: "localhost:4444",
+			name:    "rejects zero shutdown timeout",
+			modify:  func(c *Config) { c.ServerShutdownTimeout = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects negative readiness drain delay",
+			modify:  func(c *Config) { c.ReadinessDrainDelay = -time.Second },
+			wantErr: true,
+		},
+		{
+			name:   "accepts zero readiness drain delay",
+			modify: func(c *Config) { c.ReadinessDrainDelay = 0 },
+		},
+		{
+			name:    "rejects sample rate above 1",
+			modify:  func(c *Config) { c.AccessLogSampleRate = 1.5 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects negative sample rate",
+			modify:  func(c *Config) { c.AccessLogSampleRate = -0.1 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero order batch limit",
+			modify:  func(c *Config) { c.OrderBatchLimit = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero max body size",
+			modify:  func(c *Config) { c.MaxBodySize = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero read header timeout",
+			modify:  func(c *Config) { c.ReadHeaderTimeout = 0 },
+			wantErr: true,
 		},
 		{
-			name: "sets value from flag if both flag and env are set",
-			args: args{
-				Env:  "localhost:3333",
-				Flag: "localhost:4444",
+			name:    "rejects zero idle timeout",
+			modify:  func(c *Config) { c.IdleTimeout = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero max header bytes",
+			modify:  func(c *Config) { c.MaxHeaderBytes = 0 },
+			wantErr: true,
+		},
+		{
+			name: "accepts TLS certificate",
+			modify: func(c *Config) {
+				c.TLS.CertFile = "tls.crt"
+				c.TLS.KeyFile = "tls.key"
+				c.TLS.ClientCAFile = "ca.crt"
 			},
-			want: "localhost:4444",
+		},
+		{
+			name:    "rejects TLS certificate without key",
+			modify:  func(c *Config) { c.TLS.CertFile = "tls.crt" },
+			wantErr: true,
+		},
+		{
+			name:    "rejects TLS client CA without certificate",
+			modify:  func(c *Config) { c.TLS.ClientCAFile = "ca.crt" },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero TLS reload interval",
+			modify:  func(c *Config) { c.TLS.ReloadInterval = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects unknown rate limit store",
+			modify:  func(c *Config) { c.RateLimit.Store = "redis" },
+			wantErr: true,
+		},
+		{
+			name:    "rejects rate limit without period",
+			modify:  func(c *Config) { c.RateLimit.Withdraw.Period = 0 },
+			wantErr: true,
+		},
+		{
+			name:   "accepts turned off rate limit",
+			modify: func(c *Config) { c.RateLimit.Orders.Requests = 0 },
 		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.args.Env != "" {
-				t.Setenv(runAddressEnvKey, tt.args.Env)
-			}
-			if tt.args.Flag != "" {
-				err := flag.Set(runAddressFlagName, tt.args.Flag)
-				require.NoError(t, err)
-			}
-			flag.Parse()
-			readConfig()
+			c := DefaultConfig()
+			tt.modify(&c)
 
-			assert.Equal(t, tt.want, config.RunAddress)
+			err := c.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
 		})
 	}
 }
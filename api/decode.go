@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// decodeJSON strictly decodes JSON request body into v. Body must hold a single JSON value
+// without fields v does not have, and fields of v tagged `required:"true"` must be present
+// and not null. Returned errors tell clients which field is wrong. Errors of reading the body,
+// such as the one of body exceeding limit of LimitBody, are returned as they are.
+func decodeJSON(r *http.Request, v interface{}) error {
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var bodyErr *apperr.Error
+		if errors.As(err, &bodyErr) {
+			return bodyErr
+		}
+		return apperr.Wrap(errInvalidJSON, err)
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return errInvalidJSON.WithPublic("request body is empty")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return decodeError(err)
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errInvalidJSON.WithPublic("request body has data after JSON value")
+	}
+
+	return checkRequired(body, v)
+}
+
+// decodeError describes error of json.Decoder to the client
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field == "" {
+			return apperr.Wrap(errInvalidJSONField, err).WithPublic(fmt.Sprintf("request body must be %s", jsonType(typeErr.Type)))
+		}
+		return apperr.Wrap(errInvalidJSONField, err).WithPublic(fmt.Sprintf("field %q must be %s", typeErr.Field, jsonType(typeErr.Type)))
+	}
+
+	// json.Decoder reports unknown fields with plain error
+	if field := strings.TrimPrefix(err.Error(), "json: unknown field "); field != err.Error() {
+		return apperr.Wrap(errUnknownJSONField, err).WithPublic(fmt.Sprintf("field %s is not allowed", field))
+	}
+
+	return apperr.Wrap(errInvalidJSON, err)
+}
+
+// jsonType names JSON type values of t are decoded from
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return jsonType(t.Elem())
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+
+	return t.String()
+}
+
+// checkRequired returns error if body, which has been decoded into v, lacks any of the fields
+// of v tagged `required:"true"` or has them set to null
+func checkRequired(body []byte, v interface{}) error {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("required") != "true" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+
+		if fields == nil {
+			if err := json.Unmarshal(body, &fields); err != nil {
+				return apperr.Wrap(errInvalidJSON, err)
+			}
+		}
+
+		if !present(fields, name) {
+			return errMissingJSONField.WithPublic(fmt.Sprintf("field %q is required", name))
+		}
+	}
+
+	return nil
+}
+
+// present reports whether fields have non-null field name, matched case-insensitively as json.Unmarshal does
+func present(fields map[string]json.RawMessage, name string) bool {
+	for key, value := range fields {
+		if strings.EqualFold(key, name) && string(value) != "null" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type decodeTestRequest struct {
+	Order    string          `json:"order" required:"true"`
+	Sum      decimal.Decimal `json:"sum" required:"true"`
+	Quantity int             `json:"quantity"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       io.Reader
+		want       decodeTestRequest
+		wantReason string
+		wantDetail string
+	}{
+		{
+			name: "decodes body",
+			body: strings.NewReader(`{"order": "79927398713", "sum": 10.5, "quantity": 2}`),
+			want: decodeTestRequest{Order: "79927398713", Sum: decimal.RequireFromString("10.5"), Quantity: 2},
+		},
+		{
+			name: "accepts trailing whitespace",
+			body: strings.NewReader("{\"order\": \"79927398713\", \"sum\": 10}\n\n"),
+			want: decodeTestRequest{Order: "79927398713", Sum: decimal.NewFromInt(10)},
+		},
+		{
+			name: "matches field names case-insensitively",
+			body: strings.NewReader(`{"Order": "79927398713", "SUM": 10}`),
+			want: decodeTestRequest{Order: "79927398713", Sum: decimal.NewFromInt(10)},
+		},
+		{
+			name:       "rejects empty body",
+			body:       strings.NewReader(" \n"),
+			wantReason: "invalid_json",
+			wantDetail: "request body is empty",
+		},
+		{
+			name:       "rejects malformed JSON",
+			body:       strings.NewReader(`{"order": `),
+			wantReason: "invalid_json",
+			wantDetail: "request body is not valid JSON",
+		},
+		{
+			name:       "rejects data after JSON value",
+			body:       strings.NewReader(`{"order": "79927398713", "sum": 10}"`),
+			wantReason: "invalid_json",
+			wantDetail: "request body has data after JSON value",
+		},
+		{
+			name:       "rejects second JSON value",
+			body:       strings.NewReader(`{"order": "79927398713", "sum": 10} {}`),
+			wantReason: "invalid_json",
+			wantDetail: "request body has data after JSON value",
+		},
+		{
+			name:       "rejects unknown field",
+			body:       strings.NewReader(`{"order": "79927398713", "sum": 10, "currency": "USD"}`),
+			wantReason: "unknown_field",
+			wantDetail: `field "currency" is not allowed`,
+		},
+		{
+			name:       "rejects missing required field",
+			body:       strings.NewReader(`{"sum": 10}`),
+			wantReason: "missing_field",
+			wantDetail: `field "order" is required`,
+		},
+		{
+			name:       "rejects null required field",
+			body:       strings.NewReader(`{"order": "79927398713", "sum": null}`),
+			wantReason: "missing_field",
+			wantDetail: `field "sum" is required`,
+		},
+		{
+			name:       "rejects field of wrong type",
+			body:       strings.NewReader(`{"order": 79927398713, "sum": 10}`),
+			wantReason: "invalid_field",
+			wantDetail: `field "order" must be a string`,
+		},
+		{
+			name:       "rejects fraction in integer field",
+			body:       strings.NewReader(`{"order": "79927398713", "sum": 10, "quantity": 1.5}`),
+			wantReason: "invalid_field",
+			wantDetail: `field "quantity" must be an integer`,
+		},
+		{
+			name:       "rejects body of wrong type",
+			body:       strings.NewReader(`["79927398713"]`),
+			wantReason: "invalid_field",
+			wantDetail: "request body must be an object",
+		},
+		{
+			name:       "returns error of body reader as it is",
+			body:       io.MultiReader(strings.NewReader(`{"order": `), errReader{apperr.New(apperr.CodeTooLarge, "too large").WithReason("body_too_large")}),
+			wantReason: "body_too_large",
+			wantDetail: "too large",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", tt.body)
+
+			var got decodeTestRequest
+			err := decodeJSON(r, &got)
+
+			if tt.wantReason == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want.Order, got.Order)
+				assert.True(t, tt.want.Sum.Equal(got.Sum), "sum %s", got.Sum)
+				assert.Equal(t, tt.want.Quantity, got.Quantity)
+				return
+			}
+
+			require.Error(t, err)
+			assert.Equal(t, tt.wantReason, apperr.Reason(err))
+			assert.Equal(t, tt.wantDetail, apperr.PublicMessage(err))
+		})
+	}
+}
+
+func TestAPI_BodyLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodySize = 64
+
+	a, err := New(cfg, testAuth.TokenAuth(), new(authMock.Auth), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+	require.NoError(t, err)
+
+	ts := newTestServer(t, a)
+	defer ts.Close()
+
+	// Body of unknown length is sent chunked, so that it is only limited while being read
+	body := io.MultiReader(strings.NewReader(`{"login":"user","password":"` + strings.Repeat("a", 100) + `"}`))
+	resp, err := http.Post(ts.URL+"/api/user/login", "application/json", body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assertProblem(t, resp, "body_too_large", "request body may be at most 64 bytes")
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPI_CompressedBody(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodySize = 1 << 10
+
+	orders := orderSuccess()
+	a, err := New(cfg, testAuth.TokenAuth(), activeUser(new(authMock.Auth)), new(balanceMock.Balance), orders, new(webhookMock.Webhook))
+	require.NoError(t, err)
+
+	ts := newTestServer(t, a)
+	defer ts.Close()
+
+	upload := func(body string, encoding string) *http.Response {
+		t.Helper()
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte(body))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/user/orders", &buf)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "text/plain")
+		req.Header.Set("Content-Encoding", encoding)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token(100)))
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+
+		return resp
+	}
+
+	resp := upload("79927398713", "gzip")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	orders.AssertCalled(t, "AcceptOrder", mock.Anything, uint64(100), "79927398713")
+
+	resp = upload(strings.Repeat("7", 1<<20), "gzip")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assertProblem(t, resp, "body_too_large", "request body may be at most 1024 bytes once decompressed")
+
+	resp = upload("79927398713", "compress")
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	assert.Equal(t, "gzip, deflate, br", resp.Header.Get("Accept-Encoding"))
+	assertProblem(t, resp, "unsupported_content_encoding", "Content-Encoding of request body must be one of gzip, deflate, br")
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"net/http"
+)
+
+var (
+	errInvalidJSON      = apperr.New(apperr.CodeInvalidArgument, "request body is not valid JSON").WithReason("invalid_json")
+	errInvalidJSONField = apperr.New(apperr.CodeInvalidArgument, "request body has field of wrong type").WithReason("invalid_field")
+	errUnknownJSONField = apperr.New(apperr.CodeInvalidArgument, "request body has unknown field").WithReason("unknown_field")
+	errMissingJSONField = apperr.New(apperr.CodeInvalidArgument, "request body lacks required field").WithReason("missing_field")
+	errUnauthenticated  = apperr.New(apperr.CodeUnauthenticated, "unauthorized").WithReason("unauthenticated")
+	errInternal         = apperr.New(apperr.CodeInternal, "internal error")
+
+	errEmptyBatch    = apperr.New(apperr.CodeInvalidArgument, "batch contains no order numbers").WithReason("empty_batch")
+	errBatchTooLarge = apperr.New(apperr.CodeTooLarge, "batch contains too many order numbers").WithReason("batch_too_large")
+	errInvalidBatch  = apperr.New(apperr.CodeInvalidArgument, "batch is not a list of order numbers one per line").WithReason("invalid_batch")
+
+	errInvalidLastEventID = apperr.New(apperr.CodeInvalidArgument, "Last-Event-ID must be ID of an event received earlier").WithReason("invalid_last_event_id")
+)
+
+// respondWithError is the single place where errors returned by services
+// are translated to HTTP responses. Status is taken from error code,
+// and only the public message and reason of the error are sent to the client
+// as problem details, see problem.Write.
+func respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	problem.Write(w, r, err)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"github.com/soundrussian/go-practicum-diploma/service/balance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRespondWithError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{
+			name:   "tells insufficient funds by code",
+			err:    balance.ErrNotEnoughBalance,
+			status: http.StatusPaymentRequired,
+			code:   "insufficient_funds",
+			detail: "not enough balance",
+		},
+		{
+			name:   "tells invalid order number by code",
+			err:    balance.ErrInvalidOrder,
+			status: http.StatusUnprocessableEntity,
+			code:   "invalid_order_number",
+			detail: "invalid order number",
+		},
+		{
+			name:   "hides cause of the error",
+			err:    apperr.Wrap(errInvalidJSON, errors.New("invalid character 'o' in literal null")),
+			status: http.StatusBadRequest,
+			code:   "invalid_json",
+			detail: "request body is not valid JSON",
+		},
+		{
+			name:   "hides internal errors",
+			err:    apperr.Wrap(balance.ErrInternalError, errors.New("pq: connection refused")),
+			status: http.StatusInternalServerError,
+			code:   "internal",
+			detail: "internal error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", nil)
+			r = r.WithContext(logging.SetCorrelationID(r.Context(), "correlation-id"))
+			w := httptest.NewRecorder()
+
+			respondWithError(w, r, tt.err)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.status, res.StatusCode)
+			got := assertProblem(t, res, tt.code, tt.detail)
+			assert.Equal(t, tt.status, got.Status)
+			assert.Equal(t, "correlation-id", got.CorrelationID)
+			assert.Equal(t, "/api/user/balance/withdraw", got.Instance)
+		})
+	}
+}
+
+// assertProblem checks that response carries problem details with given code and detail
+func assertProblem(t *testing.T, res *http.Response, code string, detail string) problem.Details {
+	t.Helper()
+
+	assert.Equal(t, problem.ContentType, res.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	var got problem.Details
+	require.NoError(t, json.Unmarshal(body, &got), "response body is not problem details: %s", body)
+
+	assert.Equal(t, code, got.Code)
+	assert.Equal(t, "urn:gophermart:problem:"+code, got.Type)
+	assert.Equal(t, detail, got.Detail)
+	assert.Equal(t, res.StatusCode, got.Status)
+	assert.NotEmpty(t, got.CorrelationID)
+
+	return got
+}
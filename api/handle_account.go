@@ -0,0 +1,171 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"net/http"
+	"time"
+)
+
+type profileResponse struct {
+	ID               uint64 `json:"id"`
+	Login            string `json:"login"`
+	Role             string `json:"role"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+}
+
+type ledgerResponse struct {
+	Order     string  `json:"order"`
+	Amount    float64 `json:"amount"`
+	CreatedAt string  `json:"created_at"`
+}
+
+type exportResponse struct {
+	Profile    profileResponse  `json:"profile"`
+	Orders     []orderResponse  `json:"orders"`
+	Ledger     []ledgerResponse `json:"ledger"`
+	ExportedAt string           `json:"exported_at"`
+}
+
+func ledgerResponseFromModel(model model.Transaction) ledgerResponse {
+	amount, _ := model.Amount.RoundBank(2).Float64()
+
+	return ledgerResponse{
+		Order:     model.OrderID,
+		Amount:    amount,
+		CreatedAt: model.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// HandleExport returns all personal data of the current user: profile, orders and ledger.
+// Data is returned as JSON document, or as ZIP archive with a JSON file per section
+// if format=zip query parameter is given.
+func (api *API) HandleExport(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "export").Logger()
+	logger.Info().Msg("handling export")
+
+	userID, err := curruser.CurrentUser(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get current user from context")
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
+		return
+	}
+
+	user, err := api.authService.User(ctx, userID)
+	if err != nil {
+		logger.Err(err).Msgf("failed to get user %d", userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	orders, err := api.orderService.UserOrders(ctx, userID)
+	if err != nil {
+		logger.Err(err).Msgf("failed getting orders for user %d", userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	transactions, err := api.balanceService.Transactions(ctx, userID)
+	if err != nil {
+		logger.Err(err).Msgf("failed getting transactions for user %d", userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	export := exportResponse{
+		Profile: profileResponse{
+			ID:               user.ID,
+			Login:            user.Login,
+			Role:             string(user.Role),
+			TwoFactorEnabled: user.TOTPEnabled,
+		},
+		Orders:     make([]orderResponse, 0, len(orders)),
+		Ledger:     make([]ledgerResponse, 0, len(transactions)),
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, order := range orders {
+		export.Orders = append(export.Orders, orderResponseFromModel(order))
+	}
+	for _, transaction := range transactions {
+		export.Ledger = append(export.Ledger, ledgerResponseFromModel(transaction))
+	}
+
+	if r.URL.Query().Get("format") == "zip" {
+		api.writeExportZip(w, r, export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="gophermart-export.json"`)
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&export); err != nil {
+		logger.Err(err).Msg("failed to encode export")
+		return
+	}
+}
+
+func (api *API) writeExportZip(w http.ResponseWriter, r *http.Request, export exportResponse) {
+	_, logger := logging.CtxLogger(r.Context())
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="gophermart-export.zip"`)
+
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{name: "profile.json", content: export.Profile},
+		{name: "orders.json", content: export.Orders},
+		{name: "ledger.json", content: export.Ledger},
+	}
+
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			logger.Err(err).Msgf("failed to add %s to export archive", file.name)
+			return
+		}
+
+		if err := json.NewEncoder(f).Encode(file.content); err != nil {
+			logger.Err(err).Msgf("failed to write %s to export archive", file.name)
+			return
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		logger.Err(err).Msg("failed to finish export archive")
+	}
+}
+
+// HandleDeleteAccount anonymizes the current user and revokes all their tokens and API keys.
+// Orders and ledger are kept for accounting.
+func (api *API) HandleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "delete_account").Logger()
+	logger.Info().Msg("handling delete account")
+
+	userID, err := curruser.CurrentUser(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get current user from context")
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
+		return
+	}
+
+	if err := api.authService.DeleteAccount(ctx, userID); err != nil {
+		logger.Err(err).Msgf("failed to delete user %d", userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	logger.Info().Msgf("deleted user %d", userID)
+
+	http.SetCookie(w, &http.Cookie{Name: "jwt", Value: "", MaxAge: -1})
+	w.WriteHeader(http.StatusNoContent)
+}
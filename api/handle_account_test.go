@@ -0,0 +1,173 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/service/auth"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// activeUser makes auth mock report every user as active,
+// as ActiveUser middleware checks it on each request to protected routes
+func activeUser(a auth.Auth) auth.Auth {
+	if m, ok := a.(*authMock.Auth); ok {
+		m.On("User", mock.Anything, mock.Anything).Return(func(_ context.Context, userID uint64) *model.User {
+			return &model.User{ID: userID, Role: storedRole(userID)}
+		}, nil)
+	}
+	return a
+}
+
+func TestAPI_Account(t *testing.T) {
+	user := &model.User{ID: 100, Login: "john.doe@example.com", Role: model.RoleUser}
+	uploadedAt := time.Date(2022, 3, 6, 5, 4, 1, 0, time.UTC)
+
+	type args struct {
+		method string
+		path   string
+		auth   *authMock.Auth
+	}
+	type want struct {
+		status      int
+		contentType string
+		body        string
+		files       []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "returns 401 if user has deleted the account",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/user/export",
+				auth:   deletedUserMock(),
+			},
+			want: want{
+				status: http.StatusUnauthorized,
+			},
+		},
+		{
+			name: "returns profile, orders and ledger as JSON",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/user/export",
+				auth:   userMock(user),
+			},
+			want: want{
+				status:      http.StatusOK,
+				contentType: "application/json",
+				body:        `"profile":{"id":100,"login":"john.doe@example.com","role":"user","two_factor_enabled":false},"orders":[{"number":"79927398713","status":"PROCESSED","accrual":500,"uploaded_at":"2022-03-06T05:04:01Z"}],"ledger":[{"order":"79927398713","amount":500,"created_at":"2022-03-06T05:04:01Z"}]`,
+			},
+		},
+		{
+			name: "returns zip archive if asked",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/user/export?format=zip",
+				auth:   userMock(user),
+			},
+			want: want{
+				status:      http.StatusOK,
+				contentType: "application/zip",
+				files:       []string{"profile.json", "orders.json", "ledger.json"},
+			},
+		},
+		{
+			name: "returns 204 when account is deleted",
+			args: args{
+				method: http.MethodDelete,
+				path:   "/api/user",
+				auth:   deleteAccountMock(user),
+			},
+			want: want{
+				status: http.StatusNoContent,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := new(orderMock.Order)
+			orders.On("UserOrders", mock.Anything, uint64(100)).Return([]model.Order{
+				{OrderID: "79927398713", Status: model.OrderProcessed, Accrual: decimal.NewFromInt(500), UploadedAt: uploadedAt},
+			}, nil)
+			balance := new(balanceMock.Balance)
+			balance.On("Transactions", mock.Anything, uint64(100)).Return([]model.Transaction{
+				{OrderID: "79927398713", Amount: decimal.NewFromInt(500), CreatedAt: uploadedAt},
+			}, nil)
+
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), tt.args.auth, balance, orders, new(webhookMock.Webhook))
+			require.NoError(t, err)
+
+			ts := newTestServer(t, a)
+			defer ts.Close()
+
+			req, err := http.NewRequest(tt.args.method, ts.URL+tt.args.path, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token(100)))
+
+			transport := http.Transport{}
+			resp, err := transport.RoundTrip(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.want.status, resp.StatusCode)
+
+			if tt.want.contentType != "" {
+				assert.Equal(t, tt.want.contentType, resp.Header.Get("Content-Type"))
+			}
+
+			resBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			if tt.want.body != "" {
+				assert.Contains(t, string(resBody), tt.want.body)
+			}
+
+			if len(tt.want.files) > 0 {
+				archive, err := zip.NewReader(bytes.NewReader(resBody), int64(len(resBody)))
+				require.NoError(t, err)
+
+				names := make([]string, 0, len(archive.File))
+				for _, f := range archive.File {
+					names = append(names, f.Name)
+				}
+				assert.Equal(t, tt.want.files, names)
+			}
+		})
+	}
+}
+
+func userMock(user *model.User) *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("User", mock.Anything, user.ID).Return(user, nil)
+	return m
+}
+
+func deletedUserMock() *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("User", mock.Anything, mock.Anything).Return(nil, auth.ErrUnknownUser)
+	return m
+}
+
+func deleteAccountMock(user *model.User) *authMock.Auth {
+	m := userMock(user)
+	m.On("DeleteAccount", mock.Anything, user.ID).Return(nil)
+	return m
+}
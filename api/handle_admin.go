@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"net/http"
+	"strconv"
+)
+
+var errInvalidUserID = apperr.New(apperr.CodeInvalidArgument, "user id must be a positive integer").WithReason("invalid_user_id")
+
+type roleJSONRequest struct {
+	Role model.Role `json:"role" required:"true"`
+}
+
+// HandleAdminUserOrders returns orders of the user given in URL
+func (api *API) HandleAdminUserOrders(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "admin_user_orders").Logger()
+	logger.Info().Msg("handling admin user orders")
+
+	userID, err := userIDParam(r)
+	if err != nil {
+		logger.Err(err).Msg("failed to parse user id")
+		respondWithError(w, r, err)
+		return
+	}
+
+	orders, err := api.orderService.UserOrders(ctx, userID)
+	if err != nil {
+		logger.Err(err).Msgf("failed getting orders for user %d", userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	if len(orders) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	response := make([]orderResponse, 0, len(orders))
+	for _, order := range orders {
+		response = append(response, orderResponseFromModel(order))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&response); err != nil {
+		logger.Err(err).Msgf("failed to encode json response from %d orders", len(orders))
+		respondWithError(w, r, err)
+		return
+	}
+}
+
+// HandleAdminUserBalance returns balance of the user given in URL
+func (api *API) HandleAdminUserBalance(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "admin_user_balance").Logger()
+	logger.Info().Msg("handling admin user balance")
+
+	userID, err := userIDParam(r)
+	if err != nil {
+		logger.Err(err).Msg("failed to parse user id")
+		respondWithError(w, r, err)
+		return
+	}
+
+	userBalance, err := api.balanceService.UserBalance(ctx, userID)
+	if err != nil {
+		logger.Err(err).Msgf("failed to get balance for user %d", userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	resp := respFromModel(userBalance)
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&resp); err != nil {
+		logger.Err(err).Msg("failed to encode json response")
+		respondWithError(w, r, err)
+		return
+	}
+}
+
+// HandleAdminUserRole changes role of the user given in URL
+func (api *API) HandleAdminUserRole(w http.ResponseWriter, r *http.Request) {
+	var jsonRequest roleJSONRequest
+
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "admin_user_role").Logger()
+	logger.Info().Msg("handling admin user role")
+
+	userID, err := userIDParam(r)
+	if err != nil {
+		logger.Err(err).Msg("failed to parse user id")
+		respondWithError(w, r, err)
+		return
+	}
+
+	if err := decodeJSON(r, &jsonRequest); err != nil {
+		logger.Err(err).Msgf("failed to parse request body as JSON")
+		respondWithError(w, r, err)
+		return
+	}
+
+	if err := api.authService.SetRole(ctx, userID, jsonRequest.Role); err != nil {
+		logger.Err(err).Msgf("failed to set role %s for user %d", jsonRequest.Role, userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	logger.Info().Msgf("set role %s for user %d", jsonRequest.Role, userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func userIDParam(r *http.Request) (uint64, error) {
+	userID, err := strconv.ParseUint(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil || userID == 0 {
+		return 0, apperr.Wrap(errInvalidUserID, err)
+	}
+
+	return userID, nil
+}
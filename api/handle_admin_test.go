@@ -0,0 +1,276 @@
+package api
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/service/auth"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	"github.com/soundrussian/go-practicum-diploma/service/balance"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	"github.com/soundrussian/go-practicum-diploma/service/order"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPI_Admin(t *testing.T) {
+	type args struct {
+		method  string
+		path    string
+		body    string
+		token   string
+		headers map[string]string
+		auth    auth.Auth
+		balance balance.Balance
+		order   order.Order
+	}
+	type want struct {
+		status int
+		body   string
+	}
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "returns 401 if user is not authorized",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/admin/users/100/balance",
+			},
+			want: want{
+				status: http.StatusUnauthorized,
+			},
+		},
+		{
+			name: "returns 403 if user has no staff role",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/admin/users/100/balance",
+				token:  token(100),
+			},
+			want: want{
+				status: http.StatusForbidden,
+			},
+		},
+		{
+			name: "returns 403 if staff role has been taken away since token was issued",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/admin/users/100/balance",
+				token:  tokenWithRole(100, model.RoleAdmin),
+			},
+			want: want{
+				status: http.StatusForbidden,
+			},
+		},
+		{
+			name: "does not accept api keys",
+			args: args{
+				method:  http.MethodGet,
+				path:    "/api/admin/users/100/balance",
+				headers: map[string]string{"X-API-Key": testAPIKey},
+				auth:    apiKeyAuthMock(model.ScopeBalanceRead),
+			},
+			want: want{
+				status: http.StatusUnauthorized,
+			},
+		},
+		{
+			name: "returns balance of any user to support",
+			args: args{
+				method:  http.MethodGet,
+				path:    "/api/admin/users/100/balance",
+				token:   tokenWithRole(supportUserID, model.RoleSupport),
+				balance: balanceForUserMock(100, decimal.NewFromInt(500), decimal.NewFromInt(42)),
+			},
+			want: want{
+				status: http.StatusOK,
+				body:   `{"current":500,"withdrawn":42}` + "\n",
+			},
+		},
+		{
+			name: "returns 204 if user has no orders",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/admin/users/100/orders",
+				token:  tokenWithRole(adminUserID, model.RoleAdmin),
+				order:  noOrdersMock(),
+			},
+			want: want{
+				status: http.StatusNoContent,
+			},
+		},
+		{
+			name: "returns 400 if user id is invalid",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/admin/users/abc/orders",
+				token:  tokenWithRole(adminUserID, model.RoleAdmin),
+			},
+			want: want{
+				status: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "returns 403 if support tries to change role",
+			args: args{
+				method:  http.MethodPut,
+				path:    "/api/admin/users/100/role",
+				token:   tokenWithRole(supportUserID, model.RoleSupport),
+				headers: map[string]string{"Content-Type": "application/json"},
+				body:    `{"role": "admin"}`,
+			},
+			want: want{
+				status: http.StatusForbidden,
+			},
+		},
+		{
+			name: "returns 404 if admin changes role of unknown user",
+			args: args{
+				method:  http.MethodPut,
+				path:    "/api/admin/users/100/role",
+				token:   tokenWithRole(adminUserID, model.RoleAdmin),
+				headers: map[string]string{"Content-Type": "application/json"},
+				body:    `{"role": "support"}`,
+				auth:    setRoleMock(auth.ErrUnknownUser),
+			},
+			want: want{
+				status: http.StatusNotFound,
+			},
+		},
+		{
+			name: "returns 204 if admin changes role",
+			args: args{
+				method:  http.MethodPut,
+				path:    "/api/admin/users/100/role",
+				token:   tokenWithRole(adminUserID, model.RoleAdmin),
+				headers: map[string]string{"Content-Type": "application/json"},
+				body:    `{"role": "support"}`,
+				auth:    setRoleMock(nil),
+			},
+			want: want{
+				status: http.StatusNoContent,
+			},
+		},
+		{
+			name: "returns 403 if support reads log level",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/admin/log-level",
+				token:  tokenWithRole(supportUserID, model.RoleSupport),
+			},
+			want: want{
+				status: http.StatusForbidden,
+			},
+		},
+		{
+			name: "returns current log level to admin",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/admin/log-level",
+				token:  tokenWithRole(adminUserID, model.RoleAdmin),
+			},
+			want: want{
+				status: http.StatusOK,
+				body:   `{"level":"` + logging.Level().String() + `"}` + "\n",
+			},
+		},
+		{
+			name: "returns 400 if log level is unknown",
+			args: args{
+				method:  http.MethodPut,
+				path:    "/api/admin/log-level",
+				token:   tokenWithRole(adminUserID, model.RoleAdmin),
+				headers: map[string]string{"Content-Type": "application/json"},
+				body:    `{"level": "loud"}`,
+			},
+			want: want{
+				status: http.StatusBadRequest,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.auth == nil {
+				tt.args.auth = new(authMock.Auth)
+			}
+			if tt.args.balance == nil {
+				tt.args.balance = new(balanceMock.Balance)
+			}
+			if tt.args.order == nil {
+				tt.args.order = new(orderMock.Order)
+			}
+
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(tt.args.auth), tt.args.balance, tt.args.order, new(webhookMock.Webhook))
+			require.NoError(t, err)
+
+			ts := newTestServer(t, a)
+			defer ts.Close()
+
+			req, err := http.NewRequest(tt.args.method, ts.URL+tt.args.path, strings.NewReader(tt.args.body))
+			require.NoError(t, err)
+
+			if tt.args.token != "" {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tt.args.token))
+			}
+
+			for header, value := range tt.args.headers {
+				req.Header.Set(header, value)
+			}
+
+			transport := http.Transport{}
+			resp, err := transport.RoundTrip(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			if tt.want.body != "" {
+				resBody, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+
+				assert.Equal(t, tt.want.body, string(resBody))
+			}
+
+			assert.Equal(t, tt.want.status, resp.StatusCode)
+		})
+	}
+}
+
+func setRoleMock(err error) *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("SetRole", mock.Anything, uint64(100), model.RoleSupport).Return(err)
+	return m
+}
+
+func TestAPI_HandleSetLogLevel(t *testing.T) {
+	initial := logging.Level()
+	defer logging.SetLevel(initial)
+
+	a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(new(authMock.Auth)), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+	require.NoError(t, err)
+
+	ts := newTestServer(t, a)
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodPut, ts.URL+"/api/admin/log-level", strings.NewReader(`{"level": "debug"}`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tokenWithRole(adminUserID, model.RoleAdmin)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, zerolog.DebugLevel, logging.Level())
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errInvalidAPIKeyID = apperr.New(apperr.CodeInvalidArgument, "api key id must be a positive integer").WithReason("invalid_api_key_id")
+
+type createAPIKeyJSONRequest struct {
+	Name   string              `json:"name" required:"true"`
+	Scopes []model.APIKeyScope `json:"scopes" required:"true"`
+}
+
+type apiKeyResponse struct {
+	ID        uint64              `json:"id"`
+	Name      string              `json:"name"`
+	Prefix    string              `json:"prefix"`
+	Scopes    []model.APIKeyScope `json:"scopes"`
+	CreatedAt string              `json:"created_at"`
+	RevokedAt string              `json:"revoked_at,omitempty"`
+	// Key is only returned once, when the key is created
+	Key string `json:"key,omitempty"`
+}
+
+func apiKeyResponseFromModel(model model.APIKey) apiKeyResponse {
+	resp := apiKeyResponse{
+		ID:        model.ID,
+		Name:      model.Name,
+		Prefix:    model.Prefix,
+		Scopes:    model.Scopes,
+		CreatedAt: model.CreatedAt.Format(time.RFC3339),
+	}
+	if model.RevokedAt != nil {
+		resp.RevokedAt = model.RevokedAt.Format(time.RFC3339)
+	}
+
+	return resp
+}
+
+func (api *API) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var jsonRequest createAPIKeyJSONRequest
+
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "create_api_key").Logger()
+	logger.Info().Msg("handling create api key")
+
+	if err := decodeJSON(r, &jsonRequest); err != nil {
+		logger.Err(err).Msgf("failed to parse request body as JSON")
+		respondWithError(w, r, err)
+		return
+	}
+
+	userID, err := curruser.CurrentUser(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get current user from context")
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
+		return
+	}
+
+	key, plaintext, err := api.authService.CreateAPIKey(ctx, userID, jsonRequest.Name, jsonRequest.Scopes)
+	if err != nil {
+		logger.Err(err).Msgf("failed to create api key for user %d", userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	logger.Info().Msgf("created api key id=%d for user %d", key.ID, userID)
+
+	resp := apiKeyResponseFromModel(*key)
+	resp.Key = plaintext
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&resp); err != nil {
+		logger.Err(err).Msg("failed to encode json response")
+		return
+	}
+}
+
+func (api *API) HandleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "api_keys").Logger()
+	logger.Info().Msg("handling api keys")
+
+	userID, err := curruser.CurrentUser(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get current user from context")
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
+		return
+	}
+
+	keys, err := api.authService.APIKeys(ctx, userID)
+	if err != nil {
+		logger.Err(err).Msgf("failed getting api keys for user %d", userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	if len(keys) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	response := make([]apiKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, apiKeyResponseFromModel(key))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&response); err != nil {
+		logger.Err(err).Msgf("failed to encode json response from %d api keys", len(keys))
+		respondWithError(w, r, err)
+		return
+	}
+}
+
+func (api *API) HandleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "revoke_api_key").Logger()
+	logger.Info().Msg("handling revoke api key")
+
+	userID, err := curruser.CurrentUser(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get current user from context")
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
+		return
+	}
+
+	keyID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		logger.Err(err).Msg("failed to parse api key id")
+		respondWithError(w, r, apperr.Wrap(errInvalidAPIKeyID, err))
+		return
+	}
+
+	if err := api.authService.RevokeAPIKey(ctx, userID, keyID); err != nil {
+		logger.Err(err).Msgf("failed to revoke api key %d of user %d", keyID, userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
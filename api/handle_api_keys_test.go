@@ -0,0 +1,291 @@
+package api
+
+import (
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/service/auth"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAPIKey = "gm_test-api-key"
+
+func TestAPI_APIKeys(t *testing.T) {
+	type args struct {
+		method  string
+		path    string
+		body    string
+		token   string
+		headers map[string]string
+		auth    auth.Auth
+	}
+	type want struct {
+		status int
+		body   string
+		// code and detail are checked in problem details of error responses
+		code   string
+		detail string
+	}
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "returns 401 if user is not authorized",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/user/api-keys",
+				auth:   new(authMock.Auth),
+			},
+			want: want{
+				status: http.StatusUnauthorized,
+			},
+		},
+		{
+			name: "returns 403 if request is authenticated by api key",
+			args: args{
+				method:  http.MethodGet,
+				path:    "/api/user/api-keys",
+				headers: map[string]string{"X-API-Key": testAPIKey},
+				auth:    apiKeyAuthMock(model.ScopeOrdersWrite),
+			},
+			want: want{
+				status: http.StatusForbidden,
+			},
+		},
+		{
+			name: "returns 201 along with plaintext key when key is created",
+			args: args{
+				method:  http.MethodPost,
+				path:    "/api/user/api-keys",
+				token:   token(100),
+				headers: map[string]string{"Content-Type": "application/json"},
+				body:    `{"name": "POS", "scopes": ["orders:write"]}`,
+				auth:    createAPIKeyMock(),
+			},
+			want: want{
+				status: http.StatusCreated,
+				body:   `{"id":1,"name":"POS","prefix":"gm_test-","scopes":["orders:write"],"created_at":"2022-03-06T05:04:01Z","key":"gm_test-api-key"}` + "\n",
+			},
+		},
+		{
+			name: "returns 400 if scopes are invalid",
+			args: args{
+				method:  http.MethodPost,
+				path:    "/api/user/api-keys",
+				token:   token(100),
+				headers: map[string]string{"Content-Type": "application/json"},
+				body:    `{"name": "POS", "scopes": []}`,
+				auth:    invalidScopeMock(),
+			},
+			want: want{
+				status: http.StatusBadRequest,
+				code:   "invalid_api_key_scope",
+				detail: auth.ErrInvalidAPIKeyScope.Error(),
+			},
+		},
+		{
+			name: "returns 400 before calling service if scope is unknown",
+			args: args{
+				method:  http.MethodPost,
+				path:    "/api/user/api-keys",
+				token:   token(100),
+				headers: map[string]string{"Content-Type": "application/json"},
+				body:    `{"name": "POS", "scopes": ["everything"]}`,
+				auth:    new(authMock.Auth),
+			},
+			want: want{
+				status: http.StatusBadRequest,
+				code:   "invalid_request",
+				detail: "request body does not match schema: value is not one of the allowed values at /scopes/0",
+			},
+		},
+		{
+			name: "returns 204 if user has no api keys",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/user/api-keys",
+				token:  token(100),
+				auth:   noAPIKeysMock(),
+			},
+			want: want{
+				status: http.StatusNoContent,
+			},
+		},
+		{
+			name: "returns 400 if api key id is not a number",
+			args: args{
+				method: http.MethodDelete,
+				path:   "/api/user/api-keys/abc",
+				token:  token(100),
+				auth:   new(authMock.Auth),
+			},
+			want: want{
+				status: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "returns 404 if api key is not found",
+			args: args{
+				method: http.MethodDelete,
+				path:   "/api/user/api-keys/5",
+				token:  token(100),
+				auth:   revokeAPIKeyMock(auth.ErrAPIKeyNotFound),
+			},
+			want: want{
+				status: http.StatusNotFound,
+			},
+		},
+		{
+			name: "returns 204 if api key is revoked",
+			args: args{
+				method: http.MethodDelete,
+				path:   "/api/user/api-keys/5",
+				token:  token(100),
+				auth:   revokeAPIKeyMock(nil),
+			},
+			want: want{
+				status: http.StatusNoContent,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := apiKeysRequest(t, tt.args.auth, new(orderMock.Order), tt.args.method, tt.args.path, tt.args.body, tt.args.token, tt.args.headers)
+			defer resp.Body.Close()
+
+			if tt.want.body != "" {
+				resBody, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+
+				assert.Equal(t, tt.want.body, string(resBody))
+			}
+
+			if tt.want.code != "" {
+				assertProblem(t, resp, tt.want.code, tt.want.detail)
+			}
+
+			assert.Equal(t, tt.want.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestAPI_APIKeyAuthentication(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   auth.Auth
+		key    string
+		status int
+	}{
+		{
+			name:   "returns 401 if api key is invalid",
+			auth:   invalidAPIKeyMock(),
+			key:    "gm_unknown",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "returns 403 if api key does not have required scope",
+			auth:   apiKeyAuthMock(model.ScopeBalanceRead),
+			key:    testAPIKey,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "accepts order on behalf of key owner if api key has required scope",
+			auth:   apiKeyAuthMock(model.ScopeOrdersWrite),
+			key:    testAPIKey,
+			status: http.StatusAccepted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := new(orderMock.Order)
+			orders.On("AcceptOrder", mock.Anything, uint64(100), "79927398713").Return(nil)
+
+			headers := map[string]string{"Content-Type": "text/plain", "X-API-Key": tt.key}
+			resp := apiKeysRequest(t, tt.auth, orders, http.MethodPost, "/api/user/orders", "79927398713", "", headers)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func apiKeysRequest(t *testing.T, authService auth.Auth, orderService *orderMock.Order, method string, path string, body string, token string, headers map[string]string) *http.Response {
+	a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(authService), new(balanceMock.Balance), orderService, new(webhookMock.Webhook))
+	require.NoError(t, err)
+
+	ts := newTestServer(t, a)
+	t.Cleanup(ts.Close)
+
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+
+	if token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	for header, value := range headers {
+		req.Header.Set(header, value)
+	}
+
+	transport := http.Transport{}
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+
+	return resp
+}
+
+func apiKeyAuthMock(scopes ...model.APIKeyScope) *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("AuthenticateAPIKey", mock.Anything, testAPIKey).Return(&model.APIKey{ID: 1, UserID: 100, Scopes: scopes}, nil)
+	return m
+}
+
+func invalidAPIKeyMock() *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("AuthenticateAPIKey", mock.Anything, mock.Anything).Return(nil, auth.ErrAPIKeyInvalid)
+	return m
+}
+
+func createAPIKeyMock() *authMock.Auth {
+	m := new(authMock.Auth)
+	key := &model.APIKey{
+		ID:        1,
+		UserID:    100,
+		Name:      "POS",
+		Prefix:    "gm_test-",
+		Scopes:    []model.APIKeyScope{model.ScopeOrdersWrite},
+		CreatedAt: time.Date(2022, 3, 6, 5, 4, 1, 0, time.UTC),
+	}
+	m.On("CreateAPIKey", mock.Anything, uint64(100), "POS", []model.APIKeyScope{model.ScopeOrdersWrite}).Return(key, testAPIKey, nil)
+	return m
+}
+
+func invalidScopeMock() *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("CreateAPIKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, "", auth.ErrInvalidAPIKeyScope)
+	return m
+}
+
+func noAPIKeysMock() *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("APIKeys", mock.Anything, uint64(100)).Return([]model.APIKey{}, nil)
+	return m
+}
+
+func revokeAPIKeyMock(err error) *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("RevokeAPIKey", mock.Anything, uint64(100), uint64(5)).Return(err)
+	return m
+}
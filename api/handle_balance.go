@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
 	"net/http"
@@ -21,14 +22,14 @@ func (api *API) HandleBalance(w http.ResponseWriter, r *http.Request) {
 	userID, err := curruser.CurrentUser(r.Context())
 	if err != nil {
 		logger.Err(err).Msg("failed to get current user from context")
-		w.WriteHeader(http.StatusUnauthorized)
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
 		return
 	}
 
 	userBalance, err := api.balanceService.UserBalance(ctx, userID)
 	if err != nil {
 		logger.Err(err).Msg("failed to get balance for user")
-		w.WriteHeader(http.StatusInternalServerError)
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -39,7 +40,7 @@ func (api *API) HandleBalance(w http.ResponseWriter, r *http.Request) {
 	encoder := json.NewEncoder(w)
 	if err := encoder.Encode(&resp); err != nil {
 		logger.Err(err).Msgf("failed to encode json response from %+v", resp)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithError(w, r, err)
 		return
 	}
 }
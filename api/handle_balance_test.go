@@ -8,12 +8,12 @@ import (
 	"github.com/soundrussian/go-practicum-diploma/service/balance"
 	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
 	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
-	"net/http/httptest"
 	"testing"
 )
 
@@ -57,11 +57,10 @@ func TestHandleBalance(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a, err := New(new(authMock.Auth), tt.args.balance, new(orderMock.Order))
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(new(authMock.Auth)), tt.args.balance, new(orderMock.Order), new(webhookMock.Webhook))
 			require.NoError(t, err)
 
-			r := a.routes()
-			ts := httptest.NewServer(r)
+			ts := newTestServer(t, a)
 			defer ts.Close()
 
 			req, err := http.NewRequest("GET", ts.URL+"/api/user/balance", nil)
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/events"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// eventsHeartbeatInterval is how often comment is sent to idle event stream,
+// so that proxies do not close the connection and dead clients are noticed
+const eventsHeartbeatInterval = 15 * time.Second
+
+// HandleEvents streams changes of orders and balance of the current user as Server-Sent Events.
+// Clients reconnecting with Last-Event-ID header first receive events they have missed,
+// as long as the bus still keeps them. Stream ends when the client disconnects,
+// falls too far behind, or the server shuts down.
+func (api *API) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "events").Logger()
+	logger.Info().Msg("handling events")
+
+	userID, err := curruser.CurrentUser(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get current user from context")
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
+		return
+	}
+
+	var lastEventID uint64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		if lastEventID, err = strconv.ParseUint(header, 10, 64); err != nil {
+			logger.Err(err).Msgf("failed to parse Last-Event-ID %q", header)
+			respondWithError(w, r, apperr.Wrap(errInvalidLastEventID, err))
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logger.Error().Msg("response writer does not support flushing")
+		respondWithError(w, r, apperr.Wrap(errInternal, errors.New("streaming is not supported")))
+		return
+	}
+
+	replay, sub := api.events.Subscribe(userID, lastEventID)
+	defer sub.Close()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	// Proxies must pass events through as soon as they are sent
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range replay {
+		if err := writeEvent(w, event); err != nil {
+			logger.Err(err).Msgf("failed to write event %d", event.ID)
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info().Msg("client has closed event stream")
+			return
+		case <-api.streamsDone:
+			logger.Info().Msg("closing event stream on shutdown")
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				logger.Warn().Msgf("closing event stream of user %d, which does not keep up", userID)
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				logger.Err(err).Msgf("failed to write event %d", event.ID)
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				logger.Err(err).Msg("failed to write heartbeat")
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes event in Server-Sent Events format, with data encoded
+// the same way as in responses of endpoints returning the same data.
+// Events with data the API does not know how to encode are skipped.
+func writeEvent(w io.Writer, event events.Event) error {
+	var payload interface{}
+	switch data := event.Data.(type) {
+	case model.Order:
+		payload = orderResponseFromModel(data)
+	case model.UserBalance:
+		payload = respFromModel(&data)
+	default:
+		return nil
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, encoded)
+	return err
+}
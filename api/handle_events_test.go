@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/events"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_HandleEvents_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		token       string
+		lastEventID string
+		status      int
+		code        string
+		detail      string
+	}{
+		{
+			name:   "returns 401 if user is not authorized",
+			status: http.StatusUnauthorized,
+			code:   "unauthenticated",
+			detail: "unauthorized",
+		},
+		{
+			name:        "returns 400 if Last-Event-ID is not an event ID",
+			token:       token(100),
+			lastEventID: "yesterday",
+			status:      http.StatusBadRequest,
+			code:        "invalid_last_event_id",
+			detail:      "Last-Event-ID must be ID of an event received earlier",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, eventsTestAPI(t))
+
+			req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/user/events", nil)
+			require.NoError(t, err)
+			if tt.token != "" {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tt.token))
+			}
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assertProblem(t, resp, tt.code, tt.detail)
+		})
+	}
+}
+
+func TestAPI_HandleEvents_Stream(t *testing.T) {
+	order := model.Order{
+		UserID:     100,
+		OrderID:    "79927398713",
+		Status:     model.OrderProcessed,
+		Accrual:    decimal.NewFromInt(500),
+		UploadedAt: time.Date(2022, 3, 7, 9, 5, 11, 0, time.UTC),
+	}
+	balance := model.UserBalance{Current: decimal.NewFromInt(500)}
+
+	bus := events.NewBus(events.DefaultHistorySize)
+	bus.Publish(100, events.TypeOrder, order)     // 1, received before reconnect
+	bus.Publish(100, events.TypeBalance, balance) // 2, missed
+	bus.Publish(200, events.TypeOrder, order)     // 3, another user
+
+	a := eventsTestAPI(t)
+	a.SetEventBus(bus)
+
+	// Stream does not end on its own, so its response cannot be checked against spec by newTestServer
+	ts := httptest.NewServer(a.routes())
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/user/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token(100)))
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	stream := bufio.NewReader(resp.Body)
+
+	assert.Equal(t, "id: 2\nevent: balance\ndata: {\"current\":500,\"withdrawn\":0}\n\n", readEvent(t, stream))
+
+	bus.Publish(100, events.TypeOrder, order)
+	assert.Equal(t,
+		"id: 4\nevent: order\ndata: {\"number\":\"79927398713\",\"status\":\"PROCESSED\",\"accrual\":500,\"uploaded_at\":\"2022-03-07T09:05:11Z\"}\n\n",
+		readEvent(t, stream),
+	)
+
+	a.closeStreams()
+	_, err = stream.ReadByte()
+	assert.ErrorIs(t, err, io.EOF, "stream has not ended on shutdown")
+}
+
+// readEvent reads a single event from Server-Sent Events stream
+func readEvent(t *testing.T, stream *bufio.Reader) string {
+	t.Helper()
+
+	var event strings.Builder
+	for {
+		line, err := stream.ReadString('\n')
+		require.NoError(t, err)
+
+		event.WriteString(line)
+		if line == "\n" {
+			return event.String()
+		}
+	}
+}
+
+func eventsTestAPI(t *testing.T) *API {
+	t.Helper()
+
+	a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(new(authMock.Auth)), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+	require.NoError(t, err)
+
+	return a
+}
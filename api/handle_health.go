@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/soundrussian/go-practicum-diploma/pkg/health"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"net/http"
+	"sync/atomic"
+)
+
+var errShuttingDown = errors.New("server is shutting down")
+
+// HandleLiveness reports that the process is alive and able to serve requests
+func (api *API) HandleLiveness(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, r, health.Report{Status: health.StatusOK, Checks: []health.Result{}})
+}
+
+// HandleReadiness runs readiness checks and reports whether the server should receive traffic.
+// It fails once the server starts shutting down, so that load balancer stops sending requests.
+func (api *API) HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	checks := append([]health.Check{{Name: "shutdown", Critical: true, Run: api.checkNotShuttingDown}}, api.readinessChecks...)
+
+	writeHealthReport(w, r, health.Run(r.Context(), health.DefaultTimeout, checks...))
+}
+
+func (api *API) checkNotShuttingDown(_ context.Context) error {
+	if atomic.LoadInt32(&api.shuttingDown) == 1 {
+		return errShuttingDown
+	}
+
+	return nil
+}
+
+func writeHealthReport(w http.ResponseWriter, r *http.Request, report health.Report) {
+	_, logger := logging.CtxLogger(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		logger.Warn().Msgf("health check failed: %+v", report.Checks)
+		status = http.StatusServiceUnavailable
+	}
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&report); err != nil {
+		logger.Err(err).Msg("failed to encode json response")
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/soundrussian/go-practicum-diploma/pkg/health"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_Health(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	tests := []struct {
+		name         string
+		path         string
+		checks       []health.Check
+		shuttingDown bool
+		wantStatus   int
+		wantBody     []string
+	}{
+		{
+			name:       "reports liveness",
+			path:       "/healthz",
+			checks:     []health.Check{{Name: "database", Critical: true, Run: failing}},
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"status":"ok"`},
+		},
+		{
+			name: "reports readiness with results of each check",
+			path: "/readyz",
+			checks: []health.Check{
+				{Name: "database", Critical: true, Run: ok},
+				{Name: "accrual", Run: failing},
+			},
+			wantStatus: http.StatusOK,
+			wantBody: []string{
+				`"status":"ok"`,
+				`{"name":"database","status":"ok","critical":true,"latency_ms":`,
+				`"name":"accrual","status":"fail"`,
+				`"error":"connection refused"`,
+			},
+		},
+		{
+			name:       "fails readiness if critical check fails",
+			path:       "/readyz",
+			checks:     []health.Check{{Name: "database", Critical: true, Run: failing}},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   []string{`"status":"fail"`},
+		},
+		{
+			name:         "fails readiness while shutting down",
+			path:         "/readyz",
+			shuttingDown: true,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantBody:     []string{`"name":"shutdown","status":"fail"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), new(authMock.Auth), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+			require.NoError(t, err)
+
+			a.AddReadinessCheck(tt.checks...)
+			if tt.shuttingDown {
+				a.shuttingDown = 1
+			}
+
+			ts := newTestServer(t, a)
+			defer ts.Close()
+
+			resp, err := http.Get(ts.URL + tt.path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			for _, want := range tt.wantBody {
+				assert.Contains(t, string(body), want)
+			}
+		})
+	}
+}
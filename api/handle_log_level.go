@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"net/http"
+)
+
+var errInvalidLogLevel = apperr.New(apperr.CodeInvalidArgument, "unknown log level").WithReason("invalid_log_level")
+
+type logLevelJSON struct {
+	Level string `json:"level" required:"true"`
+}
+
+// HandleLogLevel returns current log level
+func (api *API) HandleLogLevel(w http.ResponseWriter, r *http.Request) {
+	_, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "log_level").Logger()
+	logger.Info().Msg("handling log level")
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(logLevelJSON{Level: logging.Level().String()}); err != nil {
+		logger.Err(err).Msg("failed to encode json response")
+		respondWithError(w, r, err)
+		return
+	}
+}
+
+// HandleSetLogLevel changes log level of the running server
+func (api *API) HandleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var jsonRequest logLevelJSON
+
+	_, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "set_log_level").Logger()
+	logger.Info().Msg("handling set log level")
+
+	if err := decodeJSON(r, &jsonRequest); err != nil {
+		logger.Err(err).Msgf("failed to parse request body as JSON")
+		respondWithError(w, r, err)
+		return
+	}
+
+	level, err := logging.ParseLevel(jsonRequest.Level)
+	if err != nil {
+		logger.Err(err).Msgf("failed to parse log level %s", jsonRequest.Level)
+		respondWithError(w, r, apperr.Wrap(errInvalidLogLevel, err))
+		return
+	}
+
+	// Logged before the change so that the entry is not filtered out by a higher level
+	logger.Warn().Msgf("changing log level from %s to %s", logging.Level(), level)
+	logging.SetLevel(level)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,16 +1,14 @@
 package api
 
 import (
-	"encoding/json"
 	"errors"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
-	"github.com/soundrussian/go-practicum-diploma/service/auth"
 	"net/http"
 )
 
 type loginJSONRequest struct {
-	Login    string `json:"login"`
-	Password string `json:"password"`
+	Login    string `json:"login" required:"true"`
+	Password string `json:"password" required:"true"`
 }
 
 func (api *API) HandleLogin(w http.ResponseWriter, r *http.Request) {
@@ -20,29 +18,28 @@ func (api *API) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	logger = logger.With().Str(logging.HandlerNameKey, "login").Logger()
 	logger.Info().Msg("handling login")
 
-	decoder := json.NewDecoder(r.Body)
-	defer r.Body.Close()
-
-	if err := decoder.Decode(&jsonRequest); err != nil {
+	if err := decodeJSON(r, &jsonRequest); err != nil {
 		logger.Err(err).Msgf("failed to parse request body as JSON")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		respondWithError(w, r, err)
 		return
 	}
 
 	user, err := api.authService.Authenticate(r.Context(), jsonRequest.Login, jsonRequest.Password)
 	if err != nil {
 		logger.Err(err).Msgf("failed to authenticate user %s", jsonRequest.Login)
-		if errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrPasswordIncorrect) {
-			http.Error(w, "incorrect login or password", http.StatusUnauthorized)
-			return
-		}
-		http.Error(w, "internal error", http.StatusInternalServerError)
+		respondWithError(w, r, err)
 		return
 	}
 
 	if user == nil {
-		logger.Err(errors.New("api.authService.Authenticate returned nil user")).Msgf("failed to login %+v", jsonRequest)
-		http.Error(w, "internal error", http.StatusInternalServerError)
+		logger.Err(errors.New("api.authService.Authenticate returned nil user")).Msgf("failed to login user %s", jsonRequest.Login)
+		respondWithError(w, r, errInternal)
+		return
+	}
+
+	if user.TOTPEnabled {
+		logger.Info().Msgf("user id=%d with login %s has to pass second factor", user.ID, user.Login)
+		api.requireSecondFactor(user, w, r)
 		return
 	}
 
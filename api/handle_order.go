@@ -2,6 +2,7 @@ package api
 
 import (
 	"errors"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
 	"github.com/soundrussian/go-practicum-diploma/service/order"
@@ -17,30 +18,24 @@ func (api *API) HandleOrder(w http.ResponseWriter, r *http.Request) {
 	orderID, err := io.ReadAll(r.Body)
 	if err != nil {
 		logger.Err(err).Msg("failed to read request body")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithError(w, r, err)
+		return
 	}
 	defer r.Body.Close()
 
 	userID, err := curruser.CurrentUser(ctx)
 	if err != nil {
 		logger.Err(err).Msg("failed to get current user from context")
-		w.WriteHeader(http.StatusUnauthorized)
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
 		return
 	}
 
 	if err := api.orderService.AcceptOrder(ctx, userID, string(orderID)); err != nil {
 		logger.Err(err).Msgf("error accepting order <%s> for user %d", string(orderID), userID)
-		status := http.StatusInternalServerError
-		if errors.Is(err, order.ErrOrderInvalid) {
-			status = http.StatusUnprocessableEntity
-		}
-		if errors.Is(err, order.ErrConflict) {
-			status = http.StatusConflict
-		}
 		if errors.Is(err, order.ErrAlreadyAccepted) {
 			return // Implicit 200 OK
 		}
-		http.Error(w, http.StatusText(status), status)
+		respondWithError(w, r, err)
 		return
 	}
 
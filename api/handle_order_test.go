@@ -6,12 +6,12 @@ import (
 	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
 	"github.com/soundrussian/go-practicum-diploma/service/order"
 	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
-	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -105,11 +105,10 @@ func TestAPI_HandleOrder(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a, err := New(new(authMock.Auth), new(balanceMock.Balance), tt.args.order)
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(new(authMock.Auth)), new(balanceMock.Balance), tt.args.order, new(webhookMock.Webhook))
 			require.NoError(t, err)
 
-			r := a.routes()
-			ts := httptest.NewServer(r)
+			ts := newTestServer(t, a)
 			defer ts.Close()
 
 			req, err := http.NewRequest("POST", ts.URL+"/api/user/orders", strings.NewReader(tt.args.body))
@@ -2,7 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"github.com/go-chi/chi/v5"
 	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
 	"net/http"
@@ -34,14 +36,14 @@ func (api *API) HandleOrders(w http.ResponseWriter, r *http.Request) {
 	userID, err := curruser.CurrentUser(ctx)
 	if err != nil {
 		logger.Err(err).Msg("failed to get current user from context")
-		w.WriteHeader(http.StatusUnauthorized)
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
 		return
 	}
 
 	orders, err := api.orderService.UserOrders(ctx, userID)
 	if err != nil {
 		logger.Err(err).Msgf("failed getting orders for user %d", userID)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -60,7 +62,42 @@ func (api *API) HandleOrders(w http.ResponseWriter, r *http.Request) {
 	encoder := json.NewEncoder(w)
 	if err := encoder.Encode(&response); err != nil {
 		logger.Err(err).Msgf("failed to encode json response from %+v", orders)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithError(w, r, err)
+		return
+	}
+}
+
+// HandleUserOrder responds with a single order of the current user.
+// Orders of other users are reported as not found, so that their existence does not leak.
+func (api *API) HandleUserOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "user_order").Logger()
+	logger.Info().Msg("handling user order")
+
+	userID, err := curruser.CurrentUser(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get current user from context")
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
+		return
+	}
+
+	number := chi.URLParam(r, "number")
+
+	order, err := api.orderService.UserOrder(ctx, userID, number)
+	if err != nil {
+		logger.Err(err).Msgf("failed getting order <%s> for user %d", number, userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	response := orderResponseFromModel(*order)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&response); err != nil {
+		logger.Err(err).Msgf("failed to encode json response from %+v", order)
+		respondWithError(w, r, err)
 		return
 	}
 }
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+type orderUploadResponse struct {
+	Number string `json:"number"`
+	Status string `json:"status"`
+}
+
+// HandleOrdersBatch accepts many orders of the current user at once, either as JSON array
+// of order numbers or as plain text with one number per line, and responds with outcome
+// for each of them. Invalid or conflicting numbers do not fail the whole batch.
+func (api *API) HandleOrdersBatch(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "orders_batch").Logger()
+	logger.Info().Msg("handling orders batch")
+
+	defer r.Body.Close()
+
+	orderIDs, err := api.readOrderBatch(r)
+	if err != nil {
+		logger.Err(err).Msg("failed to read orders batch")
+		respondWithError(w, r, err)
+		return
+	}
+
+	userID, err := curruser.CurrentUser(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get current user from context")
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
+		return
+	}
+
+	results, err := api.orderService.AcceptOrders(ctx, userID, orderIDs)
+	if err != nil {
+		logger.Err(err).Msgf("error accepting batch of %d orders for user %d", len(orderIDs), userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	response := make([]orderUploadResponse, 0, len(results))
+	for _, result := range results {
+		response = append(response, orderUploadResponseFromModel(result))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&response); err != nil {
+		logger.Err(err).Msgf("failed to encode json response from %d results", len(results))
+		respondWithError(w, r, err)
+		return
+	}
+}
+
+// readOrderBatch reads order numbers from request body according to its content type
+// and checks that there are at least one and at most OrderBatchLimit of them
+func (api *API) readOrderBatch(r *http.Request) ([]string, error) {
+	var orderIDs []string
+	var err error
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		err = decodeJSON(r, &orderIDs)
+	} else {
+		orderIDs, err = readTextOrderBatch(r.Body, api.config.OrderBatchLimit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(orderIDs) == 0 {
+		return nil, errEmptyBatch
+	}
+
+	if len(orderIDs) > api.config.OrderBatchLimit {
+		return nil, errBatchTooLarge.WithPublic(fmt.Sprintf("batch may contain at most %d order numbers", api.config.OrderBatchLimit))
+	}
+
+	return orderIDs, nil
+}
+
+// readTextOrderBatch reads order numbers one per line, skipping blank lines.
+// Reading stops once there are more than limit numbers, since the batch is rejected anyway.
+// Errors of reading the body, such as the one of body exceeding limit of LimitBody, are returned as they are.
+func readTextOrderBatch(body io.Reader, limit int) ([]string, error) {
+	orderIDs := make([]string, 0)
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() && len(orderIDs) <= limit {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		orderIDs = append(orderIDs, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		var bodyErr *apperr.Error
+		if errors.As(err, &bodyErr) {
+			return nil, bodyErr
+		}
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, apperr.Wrap(errInvalidBatch, err).WithPublic(fmt.Sprintf("batch has line longer than %d bytes", bufio.MaxScanTokenSize))
+		}
+		return nil, apperr.Wrap(errInvalidBatch, err)
+	}
+
+	return orderIDs, nil
+}
+
+func orderUploadResponseFromModel(result model.OrderUploadResult) orderUploadResponse {
+	return orderUploadResponse{
+		Number: result.OrderID,
+		Status: result.Status.String(),
+	}
+}
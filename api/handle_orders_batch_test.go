@@ -0,0 +1,216 @@
+package api
+
+import (
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	"github.com/soundrussian/go-practicum-diploma/service/order"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPI_HandleOrdersBatch(t *testing.T) {
+	batchResults := []model.OrderUploadResult{
+		{OrderID: "79927398713", Status: model.OrderUploadAccepted},
+		{OrderID: "12345678903", Status: model.OrderUploadAlreadyUploaded},
+		{OrderID: "346436439", Status: model.OrderUploadConflict},
+		{OrderID: "12345", Status: model.OrderUploadInvalid},
+	}
+	batchResponse := `[{"number":"79927398713","status":"ACCEPTED"},` +
+		`{"number":"12345678903","status":"ALREADY_UPLOADED"},` +
+		`{"number":"346436439","status":"CONFLICT"},` +
+		`{"number":"12345","status":"INVALID"}]` + "\n"
+	batchNumbers := []string{"79927398713", "12345678903", "346436439", "12345"}
+
+	type args struct {
+		body        string
+		token       string
+		contentType string
+		order       order.Order
+	}
+	type want struct {
+		status int
+		body   string
+		code   string
+		detail string
+	}
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "returns 401 if user is not authorized",
+			args: args{
+				contentType: "text/plain",
+				body:        "79927398713",
+				order:       new(orderMock.Order),
+			},
+			want: want{
+				status: http.StatusUnauthorized,
+				code:   "unauthenticated",
+				detail: "unauthorized",
+			},
+		},
+		{
+			name: "returns 415 if content type is neither JSON nor plain text",
+			args: args{
+				token:       token(100),
+				contentType: "application/xml",
+				body:        "<orders/>",
+				order:       new(orderMock.Order),
+			},
+			want: want{
+				status: http.StatusUnsupportedMediaType,
+				code:   "unsupported_content_type",
+				detail: "Content-Type of request body must be one of application/json, text/plain",
+			},
+		},
+		{
+			name: "returns 400 if JSON body is not array of strings",
+			args: args{
+				token:       token(100),
+				contentType: "application/json",
+				body:        `[79927398713]`,
+				order:       new(orderMock.Order),
+			},
+			want: want{
+				status: http.StatusBadRequest,
+				code:   "invalid_request",
+				detail: "request body does not match schema: Field must be set to string or not be present at /0",
+			},
+		},
+		{
+			name: "returns 400 if batch is empty",
+			args: args{
+				token:       token(100),
+				contentType: "text/plain",
+				body:        "\n  \n",
+				order:       new(orderMock.Order),
+			},
+			want: want{
+				status: http.StatusBadRequest,
+				code:   "empty_batch",
+				detail: "batch contains no order numbers",
+			},
+		},
+		{
+			name: "returns 400 if line of plain text batch is too long",
+			args: args{
+				token:       token(100),
+				contentType: "text/plain",
+				body:        "79927398713\n" + strings.Repeat("7", 1<<17),
+				order:       new(orderMock.Order),
+			},
+			want: want{
+				status: http.StatusBadRequest,
+				code:   "invalid_batch",
+				detail: "batch has line longer than 65536 bytes",
+			},
+		},
+		{
+			name: "returns 413 if batch has more numbers than allowed",
+			args: args{
+				token:       token(100),
+				contentType: "application/json",
+				body:        `["79927398713","12345678903","346436439","12345","79927398713"]`,
+				order:       new(orderMock.Order),
+			},
+			want: want{
+				status: http.StatusRequestEntityTooLarge,
+				code:   "batch_too_large",
+				detail: "batch may contain at most 4 order numbers",
+			},
+		},
+		{
+			name: "returns 500 if batch could not be stored",
+			args: args{
+				token:       token(100),
+				contentType: "text/plain",
+				body:        "79927398713",
+				order:       ordersBatchMock([]string{"79927398713"}, nil, order.ErrInternalError),
+			},
+			want: want{
+				status: http.StatusInternalServerError,
+				code:   "internal",
+				detail: "internal error",
+			},
+		},
+		{
+			name: "returns outcome of each number sent as JSON array",
+			args: args{
+				token:       token(100),
+				contentType: "application/json",
+				body:        `["79927398713","12345678903"]`,
+				order:       ordersBatchMock([]string{"79927398713", "12345678903"}, batchResults[:2], nil),
+			},
+			want: want{
+				status: http.StatusOK,
+				body:   `[{"number":"79927398713","status":"ACCEPTED"},{"number":"12345678903","status":"ALREADY_UPLOADED"}]` + "\n",
+			},
+		},
+		{
+			name: "returns outcome of each number sent one per line",
+			args: args{
+				token:       token(100),
+				contentType: "text/plain; charset=utf-8",
+				body:        "79927398713\r\n12345678903\n\n 346436439 \n12345",
+				order:       ordersBatchMock(batchNumbers, batchResults, nil),
+			},
+			want: want{
+				status: http.StatusOK,
+				body:   batchResponse,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.OrderBatchLimit = len(batchNumbers)
+
+			a, err := New(cfg, testAuth.TokenAuth(), activeUser(new(authMock.Auth)), new(balanceMock.Balance), tt.args.order, new(webhookMock.Webhook))
+			require.NoError(t, err)
+
+			ts := newTestServer(t, a)
+			defer ts.Close()
+
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/user/orders/batch", strings.NewReader(tt.args.body))
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", tt.args.contentType)
+			if tt.args.token != "" {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tt.args.token))
+			}
+
+			transport := http.Transport{}
+			resp, err := transport.RoundTrip(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.want.status, resp.StatusCode)
+
+			if tt.want.code != "" {
+				assertProblem(t, resp, tt.want.code, tt.want.detail)
+				return
+			}
+
+			resBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.body, string(resBody))
+		})
+	}
+}
+
+func ordersBatchMock(orderIDs []string, results []model.OrderUploadResult, err error) *orderMock.Order {
+	m := new(orderMock.Order)
+	m.On("AcceptOrders", mock.Anything, uint64(100), orderIDs).Return(results, err)
+	return m
+}
@@ -8,13 +8,13 @@ import (
 	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
 	"github.com/soundrussian/go-practicum-diploma/service/order"
 	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -98,11 +98,10 @@ func TestAPI_HandleOrders(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a, err := New(new(authMock.Auth), new(balanceMock.Balance), tt.args.order)
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(new(authMock.Auth)), new(balanceMock.Balance), tt.args.order, new(webhookMock.Webhook))
 			require.NoError(t, err)
 
-			r := a.routes()
-			ts := httptest.NewServer(r)
+			ts := newTestServer(t, a)
 			defer ts.Close()
 
 			req, err := http.NewRequest("GET", ts.URL+"/api/user/orders", nil)
@@ -149,3 +148,100 @@ func ordersMock(orders []model.Order) *orderMock.Order {
 	m.On("UserOrders", mock.Anything, mock.Anything).Return(orders, nil)
 	return m
 }
+
+func TestAPI_HandleUserOrder(t *testing.T) {
+	type want struct {
+		status int
+		body   string
+		code   string
+		detail string
+	}
+	tests := []struct {
+		name  string
+		token string
+		order order.Order
+		want  want
+	}{
+		{
+			name:  "returns 401 if user is not authorized",
+			order: new(orderMock.Order),
+			want: want{
+				status: http.StatusUnauthorized,
+				code:   "unauthenticated",
+				detail: "unauthorized",
+			},
+		},
+		{
+			name:  "returns 404 if order is not found or belongs to another user",
+			token: token(100),
+			order: userOrderMock(nil, order.ErrOrderNotFound),
+			want: want{
+				status: http.StatusNotFound,
+				code:   "order_not_found",
+				detail: "order not found",
+			},
+		},
+		{
+			name:  "returns 500 if order could not be fetched",
+			token: token(100),
+			order: userOrderMock(nil, order.ErrInternalError),
+			want: want{
+				status: http.StatusInternalServerError,
+				code:   "internal",
+				detail: "internal error",
+			},
+		},
+		{
+			name:  "returns 200 and the order",
+			token: token(100),
+			order: userOrderMock(&model.Order{
+				UserID:     100,
+				Accrual:    decimal.NewFromInt(500),
+				OrderID:    "79927398713",
+				Status:     model.OrderProcessed,
+				UploadedAt: time.Date(2022, 3, 7, 9, 5, 11, 0, time.UTC),
+			}, nil),
+			want: want{
+				status: http.StatusOK,
+				body:   `{"number":"79927398713","status":"PROCESSED","accrual":500,"uploaded_at":"2022-03-07T09:05:11Z"}` + "\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(new(authMock.Auth)), new(balanceMock.Balance), tt.order, new(webhookMock.Webhook))
+			require.NoError(t, err)
+
+			ts := newTestServer(t, a)
+
+			req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/user/orders/79927398713", nil)
+			require.NoError(t, err)
+
+			if tt.token != "" {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tt.token))
+			}
+
+			transport := http.Transport{}
+			resp, err := transport.RoundTrip(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.want.status, resp.StatusCode)
+
+			if tt.want.code != "" {
+				assertProblem(t, resp, tt.want.code, tt.want.detail)
+				return
+			}
+
+			resBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.body, string(resBody))
+		})
+	}
+}
+
+func userOrderMock(result *model.Order, err error) *orderMock.Order {
+	m := new(orderMock.Order)
+	m.On("UserOrder", mock.Anything, uint64(100), "79927398713").Return(result, err)
+	return m
+}
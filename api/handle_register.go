@@ -1,16 +1,14 @@
 package api
 
 import (
-	"encoding/json"
 	"errors"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
-	"github.com/soundrussian/go-practicum-diploma/service/auth"
 	"net/http"
 )
 
 type registerJSONRequest struct {
-	Login    string `json:"login"`
-	Password string `json:"password"`
+	Login    string `json:"login" required:"true"`
+	Password string `json:"password" required:"true"`
 }
 
 func (api *API) HandleRegister(w http.ResponseWriter, r *http.Request) {
@@ -20,29 +18,22 @@ func (api *API) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	logger = logger.With().Str(logging.HandlerNameKey, "register").Logger()
 	logger.Info().Msg("handling register")
 
-	decoder := json.NewDecoder(r.Body)
-	defer r.Body.Close()
-
-	if err := decoder.Decode(&jsonRequest); err != nil {
+	if err := decodeJSON(r, &jsonRequest); err != nil {
 		logger.Err(err).Msgf("failed to parse request body as JSON")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		respondWithError(w, r, err)
 		return
 	}
 
 	user, err := api.authService.Register(r.Context(), jsonRequest.Login, jsonRequest.Password)
 	if err != nil {
 		logger.Err(err).Msgf("failed to register user %s", jsonRequest.Login)
-		status := http.StatusBadRequest
-		if errors.Is(err, auth.ErrUserAlreadyRegistered) {
-			status = http.StatusConflict
-		}
-		http.Error(w, err.Error(), status)
+		respondWithError(w, r, err)
 		return
 	}
 
 	if user == nil {
-		logger.Err(errors.New("api.authService.Register returned nil user")).Msgf("failed to register %+v", jsonRequest)
-		http.Error(w, "registration failed", http.StatusInternalServerError)
+		logger.Err(errors.New("api.authService.Register returned nil user")).Msgf("failed to register user %s", jsonRequest.Login)
+		respondWithError(w, r, errInternal)
 		return
 	}
 
@@ -1,18 +1,19 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"github.com/soundrussian/go-practicum-diploma/model"
 	"github.com/soundrussian/go-practicum-diploma/service/auth"
 	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
 	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
 	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
-	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -37,6 +38,9 @@ func TestHandleRegister(t *testing.T) {
 		status  int
 		headers map[string]string
 		body    string
+		// code and detail are checked in problem details of error responses
+		code   string
+		detail string
 	}
 	tests := []struct {
 		name string
@@ -51,6 +55,8 @@ func TestHandleRegister(t *testing.T) {
 			},
 			want: want{
 				status: http.StatusUnsupportedMediaType,
+				code:   "unsupported_content_type",
+				detail: "Content-Type of request body must be one of application/json",
 			},
 		},
 		{
@@ -77,7 +83,8 @@ func TestHandleRegister(t *testing.T) {
 			},
 			want: want{
 				status: http.StatusBadRequest,
-				body:   auth.ErrInvalidLogin.Error() + "\n",
+				code:   "invalid_login",
+				detail: auth.ErrInvalidLogin.Error(),
 			},
 		},
 		{
@@ -91,7 +98,23 @@ func TestHandleRegister(t *testing.T) {
 			},
 			want: want{
 				status: http.StatusConflict,
-				body:   auth.ErrUserAlreadyRegistered.Error() + "\n",
+				code:   "login_taken",
+				detail: auth.ErrUserAlreadyRegistered.Error(),
+			},
+		},
+		{
+			name: "returns 500 without internal details if registration failed unexpectedly",
+			args: args{
+				headers: map[string]string{
+					"Content-Type": "application/json",
+				},
+				body: validRequest,
+				auth: failedRegistration(),
+			},
+			want: want{
+				status: http.StatusInternalServerError,
+				code:   "internal",
+				detail: "internal error",
 			},
 		},
 		{
@@ -115,11 +138,10 @@ func TestHandleRegister(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a, err := New(tt.args.auth, new(balanceMock.Balance), new(orderMock.Order))
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), tt.args.auth, new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
 			require.NoError(t, err)
 
-			r := a.routes()
-			ts := httptest.NewServer(r)
+			ts := newTestServer(t, a)
 			defer ts.Close()
 
 			req, err := http.NewRequest("POST", ts.URL+"/api/user/register", strings.NewReader(tt.args.body))
@@ -141,6 +163,11 @@ func TestHandleRegister(t *testing.T) {
 				assert.Equal(t, tt.want.body, string(resBody))
 			}
 
+			if tt.want.code != "" {
+				defer resp.Body.Close()
+				assertProblem(t, resp, tt.want.code, tt.want.detail)
+			}
+
 			assert.Equal(t, tt.want.status, resp.StatusCode)
 
 			for wantHeader, wantHeaderValue := range tt.want.headers {
@@ -163,6 +190,12 @@ func duplicateUser() *authMock.Auth {
 	return m
 }
 
+func failedRegistration() *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("Register", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("pq: connection refused"))
+	return m
+}
+
 func successfulRegistration(userID uint64) *authMock.Auth {
 	m := new(authMock.Auth)
 	m.On("Register", mock.Anything, mock.Anything, mock.Anything).Return(&model.User{ID: userID}, nil)
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"net/http"
+)
+
+type secondFactorRequiredJSONResponse struct {
+	TwoFactorToken string `json:"two_factor_token"`
+}
+
+type secondFactorJSONRequest struct {
+	Token string `json:"two_factor_token" required:"true"`
+	Code  string `json:"code" required:"true"`
+}
+
+type enrollTOTPJSONResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type confirmTOTPJSONRequest struct {
+	Code string `json:"code" required:"true"`
+}
+
+type confirmTOTPJSONResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// requireSecondFactor responds with 202 Accepted and a short-lived token
+// that should be sent along with TOTP code to HandleLoginSecondFactor
+func (api *API) requireSecondFactor(user *model.User, w http.ResponseWriter, r *http.Request) {
+	_, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "require_second_factor").Logger()
+
+	token, err := api.authService.SecondFactorToken(r.Context(), user)
+	if err != nil {
+		logger.Err(err).Msg("failed to get second factor token for user")
+		respondWithError(w, r, errInternal)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	response := secondFactorRequiredJSONResponse{TwoFactorToken: *token}
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&response); err != nil {
+		logger.Err(err).Msg("failed to encode json response")
+		return
+	}
+}
+
+// HandleLoginSecondFactor completes login of users with 2FA enabled
+func (api *API) HandleLoginSecondFactor(w http.ResponseWriter, r *http.Request) {
+	var jsonRequest secondFactorJSONRequest
+
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "login_second_factor").Logger()
+	logger.Info().Msg("handling login second factor")
+
+	if err := decodeJSON(r, &jsonRequest); err != nil {
+		logger.Err(err).Msgf("failed to parse request body as JSON")
+		respondWithError(w, r, err)
+		return
+	}
+
+	user, err := api.authService.VerifySecondFactor(ctx, jsonRequest.Token, jsonRequest.Code)
+	if err != nil {
+		logger.Err(err).Msg("failed to verify second factor")
+		respondWithError(w, r, err)
+		return
+	}
+
+	logger.Info().Msgf("authenticated user id=%d with login %s using second factor", user.ID, user.Login)
+
+	api.authenticate(user, w, r)
+}
+
+// HandleEnrollTOTP starts 2FA enrollment and returns otpauth URI to be added to authenticator app
+func (api *API) HandleEnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "enroll_totp").Logger()
+	logger.Info().Msg("handling enroll totp")
+
+	userID, err := curruser.CurrentUser(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get current user from context")
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
+		return
+	}
+
+	secret, uri, err := api.authService.EnrollTOTP(ctx, userID)
+	if err != nil {
+		logger.Err(err).Msgf("failed to enroll totp for user %d", userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	response := enrollTOTPJSONResponse{Secret: secret, URI: uri}
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&response); err != nil {
+		logger.Err(err).Msg("failed to encode json response")
+		return
+	}
+}
+
+// HandleConfirmTOTP enables 2FA once user proves that authenticator app is set up
+func (api *API) HandleConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var jsonRequest confirmTOTPJSONRequest
+
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "confirm_totp").Logger()
+	logger.Info().Msg("handling confirm totp")
+
+	if err := decodeJSON(r, &jsonRequest); err != nil {
+		logger.Err(err).Msgf("failed to parse request body as JSON")
+		respondWithError(w, r, err)
+		return
+	}
+
+	userID, err := curruser.CurrentUser(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get current user from context")
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
+		return
+	}
+
+	codes, err := api.authService.ConfirmTOTP(ctx, userID, jsonRequest.Code)
+	if err != nil {
+		logger.Err(err).Msgf("failed to confirm totp for user %d", userID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	logger.Info().Msgf("enabled totp for user %d", userID)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	response := confirmTOTPJSONResponse{RecoveryCodes: codes}
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&response); err != nil {
+		logger.Err(err).Msg("failed to encode json response")
+		return
+	}
+}
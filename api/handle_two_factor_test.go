@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/service/auth"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPI_TwoFactorLogin(t *testing.T) {
+	user := &model.User{ID: 100, Login: "john.doe@example.com", TOTPEnabled: true}
+	partial := secondFactorToken(user)
+
+	type args struct {
+		path    string
+		body    string
+		token   string
+		headers map[string]string
+		auth    auth.Auth
+	}
+	type want struct {
+		status  int
+		body    string
+		headers map[string]string
+	}
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "returns 202 with second factor token instead of auth token if user has 2FA enabled",
+			args: args{
+				path:    "/api/user/login",
+				body:    validRequest,
+				headers: map[string]string{"Content-Type": "application/json"},
+				auth:    twoFactorLoginMock(user, partial),
+			},
+			want: want{
+				status:  http.StatusAccepted,
+				body:    fmt.Sprintf(`{"two_factor_token":"%s"}`+"\n", partial),
+				headers: map[string]string{"Set-Cookie": "", "Authentication": ""},
+			},
+		},
+		{
+			name: "returns 401 if second factor is invalid",
+			args: args{
+				path:    "/api/user/login/2fa",
+				body:    fmt.Sprintf(`{"two_factor_token":"%s","code":"000000"}`, partial),
+				headers: map[string]string{"Content-Type": "application/json"},
+				auth:    verifySecondFactorMock(partial, "000000", nil, auth.ErrSecondFactorInvalid),
+			},
+			want: want{
+				status: http.StatusUnauthorized,
+			},
+		},
+		{
+			name: "returns auth token if second factor is valid",
+			args: args{
+				path:    "/api/user/login/2fa",
+				body:    fmt.Sprintf(`{"two_factor_token":"%s","code":"123456"}`, partial),
+				headers: map[string]string{"Content-Type": "application/json"},
+				auth:    verifySecondFactorMock(partial, "123456", user, nil),
+			},
+			want: want{
+				status: http.StatusOK,
+				body:   fmt.Sprintf(`{"token":"%s"}`+"\n", token(100)),
+			},
+		},
+		{
+			name: "does not accept second factor token as auth token",
+			args: args{
+				path:  "/api/user/2fa/enroll",
+				token: partial,
+				auth:  new(authMock.Auth),
+			},
+			want: want{
+				status: http.StatusUnauthorized,
+			},
+		},
+		{
+			name: "returns 409 if 2FA is already enabled",
+			args: args{
+				path:  "/api/user/2fa/enroll",
+				token: token(100),
+				auth:  enrollTOTPMock(auth.ErrTOTPAlreadyEnabled),
+			},
+			want: want{
+				status: http.StatusConflict,
+			},
+		},
+		{
+			name: "returns otpauth uri on enrollment",
+			args: args{
+				path:  "/api/user/2fa/enroll",
+				token: token(100),
+				auth:  enrollTOTPMock(nil),
+			},
+			want: want{
+				status: http.StatusOK,
+				body:   `{"secret":"JBSWY3DPEHPK3PXP","uri":"otpauth://totp/Gophermart:john?secret=JBSWY3DPEHPK3PXP"}` + "\n",
+			},
+		},
+		{
+			name: "returns recovery codes on confirmation",
+			args: args{
+				path:    "/api/user/2fa/confirm",
+				token:   token(100),
+				body:    `{"code":"123456"}`,
+				headers: map[string]string{"Content-Type": "application/json"},
+				auth:    confirmTOTPMock(),
+			},
+			want: want{
+				status: http.StatusOK,
+				body:   `{"recovery_codes":["abcd-efgh","ijkl-mnop"]}` + "\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(tt.args.auth), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+			require.NoError(t, err)
+
+			ts := newTestServer(t, a)
+			defer ts.Close()
+
+			req, err := http.NewRequest(http.MethodPost, ts.URL+tt.args.path, strings.NewReader(tt.args.body))
+			require.NoError(t, err)
+
+			if tt.args.token != "" {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tt.args.token))
+			}
+
+			for header, value := range tt.args.headers {
+				req.Header.Set(header, value)
+			}
+
+			transport := http.Transport{}
+			resp, err := transport.RoundTrip(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			if tt.want.body != "" {
+				resBody, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+
+				assert.Equal(t, tt.want.body, string(resBody))
+			}
+
+			assert.Equal(t, tt.want.status, resp.StatusCode)
+
+			for wantHeader, wantHeaderValue := range tt.want.headers {
+				assert.Equal(t, wantHeaderValue, resp.Header.Get(wantHeader))
+			}
+		})
+	}
+}
+
+func secondFactorToken(user *model.User) string {
+	t, _ := testAuth.SecondFactorToken(context.Background(), user)
+	return *t
+}
+
+func twoFactorLoginMock(user *model.User, partial string) *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("Authenticate", mock.Anything, mock.Anything, mock.Anything).Return(user, nil)
+	m.On("SecondFactorToken", mock.Anything, user).Return(&partial, nil)
+	return m
+}
+
+func verifySecondFactorMock(partial string, code string, user *model.User, err error) *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("VerifySecondFactor", mock.Anything, partial, code).Return(user, err)
+	t := token(100)
+	m.On("AuthToken", mock.Anything, mock.Anything).Return(&t, nil)
+	return m
+}
+
+func enrollTOTPMock(err error) *authMock.Auth {
+	m := new(authMock.Auth)
+	if err != nil {
+		m.On("EnrollTOTP", mock.Anything, uint64(100)).Return("", "", err)
+	} else {
+		m.On("EnrollTOTP", mock.Anything, uint64(100)).Return("JBSWY3DPEHPK3PXP", "otpauth://totp/Gophermart:john?secret=JBSWY3DPEHPK3PXP", nil)
+	}
+	return m
+}
+
+func confirmTOTPMock() *authMock.Auth {
+	m := new(authMock.Auth)
+	m.On("ConfirmTOTP", mock.Anything, uint64(100), "123456").Return([]string{"abcd-efgh", "ijkl-mnop"}, nil)
+	return m
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	errInvalidWebhookID  = apperr.New(apperr.CodeInvalidArgument, "webhook id must be a positive integer").WithReason("invalid_webhook_id")
+	errInvalidDeliveryID = apperr.New(apperr.CodeInvalidArgument, "delivery id must be a positive integer").WithReason("invalid_delivery_id")
+)
+
+type webhookJSONRequest struct {
+	UserID uint64               `json:"user_id" required:"true"`
+	URL    string               `json:"url" required:"true"`
+	Events []model.WebhookEvent `json:"events" required:"true"`
+}
+
+type webhookResponse struct {
+	ID     uint64               `json:"id"`
+	UserID uint64               `json:"user_id"`
+	URL    string               `json:"url"`
+	Events []model.WebhookEvent `json:"events"`
+	// Secret is only shown once, when webhook is created
+	Secret    string `json:"secret,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type webhookDeliveryResponse struct {
+	ID       uint64             `json:"id"`
+	Event    model.WebhookEvent `json:"event"`
+	Status   string             `json:"status"`
+	Attempts int                `json:"attempts"`
+	Payload  json.RawMessage    `json:"payload"`
+	// NextAttemptAt is only set for pending deliveries
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  string `json:"last_attempt_at,omitempty"`
+	ResponseStatus int    `json:"response_status,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	CreatedAt      string `json:"created_at"`
+}
+
+func webhookResponseFromModel(model model.Webhook) webhookResponse {
+	return webhookResponse{
+		ID:        model.ID,
+		UserID:    model.UserID,
+		URL:       model.URL,
+		Events:    model.Events,
+		CreatedAt: model.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func webhookDeliveryResponseFromModel(delivery model.WebhookDelivery) webhookDeliveryResponse {
+	resp := webhookDeliveryResponse{
+		ID:             delivery.ID,
+		Event:          delivery.Event,
+		Status:         delivery.Status.String(),
+		Attempts:       delivery.Attempts,
+		Payload:        delivery.Payload,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Format(time.RFC3339),
+	}
+
+	if delivery.Status == model.WebhookDeliveryPending {
+		resp.NextAttemptAt = delivery.NextAttemptAt.Format(time.RFC3339)
+	}
+	if delivery.LastAttemptAt != nil {
+		resp.LastAttemptAt = delivery.LastAttemptAt.Format(time.RFC3339)
+	}
+
+	return resp
+}
+
+// HandleCreateWebhook subscribes partner URL to events of a user. Response contains
+// the secret deliveries are signed with, which cannot be retrieved later.
+func (api *API) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var jsonRequest webhookJSONRequest
+
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "create_webhook").Logger()
+	logger.Info().Msg("handling create webhook")
+
+	if err := decodeJSON(r, &jsonRequest); err != nil {
+		logger.Err(err).Msgf("failed to parse request body as JSON")
+		respondWithError(w, r, err)
+		return
+	}
+
+	hook, err := api.webhookService.CreateWebhook(ctx, jsonRequest.UserID, jsonRequest.URL, jsonRequest.Events)
+	if err != nil {
+		logger.Err(err).Msgf("failed to create webhook of user %d for %s", jsonRequest.UserID, jsonRequest.URL)
+		respondWithError(w, r, err)
+		return
+	}
+
+	logger.Info().Msgf("created webhook %d of user %d for %s", hook.ID, hook.UserID, hook.URL)
+
+	resp := webhookResponseFromModel(*hook)
+	resp.Secret = hook.Secret
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&resp); err != nil {
+		logger.Err(err).Msgf("failed to encode json response for webhook %d", hook.ID)
+		return
+	}
+}
+
+// HandleWebhooks returns all webhooks without their secrets
+func (api *API) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "webhooks").Logger()
+	logger.Info().Msg("handling webhooks")
+
+	hooks, err := api.webhookService.Webhooks(ctx)
+	if err != nil {
+		logger.Err(err).Msg("failed to get webhooks")
+		respondWithError(w, r, err)
+		return
+	}
+
+	if len(hooks) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	response := make([]webhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		response = append(response, webhookResponseFromModel(hook))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&response); err != nil {
+		logger.Err(err).Msgf("failed to encode json response from %d webhooks", len(hooks))
+		respondWithError(w, r, err)
+		return
+	}
+}
+
+// HandleDeleteWebhook deletes webhook given in URL. Its pending deliveries are not sent.
+func (api *API) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "delete_webhook").Logger()
+	logger.Info().Msg("handling delete webhook")
+
+	webhookID, err := idParam(r, "webhookID", errInvalidWebhookID)
+	if err != nil {
+		logger.Err(err).Msg("failed to parse webhook id")
+		respondWithError(w, r, err)
+		return
+	}
+
+	if err := api.webhookService.DeleteWebhook(ctx, webhookID); err != nil {
+		logger.Err(err).Msgf("failed to delete webhook %d", webhookID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	logger.Info().Msgf("deleted webhook %d", webhookID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleWebhookDeliveries returns latest deliveries of the webhook given in URL, newest first
+func (api *API) HandleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "webhook_deliveries").Logger()
+	logger.Info().Msg("handling webhook deliveries")
+
+	webhookID, err := idParam(r, "webhookID", errInvalidWebhookID)
+	if err != nil {
+		logger.Err(err).Msg("failed to parse webhook id")
+		respondWithError(w, r, err)
+		return
+	}
+
+	deliveries, err := api.webhookService.Deliveries(ctx, webhookID)
+	if err != nil {
+		logger.Err(err).Msgf("failed to get deliveries of webhook %d", webhookID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	if len(deliveries) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	response := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		response = append(response, webhookDeliveryResponseFromModel(delivery))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&response); err != nil {
+		logger.Err(err).Msgf("failed to encode json response from %d deliveries", len(deliveries))
+		respondWithError(w, r, err)
+		return
+	}
+}
+
+// HandleRedeliverWebhook schedules delivery given in URL to be sent again
+func (api *API) HandleRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx, logger := logging.CtxLogger(r.Context())
+	logger = logger.With().Str(logging.HandlerNameKey, "redeliver_webhook").Logger()
+	logger.Info().Msg("handling redeliver webhook")
+
+	deliveryID, err := idParam(r, "deliveryID", errInvalidDeliveryID)
+	if err != nil {
+		logger.Err(err).Msg("failed to parse delivery id")
+		respondWithError(w, r, err)
+		return
+	}
+
+	if err := api.webhookService.Redeliver(ctx, deliveryID); err != nil {
+		logger.Err(err).Msgf("failed to redeliver %d", deliveryID)
+		respondWithError(w, r, err)
+		return
+	}
+
+	logger.Info().Msgf("scheduled redelivery of %d", deliveryID)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// idParam parses positive integer ID from URL param name, returning invalid if it is not one
+func idParam(r *http.Request, name string, invalid *apperr.Error) (uint64, error) {
+	id, err := strconv.ParseUint(chi.URLParam(r, name), 10, 64)
+	if err != nil || id == 0 {
+		return 0, apperr.Wrap(invalid, err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	"github.com/soundrussian/go-practicum-diploma/service/webhook"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_HandleWebhooks(t *testing.T) {
+	createdAt := time.Date(2022, 3, 7, 9, 5, 11, 0, time.UTC)
+	hook := model.Webhook{
+		ID:        1,
+		UserID:    100,
+		URL:       "https://partner.example/hooks",
+		Secret:    "whsec_secret",
+		Events:    []model.WebhookEvent{model.WebhookOrderCredited},
+		CreatedAt: createdAt,
+	}
+	lastAttemptAt := createdAt.Add(time.Minute)
+	deliveries := []model.WebhookDelivery{
+		{
+			ID:             2,
+			WebhookID:      1,
+			Event:          model.WebhookOrderCredited,
+			Payload:        []byte(`{"order":"79927398713","accrual":500,"credited_at":"2022-03-07T09:05:11Z"}`),
+			Status:         model.WebhookDeliveryPending,
+			Attempts:       1,
+			NextAttemptAt:  createdAt.Add(2 * time.Minute),
+			LastAttemptAt:  &lastAttemptAt,
+			ResponseStatus: http.StatusServiceUnavailable,
+			LastError:      "webhook responded with status 503",
+			CreatedAt:      createdAt,
+		},
+		{
+			ID:             1,
+			WebhookID:      1,
+			Event:          model.WebhookOrderCredited,
+			Payload:        []byte(`{"order":"12345678903","accrual":100,"credited_at":"2022-03-07T09:05:11Z"}`),
+			Status:         model.WebhookDeliverySucceeded,
+			Attempts:       1,
+			NextAttemptAt:  createdAt,
+			LastAttemptAt:  &lastAttemptAt,
+			ResponseStatus: http.StatusOK,
+			CreatedAt:      createdAt,
+		},
+	}
+
+	type args struct {
+		method  string
+		path    string
+		body    string
+		token   string
+		webhook *webhookMock.Webhook
+	}
+	type want struct {
+		status int
+		body   string
+		code   string
+		detail string
+	}
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "returns 403 to support",
+			args: args{
+				method: http.MethodGet,
+				path:   "/api/admin/webhooks",
+				token:  tokenWithRole(supportUserID, model.RoleSupport),
+			},
+			want: want{status: http.StatusForbidden, code: "forbidden", detail: "route is not available to your role"},
+		},
+		{
+			name: "creates webhook and returns its secret",
+			args: args{
+				method:  http.MethodPost,
+				path:    "/api/admin/webhooks",
+				body:    `{"user_id": 100, "url": "https://partner.example/hooks", "events": ["order.credited"]}`,
+				webhook: createWebhookMock(&hook, nil),
+			},
+			want: want{
+				status: http.StatusCreated,
+				body: `{"id":1,"user_id":100,"url":"https://partner.example/hooks","events":["order.credited"],` +
+					`"secret":"whsec_secret","created_at":"2022-03-07T09:05:11Z"}` + "\n",
+			},
+		},
+		{
+			name: "returns 400 if webhook URL is invalid",
+			args: args{
+				method:  http.MethodPost,
+				path:    "/api/admin/webhooks",
+				body:    `{"user_id": 100, "url": "ftp://partner.example/hooks", "events": ["order.credited"]}`,
+				webhook: createWebhookMock(nil, webhook.ErrInvalidURL),
+			},
+			want: want{
+				status: http.StatusBadRequest,
+				code:   "invalid_webhook_url",
+				detail: "webhook url must be absolute http or https url",
+			},
+		},
+		{
+			name: "returns 400 if webhook has no user",
+			args: args{
+				method:  http.MethodPost,
+				path:    "/api/admin/webhooks",
+				body:    `{"url": "https://partner.example/hooks", "events": ["order.credited"]}`,
+				webhook: new(webhookMock.Webhook),
+			},
+			want: want{
+				status: http.StatusBadRequest,
+				code:   "invalid_request",
+				detail: `request body does not match schema: property "user_id" is missing at /user_id`,
+			},
+		},
+		{
+			name: "returns 422 if webhook user does not exist",
+			args: args{
+				method:  http.MethodPost,
+				path:    "/api/admin/webhooks",
+				body:    `{"user_id": 100, "url": "https://partner.example/hooks", "events": ["order.credited"]}`,
+				webhook: createWebhookMock(nil, webhook.ErrUserNotFound),
+			},
+			want: want{
+				status: http.StatusUnprocessableEntity,
+				code:   "webhook_user_not_found",
+				detail: "webhook user does not exist",
+			},
+		},
+		{
+			name: "lists webhooks without secrets",
+			args: args{
+				method:  http.MethodGet,
+				path:    "/api/admin/webhooks",
+				webhook: webhooksMock([]model.Webhook{hook}),
+			},
+			want: want{
+				status: http.StatusOK,
+				body:   `[{"id":1,"user_id":100,"url":"https://partner.example/hooks","events":["order.credited"],"created_at":"2022-03-07T09:05:11Z"}]` + "\n",
+			},
+		},
+		{
+			name: "returns 204 if there are no webhooks",
+			args: args{
+				method:  http.MethodGet,
+				path:    "/api/admin/webhooks",
+				webhook: webhooksMock([]model.Webhook{}),
+			},
+			want: want{status: http.StatusNoContent},
+		},
+		{
+			name: "deletes webhook",
+			args: args{
+				method:  http.MethodDelete,
+				path:    "/api/admin/webhooks/1",
+				webhook: deleteWebhookMock(nil),
+			},
+			want: want{status: http.StatusNoContent},
+		},
+		{
+			name: "returns 404 when deleting unknown webhook",
+			args: args{
+				method:  http.MethodDelete,
+				path:    "/api/admin/webhooks/1",
+				webhook: deleteWebhookMock(webhook.ErrWebhookNotFound),
+			},
+			want: want{status: http.StatusNotFound, code: "webhook_not_found", detail: "webhook not found"},
+		},
+		{
+			name: "lists deliveries of webhook",
+			args: args{
+				method:  http.MethodGet,
+				path:    "/api/admin/webhooks/1/deliveries",
+				webhook: deliveriesMock(deliveries),
+			},
+			want: want{
+				status: http.StatusOK,
+				body: `[{"id":2,"event":"order.credited","status":"PENDING","attempts":1,` +
+					`"payload":{"order":"79927398713","accrual":500,"credited_at":"2022-03-07T09:05:11Z"},` +
+					`"next_attempt_at":"2022-03-07T09:07:11Z","last_attempt_at":"2022-03-07T09:06:11Z",` +
+					`"response_status":503,"last_error":"webhook responded with status 503","created_at":"2022-03-07T09:05:11Z"},` +
+					`{"id":1,"event":"order.credited","status":"SUCCEEDED","attempts":1,` +
+					`"payload":{"order":"12345678903","accrual":100,"credited_at":"2022-03-07T09:05:11Z"},` +
+					`"last_attempt_at":"2022-03-07T09:06:11Z","response_status":200,"created_at":"2022-03-07T09:05:11Z"}]` + "\n",
+			},
+		},
+		{
+			name: "returns 204 if webhook has no deliveries",
+			args: args{
+				method:  http.MethodGet,
+				path:    "/api/admin/webhooks/1/deliveries",
+				webhook: deliveriesMock([]model.WebhookDelivery{}),
+			},
+			want: want{status: http.StatusNoContent},
+		},
+		{
+			name: "schedules redelivery",
+			args: args{
+				method:  http.MethodPost,
+				path:    "/api/admin/webhooks/deliveries/2/redeliver",
+				webhook: redeliverMock(nil),
+			},
+			want: want{status: http.StatusAccepted},
+		},
+		{
+			name: "returns 404 when redelivering unknown delivery",
+			args: args{
+				method:  http.MethodPost,
+				path:    "/api/admin/webhooks/deliveries/2/redeliver",
+				webhook: redeliverMock(webhook.ErrDeliveryNotFound),
+			},
+			want: want{status: http.StatusNotFound, code: "webhook_delivery_not_found", detail: "webhook delivery not found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.args.webhook == nil {
+				tt.args.webhook = new(webhookMock.Webhook)
+			}
+			if tt.args.token == "" {
+				tt.args.token = tokenWithRole(adminUserID, model.RoleAdmin)
+			}
+
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(new(authMock.Auth)), new(balanceMock.Balance), new(orderMock.Order), tt.args.webhook)
+			require.NoError(t, err)
+
+			ts := newTestServer(t, a)
+			defer ts.Close()
+
+			req, err := http.NewRequest(tt.args.method, ts.URL+tt.args.path, strings.NewReader(tt.args.body))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tt.args.token))
+			if tt.args.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.want.status, resp.StatusCode)
+
+			if tt.want.code != "" {
+				assertProblem(t, resp, tt.want.code, tt.want.detail)
+				return
+			}
+
+			resBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.body, string(resBody))
+			tt.args.webhook.AssertExpectations(t)
+		})
+	}
+}
+
+func createWebhookMock(hook *model.Webhook, err error) *webhookMock.Webhook {
+	m := new(webhookMock.Webhook)
+	m.On("CreateWebhook", mock.Anything, uint64(100), mock.AnythingOfType("string"), []model.WebhookEvent{model.WebhookOrderCredited}).Return(hook, err)
+	return m
+}
+
+func webhooksMock(hooks []model.Webhook) *webhookMock.Webhook {
+	m := new(webhookMock.Webhook)
+	m.On("Webhooks", mock.Anything).Return(hooks, nil)
+	return m
+}
+
+func deleteWebhookMock(err error) *webhookMock.Webhook {
+	m := new(webhookMock.Webhook)
+	m.On("DeleteWebhook", mock.Anything, uint64(1)).Return(err)
+	return m
+}
+
+func deliveriesMock(deliveries []model.WebhookDelivery) *webhookMock.Webhook {
+	m := new(webhookMock.Webhook)
+	m.On("Deliveries", mock.Anything, uint64(1)).Return(deliveries, nil)
+	return m
+}
+
+func redeliverMock(err error) *webhookMock.Webhook {
+	m := new(webhookMock.Webhook)
+	m.On("Redeliver", mock.Anything, uint64(2)).Return(err)
+	return m
+}
@@ -6,12 +6,12 @@ import (
 	"github.com/soundrussian/go-practicum-diploma/service/balance"
 	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
 	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
-	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -66,13 +66,49 @@ func TestAPI_HandleWithdraw(t *testing.T) {
 				status: http.StatusBadRequest,
 			},
 		},
+		{
+			name: "returns 400 if body has unknown field",
+			args: args{
+				token:   token(100),
+				balance: new(balanceMock.Balance),
+				headers: map[string]string{"Content-Type": "application/json"},
+				body:    `{"order": "79927398713", "sum": 10, "currency": "USD"}`,
+			},
+			want: want{
+				status: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "returns 400 if body has data after JSON",
+			args: args{
+				token:   token(100),
+				balance: new(balanceMock.Balance),
+				headers: map[string]string{"Content-Type": "application/json"},
+				body:    `{"order": "79927398713", "sum": 10}"`,
+			},
+			want: want{
+				status: http.StatusBadRequest,
+			},
+		},
+		{
+			name: "returns 413 if body is too large",
+			args: args{
+				token:   token(100),
+				balance: new(balanceMock.Balance),
+				headers: map[string]string{"Content-Type": "application/json"},
+				body:    `{"order": "79927398713", "sum": 10, "comment": "` + strings.Repeat("a", 2<<20) + `"}`,
+			},
+			want: want{
+				status: http.StatusRequestEntityTooLarge,
+			},
+		},
 		{
 			name: "returns 422 if order is invalid",
 			args: args{
 				token:   token(100),
 				balance: invalidOrderMock(),
 				headers: map[string]string{"Content-Type": "application/json"},
-				body:    `{"order": "not a number", "sum": 10}"`,
+				body:    `{"order": "not a number", "sum": 10}`,
 			},
 			want: want{
 				status: http.StatusUnprocessableEntity,
@@ -84,7 +120,7 @@ func TestAPI_HandleWithdraw(t *testing.T) {
 				token:   token(100),
 				balance: invalidSumMock(),
 				headers: map[string]string{"Content-Type": "application/json"},
-				body:    `{"order": "79927398713", "sum": -10}"`,
+				body:    `{"order": "79927398713", "sum": -10}`,
 			},
 			want: want{
 				status: http.StatusUnprocessableEntity,
@@ -96,7 +132,7 @@ func TestAPI_HandleWithdraw(t *testing.T) {
 				token:   token(100),
 				balance: notEnoughBalanceMock(),
 				headers: map[string]string{"Content-Type": "application/json"},
-				body:    `{"order": "79927398713", "sum": 10}"`,
+				body:    `{"order": "79927398713", "sum": 10}`,
 			},
 			want: want{
 				status: http.StatusPaymentRequired,
@@ -108,7 +144,7 @@ func TestAPI_HandleWithdraw(t *testing.T) {
 				token:   token(100),
 				balance: successfulWithdrawal(),
 				headers: map[string]string{"Content-Type": "application/json"},
-				body:    `{"order": "79927398713", "sum": 10.22}"`,
+				body:    `{"order": "79927398713", "sum": 10.22}`,
 			},
 			want: want{
 				status: http.StatusOK,
@@ -117,11 +153,10 @@ func TestAPI_HandleWithdraw(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a, err := New(new(authMock.Auth), tt.args.balance, new(orderMock.Order))
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(new(authMock.Auth)), tt.args.balance, new(orderMock.Order), new(webhookMock.Webhook))
 			require.NoError(t, err)
 
-			r := a.routes()
-			ts := httptest.NewServer(r)
+			ts := newTestServer(t, a)
 			defer ts.Close()
 
 			req, err := http.NewRequest("POST", ts.URL+"/api/user/balance/withdraw", strings.NewReader(tt.args.body))
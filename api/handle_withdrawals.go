@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
 	"net/http"
@@ -33,14 +34,14 @@ func (api *API) HandleWithdrawals(w http.ResponseWriter, r *http.Request) {
 	userID, err := curruser.CurrentUser(ctx)
 	if err != nil {
 		logger.Err(err).Msg("failed to get current user from context")
-		w.WriteHeader(http.StatusUnauthorized)
+		respondWithError(w, r, apperr.Wrap(errUnauthenticated, err))
 		return
 	}
 
 	withdrawals, err := api.balanceService.Withdrawals(ctx, userID)
 	if err != nil {
 		logger.Err(err).Msg("failed to fetch withdrawals")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -54,10 +55,12 @@ func (api *API) HandleWithdrawals(w http.ResponseWriter, r *http.Request) {
 		response = append(response, withdrawalsResponseFromModel(withdrawal))
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+
 	encoder := json.NewEncoder(w)
 	if err := encoder.Encode(&response); err != nil {
 		logger.Err(err).Msgf("failed to encode %+v", withdrawals)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithError(w, r, err)
 		return
 	}
 }
@@ -9,12 +9,12 @@ import (
 	"github.com/soundrussian/go-practicum-diploma/service/balance"
 	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
 	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
-	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -92,11 +92,10 @@ func TestAPI_HandleWithdrawals(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a, err := New(new(authMock.Auth), tt.args.balance, new(orderMock.Order))
+			a, err := New(DefaultConfig(), testAuth.TokenAuth(), activeUser(new(authMock.Auth)), tt.args.balance, new(orderMock.Order), new(webhookMock.Webhook))
 			require.NoError(t, err)
 
-			r := a.routes()
-			ts := httptest.NewServer(r)
+			ts := newTestServer(t, a)
 			defer ts.Close()
 
 			req, err := http.NewRequest("GET", ts.URL+"/api/user/balance/withdrawals", strings.NewReader(tt.args.body))
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// random is used for sampling and is a variable to be replaced in tests
+var random = defaultRandom
+
+var defaultRandom = rand.Float64
+
+// AccessLog is middleware that writes one structured line per completed request
+// with status, duration, response size and current user. Correlation ID
+// comes with the request logger.
+//
+// Successful (2xx) responses are logged with sampleRate probability, so that busy
+// deployments could log only a share of them. Other responses are always logged.
+func AccessLog(sampleRate float64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, logger := logging.CtxLogger(r.Context())
+
+			entry := &logging.AccessEntry{}
+			ctx = logging.WithAccessEntry(ctx, entry)
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			duration := time.Since(start)
+
+			// Handlers that write nothing respond with 200 OK
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			var event *zerolog.Event
+			switch {
+			case status >= http.StatusInternalServerError:
+				event = logger.Error()
+			case status >= http.StatusBadRequest:
+				event = logger.Warn()
+			default:
+				if status < http.StatusMultipleChoices && random() >= sampleRate {
+					return
+				}
+				event = logger.Info()
+			}
+
+			event = event.
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Int("status", status).
+				Dur("duration", duration).
+				Int("bytes", ww.BytesWritten())
+
+			if entry.UserID != nil {
+				event = event.Uint64(logging.CurrentUserKey, *entry.UserID)
+			}
+
+			event.Msg("request completed")
+		})
+	}
+}
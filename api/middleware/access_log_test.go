@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name       string
+		sampleRate float64
+		random     float64
+		status     int
+		body       string
+		userID     *uint64
+		wantLogged bool
+		wantLevel  string
+	}{
+		{
+			name:       "logs successful request",
+			sampleRate: 1,
+			random:     0.99,
+			status:     http.StatusOK,
+			body:       "hello",
+			wantLogged: true,
+			wantLevel:  "info",
+		},
+		{
+			name:       "logs current user",
+			sampleRate: 1,
+			status:     http.StatusAccepted,
+			userID:     userIDPtr(100),
+			wantLogged: true,
+			wantLevel:  "info",
+		},
+		{
+			name:       "skips successful request that is not sampled",
+			sampleRate: 0.5,
+			random:     0.5,
+			status:     http.StatusOK,
+			wantLogged: false,
+		},
+		{
+			name:       "logs sampled successful request",
+			sampleRate: 0.5,
+			random:     0.49,
+			status:     http.StatusOK,
+			wantLogged: true,
+			wantLevel:  "info",
+		},
+		{
+			name:       "always logs client errors",
+			sampleRate: 0,
+			random:     0.99,
+			status:     http.StatusNotFound,
+			wantLogged: true,
+			wantLevel:  "warn",
+		},
+		{
+			name:       "always logs server errors",
+			sampleRate: 0,
+			random:     0.99,
+			status:     http.StatusInternalServerError,
+			wantLogged: true,
+			wantLevel:  "error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			random = func() float64 { return tt.random }
+			defer func() { random = defaultRandom }()
+
+			var out bytes.Buffer
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.userID != nil {
+					logging.SetAccessUser(r.Context(), *tt.userID)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/api/user/orders", nil)
+			ctx := logging.SetCorrelationID(r.Context(), "correlation")
+			ctx = logging.SetCtxLogger(ctx, zerolog.New(&out).With().Str(logging.CorrelationIDKey, "correlation").Logger())
+
+			AccessLog(tt.sampleRate)(handler).ServeHTTP(httptest.NewRecorder(), r.WithContext(ctx))
+
+			if !tt.wantLogged {
+				assert.Empty(t, out.String())
+				return
+			}
+
+			var line map[string]interface{}
+			require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+
+			assert.Equal(t, tt.wantLevel, line["level"])
+			assert.Equal(t, http.MethodGet, line["method"])
+			assert.Equal(t, "/api/user/orders", line["path"])
+			assert.Equal(t, float64(tt.status), line["status"])
+			assert.Equal(t, float64(len(tt.body)), line["bytes"])
+			assert.Equal(t, "correlation", line[logging.CorrelationIDKey])
+			assert.Contains(t, line, "duration")
+			if tt.userID != nil {
+				assert.Equal(t, float64(*tt.userID), line[logging.CurrentUserKey])
+			} else {
+				assert.NotContains(t, line, logging.CurrentUserKey)
+			}
+		})
+	}
+}
+
+func TestAccessLog_DefaultStatus(t *testing.T) {
+	var out bytes.Buffer
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(logging.SetCtxLogger(r.Context(), zerolog.New(&out)))
+
+	AccessLog(1)(handler).ServeHTTP(httptest.NewRecorder(), r)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, float64(http.StatusOK), line["status"])
+}
+
+func userIDPtr(id uint64) *uint64 {
+	return &id
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"github.com/soundrussian/go-practicum-diploma/service/auth"
+	"net/http"
+)
+
+// ActiveUser is middleware that rejects requests of users who have deleted their accounts
+// and sets role of the current user as it is stored, see RequireRole. Auth tokens have
+// no expiration, so this check is what revokes tokens of deleted users and makes
+// role changes take effect right away. It should be used after CurrentUser.
+func ActiveUser(authService auth.Auth) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, logger := logging.CtxLogger(r.Context())
+
+			userID, err := curruser.CurrentUser(ctx)
+			if err != nil {
+				logger.Err(err).Msg("failed to get current user from context")
+				problem.Write(w, r, apperr.Wrap(errUnauthenticated, err))
+				return
+			}
+
+			user, err := authService.User(ctx, userID)
+			if err != nil {
+				logger.Err(err).Msgf("user %d is not active", userID)
+				problem.Write(w, r, apperr.Wrap(errUnauthenticated, err))
+				return
+			}
+
+			// Requests authenticated by API keys keep the least privileged role
+			if _, limited := curruser.Scopes(ctx); !limited {
+				role := user.Role
+				if !role.Valid() {
+					role = model.RoleUser
+				}
+				logger = logger.With().Str(logging.RoleKey, string(role)).Logger()
+				ctx = logging.SetCtxLogger(ctx, logger)
+				ctx = curruser.SetRole(ctx, role)
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
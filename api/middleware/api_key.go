@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"github.com/soundrussian/go-practicum-diploma/service/auth"
+	"net/http"
+)
+
+// APIKeyHeader is the header machine-to-machine clients pass their API key in
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyUser is middleware that authenticates requests carrying API key in APIKeyHeader.
+// It saves key owner in request context the same way CurrentUser does, along with
+// key scopes. Requests without the header are passed through to be authenticated
+// by CurrentUser.
+func APIKeyUser(authService auth.Auth) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, logger := logging.CtxLogger(r.Context())
+
+			apiKey, err := authService.AuthenticateAPIKey(ctx, key)
+			if err != nil {
+				logger.Err(err).Msg("failed to authenticate api key")
+				problem.Write(w, r, apperr.Wrap(errUnauthenticated, err))
+				return
+			}
+
+			logger = logger.With().
+				Uint64(logging.CurrentUserKey, apiKey.UserID).
+				Uint64(logging.APIKeyIDKey, apiKey.ID).
+				Logger()
+			ctx = logging.SetCtxLogger(ctx, logger)
+			ctx = curruser.SetCurrentUser(ctx, apiKey.UserID)
+			ctx = curruser.SetScopes(ctx, apiKey.Scopes)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
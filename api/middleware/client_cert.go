@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"net/http"
+)
+
+// RequireClientCert is middleware that only lets through requests made over TLS
+// with client certificate that server has verified against its client CAs.
+// It guards internal routes in addition to their own authentication.
+func RequireClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			_, logger := logging.CtxLogger(r.Context())
+			logger.Error().Msgf("request to %s has no verified client certificate", r.URL.Path)
+			problem.Write(w, r, errClientCertRequired)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
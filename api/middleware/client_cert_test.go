@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireClientCert(t *testing.T) {
+	tests := []struct {
+		name       string
+		tls        *tls.ConnectionState
+		wantStatus int
+	}{
+		{
+			name:       "rejects plain HTTP request",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "rejects request without client certificate",
+			tls:        &tls.ConnectionState{},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "lets through request with verified client certificate",
+			tls:        &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}},
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireClientCert(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			r.TLS = tt.tls
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"net/http"
+	"strings"
+)
+
+// AllowContentType is middleware that rejects requests with body of other content types
+// than contentTypes with 415 Unsupported Media Type. It works as the one of chi,
+// but responds with problem details like other errors. Requests without body are let through.
+func AllowContentType(contentTypes ...string) func(next http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(contentTypes))
+	for _, contentType := range contentTypes {
+		allowed[strings.ToLower(strings.TrimSpace(contentType))] = true
+	}
+
+	errUnsupported := apperr.New(apperr.CodeUnsupportedMediaType, "Content-Type of request body must be one of "+strings.Join(contentTypes, ", ")).
+		WithReason("unsupported_content_type")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			contentType := r.Header.Get("Content-Type")
+			mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+			if allowed[mediaType] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			_, logger := logging.CtxLogger(r.Context())
+			logger.Error().Msgf("request body has unsupported content type %q", contentType)
+			problem.Write(w, r, errUnsupported)
+		})
+	}
+}
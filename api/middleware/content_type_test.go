@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{
+			name:        "lets allowed content type through",
+			contentType: "application/json",
+			body:        "{}",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "ignores case and parameters",
+			contentType: "Text/Plain; charset=utf-8",
+			body:        "79927398713",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:       "lets request without body through",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "rejects other content type with problem details",
+			contentType: "application/xml",
+			body:        "<orders/>",
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "rejects body without content type",
+			body:       "{}",
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := AllowContentType("application/json", "text/plain")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodPost, "/api/user/orders/batch", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+
+			var p problem.Details
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+			assert.Equal(t, "unsupported_content_type", p.Code)
+			assert.Equal(t, "Content-Type of request body must be one of application/json, text/plain", p.Detail)
+		})
+	}
+}
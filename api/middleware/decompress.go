@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// supportedEncodings is sent in Accept-Encoding header of 415 responses to requests with other encodings
+const supportedEncodings = "gzip, deflate, br"
+
+var (
+	errInvalidEncoding     = apperr.New(apperr.CodeInvalidArgument, "request body is not valid for its Content-Encoding").WithReason("invalid_content_encoding")
+	errUnsupportedEncoding = apperr.New(apperr.CodeUnsupportedMediaType, "Content-Encoding of request body must be one of "+supportedEncodings).WithReason("unsupported_content_encoding")
+)
+
+// Decompress is middleware that decodes request bodies sent with Content-Encoding gzip, deflate or br,
+// so that handlers and LogRequest see the body as it was before compression. Body is decoded
+// before the request is passed on, and at most maxSize bytes are decoded, so that a small body
+// cannot expand to exhaust memory. Requests with other encodings are rejected with 415
+// Unsupported Media Type, bodies that cannot be decoded with 400, and bodies that exceed
+// maxSize once decoded with 413.
+func Decompress(maxSize int64) func(next http.Handler) http.Handler {
+	errTooLarge := apperr.New(apperr.CodeTooLarge, "decompressed request body is too large").
+		WithPublic(fmt.Sprintf("request body may be at most %d bytes once decompressed", maxSize)).
+		WithReason("body_too_large")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			_, logger := logging.CtxLogger(r.Context())
+
+			decoder, err := newDecoder(encoding, r.Body)
+			if errors.Is(err, errUnsupportedEncoding) {
+				logger.Error().Msgf("request body has unsupported encoding %q", encoding)
+				w.Header().Set("Accept-Encoding", supportedEncodings)
+				problem.Write(w, r, errUnsupportedEncoding)
+				return
+			}
+			if err != nil {
+				logger.Err(err).Msgf("failed to decode %s request body", encoding)
+				problem.Write(w, r, apperr.Wrap(errInvalidEncoding, err))
+				return
+			}
+
+			// One byte over the limit is read to tell body of exactly maxSize bytes from a larger one
+			decoded, err := io.ReadAll(io.LimitReader(decoder, maxSize+1))
+			if err != nil {
+				logger.Err(err).Msgf("failed to decode %s request body", encoding)
+				problem.Write(w, r, apperr.Wrap(errInvalidEncoding, err))
+				return
+			}
+			if int64(len(decoded)) > maxSize {
+				logger.Error().Msgf("%s request body exceeds %d bytes once decompressed", encoding, maxSize)
+				problem.Write(w, r, errTooLarge)
+				return
+			}
+			_ = r.Body.Close()
+
+			r.Body = io.NopCloser(bytes.NewReader(decoded))
+			r.ContentLength = int64(len(decoded))
+			r.Header.Set("Content-Length", strconv.Itoa(len(decoded)))
+			r.Header.Del("Content-Encoding")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newDecoder returns reader decoding body compressed with encoding
+func newDecoder(encoding string, body io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(body)
+	case "deflate":
+		return newDeflateDecoder(body)
+	case "br":
+		return brotli.NewReader(body), nil
+	}
+
+	return nil, errUnsupportedEncoding
+}
+
+// newDeflateDecoder decodes deflate body, which is zlib stream according to RFC 9110,
+// but is sent as raw deflate stream by some clients, so both are accepted
+func newDeflateDecoder(body io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+
+	header, err := buffered.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compression method is deflate, and the header is a multiple of 31, see RFC 1950
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+
+	return flate.NewReader(buffered), nil
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecompress(t *testing.T) {
+	const body = `{"order":"2377225624","sum":751}`
+
+	tests := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		maxSize    int64
+		wantStatus int
+		wantBody   string
+		wantCode   string
+	}{
+		{
+			name:       "passes through request without encoding",
+			body:       []byte(body),
+			wantStatus: http.StatusOK,
+			wantBody:   body,
+		},
+		{
+			name:       "passes through identity encoded request",
+			encoding:   "identity",
+			body:       []byte(body),
+			wantStatus: http.StatusOK,
+			wantBody:   body,
+		},
+		{
+			name:       "decodes gzip",
+			encoding:   "gzip",
+			body:       compress(t, "gzip", body),
+			wantStatus: http.StatusOK,
+			wantBody:   body,
+		},
+		{
+			name:       "decodes x-gzip",
+			encoding:   "x-gzip",
+			body:       compress(t, "gzip", body),
+			wantStatus: http.StatusOK,
+			wantBody:   body,
+		},
+		{
+			name:       "decodes zlib deflate",
+			encoding:   "deflate",
+			body:       compress(t, "deflate", body),
+			wantStatus: http.StatusOK,
+			wantBody:   body,
+		},
+		{
+			name:       "decodes raw deflate",
+			encoding:   "deflate",
+			body:       compress(t, "raw", body),
+			wantStatus: http.StatusOK,
+			wantBody:   body,
+		},
+		{
+			name:       "decodes brotli",
+			encoding:   "br",
+			body:       compress(t, "br", body),
+			wantStatus: http.StatusOK,
+			wantBody:   body,
+		},
+		{
+			name:       "ignores case of encoding",
+			encoding:   "GZIP",
+			body:       compress(t, "gzip", body),
+			wantStatus: http.StatusOK,
+			wantBody:   body,
+		},
+		{
+			name:       "accepts body of exactly max size",
+			encoding:   "gzip",
+			body:       compress(t, "gzip", body),
+			maxSize:    int64(len(body)),
+			wantStatus: http.StatusOK,
+			wantBody:   body,
+		},
+		{
+			name:       "rejects body over max size once decompressed",
+			encoding:   "gzip",
+			body:       compress(t, "gzip", strings.Repeat("0", 10<<20)),
+			maxSize:    1 << 20,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "body_too_large",
+		},
+		{
+			name:       "rejects body that is not gzip",
+			encoding:   "gzip",
+			body:       []byte(body),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_content_encoding",
+		},
+		{
+			name:       "rejects truncated gzip",
+			encoding:   "gzip",
+			body:       compress(t, "gzip", body)[:20],
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_content_encoding",
+		},
+		{
+			name:       "rejects empty gzip body",
+			encoding:   "gzip",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_content_encoding",
+		},
+		{
+			name:       "rejects malformed brotli",
+			encoding:   "br",
+			body:       []byte(body),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_content_encoding",
+		},
+		{
+			name:       "rejects unsupported encoding",
+			encoding:   "compress",
+			body:       []byte(body),
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantCode:   "unsupported_content_encoding",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxSize := tt.maxSize
+			if maxSize == 0 {
+				maxSize = 1 << 10
+			}
+
+			var gotBody []byte
+			var gotRequest *http.Request
+			handler := Decompress(maxSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var err error
+				gotBody, err = io.ReadAll(r.Body)
+				require.NoError(t, err)
+				gotRequest = r
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				r.Header.Set("Content-Encoding", tt.encoding)
+			}
+			r.Header.Set("Content-Length", "100")
+			r = r.WithContext(logging.SetCorrelationID(r.Context(), "correlation-id"))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				assert.Nil(t, gotRequest, "request must not reach handler")
+			}
+			if tt.wantStatus == http.StatusUnsupportedMediaType {
+				assert.Equal(t, "gzip, deflate, br", w.Header().Get("Accept-Encoding"))
+			}
+			if tt.wantCode != "" {
+				var p problem.Details
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+				assert.Equal(t, tt.wantStatus, p.Status)
+				assert.Equal(t, tt.wantCode, p.Code)
+				assert.Equal(t, "correlation-id", p.CorrelationID)
+			}
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, string(gotBody))
+			}
+			if tt.wantStatus == http.StatusOK && tt.encoding != "" && tt.encoding != "identity" {
+				assert.Empty(t, gotRequest.Header.Get("Content-Encoding"))
+				assert.Equal(t, int64(len(body)), gotRequest.ContentLength)
+				assert.Equal(t, "32", gotRequest.Header.Get("Content-Length"))
+			}
+		})
+	}
+}
+
+// compress compresses body with format: gzip, deflate (zlib), raw (deflate without zlib header) or br
+func compress(t *testing.T, format string, body string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch format {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw":
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	default:
+		t.Fatalf("unknown format %s", format)
+	}
+
+	_, err = io.WriteString(w, body)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
@@ -0,0 +1,14 @@
+package middleware
+
+import "github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+
+var (
+	errUnauthenticated    = apperr.New(apperr.CodeUnauthenticated, "unauthorized").WithReason("unauthenticated")
+	errRoleForbidden      = apperr.New(apperr.CodeForbidden, "route is not available to your role").WithReason("forbidden")
+	errMissingScope       = apperr.New(apperr.CodeForbidden, "api key does not have scope required by the route").WithReason("insufficient_scope")
+	errSessionRequired    = apperr.New(apperr.CodeForbidden, "route is not available to api keys").WithReason("session_required")
+	errInvalidJSON        = apperr.New(apperr.CodeInvalidArgument, "request body is not valid JSON").WithReason("invalid_json")
+	errInvalidRequest     = apperr.New(apperr.CodeInvalidArgument, "request does not match API specification").WithReason("invalid_request")
+	errClientCertRequired = apperr.New(apperr.CodeForbidden, "route requires client certificate").WithReason("client_certificate_required")
+	errRateLimited        = apperr.New(apperr.CodeTooManyRequests, "too many requests, retry later").WithReason("rate_limited")
+)
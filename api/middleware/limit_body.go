@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"io"
+	"net/http"
+)
+
+// LimitBody is middleware that limits request body to maxSize bytes. Requests declaring
+// larger Content-Length are rejected with 413 Request Entity Too Large right away,
+// and reading more than maxSize bytes of other bodies fails with error of code
+// apperr.CodeTooLarge, which handlers respond with as is. Body is limited before
+// anything reads it, e.g. ValidateRequest, which reads it whole.
+func LimitBody(maxSize int64) func(next http.Handler) http.Handler {
+	errTooLarge := apperr.New(apperr.CodeTooLarge, "request body is too large").
+		WithPublic(fmt.Sprintf("request body may be at most %d bytes", maxSize)).
+		WithReason("body_too_large")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxSize {
+				_, logger := logging.CtxLogger(r.Context())
+				logger.Error().Msgf("request body of %d bytes exceeds %d bytes", r.ContentLength, maxSize)
+				problem.Write(w, r, errTooLarge)
+				return
+			}
+
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &limitedBody{body: r.Body, remaining: maxSize, err: errTooLarge}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitedBody fails with err once more than remaining bytes are read from body
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+
+	// One byte over the limit is read to tell body of exactly the limit from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), b.err
+	}
+
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
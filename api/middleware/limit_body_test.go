@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitBody(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		wantStatus    int
+		wantBody      string
+		wantReadErr   bool
+	}{
+		{
+			name:          "passes body under the limit",
+			body:          "79927398713",
+			contentLength: 11,
+			wantStatus:    http.StatusOK,
+			wantBody:      "79927398713",
+		},
+		{
+			name:          "passes body of exactly the limit",
+			body:          "7992739871300000",
+			contentLength: 16,
+			wantStatus:    http.StatusOK,
+			wantBody:      "7992739871300000",
+		},
+		{
+			name:          "rejects declared length over the limit before handler runs",
+			body:          "79927398713000000",
+			contentLength: 17,
+			wantStatus:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:          "fails reading body of unknown length over the limit",
+			body:          strings.Repeat("7", 100),
+			contentLength: -1,
+			wantStatus:    http.StatusOK,
+			wantBody:      strings.Repeat("7", 16),
+			wantReadErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody []byte
+			var readErr error
+			called := false
+			handler := LimitBody(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				gotBody, readErr = io.ReadAll(r.Body)
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/api/user/orders", strings.NewReader(tt.body))
+			r.ContentLength = tt.contentLength
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				assert.False(t, called, "request must not reach handler")
+
+				var p struct {
+					Code   string `json:"code"`
+					Detail string `json:"detail"`
+				}
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+				assert.Equal(t, "body_too_large", p.Code)
+				assert.Equal(t, "request body may be at most 16 bytes", p.Detail)
+				return
+			}
+
+			assert.Equal(t, tt.wantBody, string(gotBody))
+			if tt.wantReadErr {
+				require.Error(t, readErr)
+				assert.Equal(t, apperr.CodeTooLarge, apperr.CodeOf(readErr))
+			} else {
+				assert.NoError(t, readErr)
+			}
+		})
+	}
+}
@@ -1,16 +1,12 @@
 package middleware
 
 import (
-	"bytes"
-	"github.com/rs/zerolog/log"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
-	"io/ioutil"
 	"net/http"
 	"strings"
 )
 
 type logEntry struct {
-	body   string
 	method string
 	addr   string
 	path   string
@@ -26,34 +22,43 @@ func fromRequest(r *http.Request) logEntry {
 	return le
 }
 
+// LogRequest logs incoming requests. Completed requests are logged by AccessLog. Request body is captured while handlers read it
+// and is logged after the request has been handled according to body policy of the route,
+// see LogBody. By default body is not logged.
 func LogRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, logger := logging.CtxLogger(r.Context())
 
 		entry := fromRequest(r)
 
-		buf, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			log.Error().Msgf("[%s] %s Error reading request body: %v", strings.ToUpper(entry.method), entry.path, err.Error())
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		entry.body = string(buf)
-
-		// Make sure body can be read again in next handlers
-		// See https://gist.github.com/briangershon/fa9feb08e6a65d52bdc35c738d8cf104?permalink_comment_id=3719593#gistcomment-3719593
-		reader := ioutil.NopCloser(bytes.NewBuffer(buf))
-		r.Body = reader
-
 		logger = logger.With().
 			Str("method", entry.method).
 			Str("addr", entry.addr).
 			Str("path", entry.path).
-			Str("body", entry.body).
 			Logger()
 
-		logger.Info().Msgf("[%s] %s", strings.ToUpper(entry.method), entry.path)
+		logger.Debug().Msgf("[%s] %s", strings.ToUpper(entry.method), entry.path)
+
+		policy := logging.BodyOmit
+		ctx = logging.WithBodyPolicy(ctx, &policy)
+
+		body := logging.NewBodyCapture(r.Body, logging.MaxLoggedBody)
+		r.Body = body
 
 		next.ServeHTTP(w, r.WithContext(ctx))
+
+		if loggable := body.Loggable(policy); loggable != "" {
+			logger.Debug().Str("body", loggable).Msgf("[%s] %s request body", strings.ToUpper(entry.method), entry.path)
+		}
 	})
 }
+
+// LogBody sets how request body is logged for the route
+func LogBody(policy logging.BodyPolicy) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logging.SetBodyPolicy(r.Context(), policy)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
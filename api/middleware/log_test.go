@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogRequest_Body(t *testing.T) {
+	const secret = "secret-password"
+
+	// Bodies are logged at debug level
+	initial := logging.Level()
+	logging.SetLevel(zerolog.DebugLevel)
+	defer logging.SetLevel(initial)
+
+	tests := []struct {
+		name       string
+		policy     *logging.BodyPolicy
+		wantInLog  string
+		wantSecret bool
+	}{
+		{
+			name:      "omits body without policy",
+			wantInLog: "bytes omitted",
+		},
+		{
+			name:      "redacts body",
+			policy:    policyPtr(logging.BodyRedacted),
+			wantInLog: logging.Redacted,
+		},
+		{
+			name:       "logs full body",
+			policy:     policyPtr(logging.BodyFull),
+			wantInLog:  secret,
+			wantSecret: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			body := `{"login": "user", "password": "` + secret + `"}`
+
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				read, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.Equal(t, body, string(read))
+			})
+			if tt.policy != nil {
+				handler = LogBody(*tt.policy)(handler)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/api/user/login", strings.NewReader(body))
+			r = r.WithContext(logging.SetCtxLogger(r.Context(), zerolog.New(&out)))
+
+			LogRequest(handler).ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Contains(t, out.String(), tt.wantInLog)
+			if !tt.wantSecret {
+				assert.NotContains(t, out.String(), secret)
+			}
+		})
+	}
+}
+
+func policyPtr(policy logging.BodyPolicy) *logging.BodyPolicy {
+	return &policy
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/soundrussian/go-practicum-diploma/pkg/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute labels requests that matched no route, so that
+// scanning random paths does not create a time series per path
+const unmatchedRoute = "unmatched"
+
+// Metrics is middleware that counts requests and observes their latency
+// by method, chi route pattern and status
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+
+		next.ServeHTTP(ww, r)
+
+		duration := time.Since(start)
+
+		route := unmatchedRoute
+		if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil && routeCtx.RoutePattern() != "" {
+			route = routeCtx.RoutePattern()
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		labels := []string{r.Method, route, strconv.Itoa(status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(duration.Seconds())
+	})
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/soundrussian/go-practicum-diploma/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Metrics)
+	r.Get("/api/user/api-keys/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantLabels []string
+	}{
+		{
+			name:       "labels request with route pattern",
+			path:       "/api/user/api-keys/42",
+			wantLabels: []string{http.MethodGet, "/api/user/api-keys/{id}", "204"},
+		},
+		{
+			name:       "labels request that matched no route",
+			path:       "/wp-admin",
+			wantLabels: []string{http.MethodGet, unmatchedRoute, "404"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.HTTPRequests.WithLabelValues(tt.wantLabels...)
+			before := testutil.ToFloat64(counter)
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
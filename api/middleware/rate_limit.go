@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/metrics"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"github.com/soundrussian/go-practicum-diploma/pkg/ratelimit"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitKey returns key identifying the client request is limited by
+type RateLimitKey func(r *http.Request) string
+
+// ByUser limits requests by current user, see CurrentUser.
+// Requests without current user are limited by remote IP.
+func ByUser(r *http.Request) string {
+	userID, err := curruser.CurrentUser(r.Context())
+	if err != nil {
+		return ByIP(r)
+	}
+
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// ByIP limits requests by remote IP
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// RateLimit is middleware that lets each client identified by key make
+// as many requests as limit allows, keeping buckets in store. Every response
+// tells the client its quota in RateLimit-* headers, and requests over the limit
+// are rejected with 429 Too Many Requests and Retry-After header.
+// Routes sharing name share buckets. Requests are let through if store fails,
+// as limits protect the service rather than guard access to it.
+// limit is called on every request, so that limits could be changed while the server is running.
+func RateLimit(name string, limit func() ratelimit.Limit, store ratelimit.Store, key RateLimitKey) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := limit()
+			if !limit.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, logger := logging.CtxLogger(r.Context())
+			client := key(r)
+
+			result, err := store.Take(ctx, name+":"+client, limit)
+			if err != nil {
+				logger.Err(err).Msgf("failed to check %s rate limit of %s, letting request through", name, client)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, seconds(limit.Period)))
+			h.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+			if !result.Allowed {
+				logger.Warn().Msgf("%s has exceeded %s rate limit", client, name)
+				metrics.HTTPRateLimited.WithLabelValues(name).Inc()
+				h.Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+				problem.Write(w, r, errRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// seconds rounds d up to whole seconds, so that clients waiting for it do not retry too early
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+
+	tests := []struct {
+		name        string
+		limit       ratelimit.Limit
+		result      ratelimit.Result
+		storeErr    error
+		wantStatus  int
+		wantHeaders map[string]string
+	}{
+		{
+			name:       "lets request through and reports quota",
+			limit:      limit,
+			result:     ratelimit.Result{Allowed: true, Remaining: 1, Reset: 30 * time.Second},
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"RateLimit-Policy":    "2;w=60",
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "1",
+				"RateLimit-Reset":     "30",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:       "rejects request over the limit",
+			limit:      limit,
+			result:     ratelimit.Result{Remaining: 0, RetryAfter: 2500 * time.Millisecond, Reset: 62500 * time.Millisecond},
+			wantStatus: http.StatusTooManyRequests,
+			wantHeaders: map[string]string{
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "63",
+				"Retry-After":         "3",
+			},
+		},
+		{
+			name:       "lets request through if store fails",
+			limit:      limit,
+			storeErr:   errors.New("connection refused"),
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"RateLimit-Limit": "",
+			},
+		},
+		{
+			name:       "does not limit requests if limit is turned off",
+			storeErr:   errors.New("store must not be called"),
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"RateLimit-Limit": "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotKey string
+			store := ratelimit.StoreFunc(func(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+				gotKey = key
+				return tt.result, tt.storeErr
+			})
+
+			handler := RateLimit("orders", func() ratelimit.Limit { return tt.limit }, store, ByUser)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodPost, "/api/user/orders", nil)
+			r = r.WithContext(curruser.SetCurrentUser(r.Context(), 100))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			for header, value := range tt.wantHeaders {
+				assert.Equal(t, value, w.Header().Get(header), header)
+			}
+			if tt.limit.Enabled() {
+				assert.Equal(t, "orders:user:100", gotKey)
+			} else {
+				assert.Empty(t, gotKey)
+			}
+		})
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/user/login", nil)
+	r.RemoteAddr = "192.0.2.1:54321"
+
+	assert.Equal(t, "ip:192.0.2.1", ByIP(r))
+	assert.Equal(t, "ip:192.0.2.1", ByUser(r), "requests without user are limited by IP")
+
+	r = r.WithContext(curruser.SetCurrentUser(r.Context(), 100))
+	assert.Equal(t, "user:100", ByUser(r))
+}
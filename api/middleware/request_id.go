@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"net/http"
+)
+
+// RequestID is middleware that uses ID passed by the client in X-Request-ID
+// or traceparent header as correlation ID of the request, so that its logs could be
+// joined with logs of the client. If there is no such header, new ID is generated.
+// Correlation ID is echoed in X-Request-ID response header, and traceparent
+// is echoed as is. It should go first in middleware chain, before any logging.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		traceparent := r.Header.Get(logging.TraceparentHeader)
+		if id, ok := logging.CorrelationIDFromHeaders(r.Header.Get(logging.RequestIDHeader), traceparent); ok {
+			ctx = logging.SetCorrelationID(ctx, id)
+		}
+
+		ctx, _ = logging.CtxLogger(ctx)
+
+		// CtxLogger always stores correlation ID in context
+		id, _ := logging.CorrelationID(ctx)
+		w.Header().Set(logging.RequestIDHeader, id)
+		if traceparent != "" {
+			w.Header().Set(logging.TraceparentHeader, traceparent)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name            string
+		headers         map[string]string
+		wantID          string
+		wantTraceparent string
+	}{
+		{
+			name:    "uses request id passed by client",
+			headers: map[string]string{"X-Request-ID": "client-request-id"},
+			wantID:  "client-request-id",
+		},
+		{
+			name:            "uses trace id from traceparent and echoes it",
+			headers:         map[string]string{"traceparent": traceparent},
+			wantID:          "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantTraceparent: traceparent,
+		},
+		{
+			name: "generates request id if client has not passed one",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotID string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, err := logging.CorrelationID(r.Context())
+				require.NoError(t, err)
+				gotID = id
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for header, value := range tt.headers {
+				r.Header.Set(header, value)
+			}
+			w := httptest.NewRecorder()
+
+			RequestID(handler).ServeHTTP(w, r)
+
+			if tt.wantID != "" {
+				assert.Equal(t, tt.wantID, gotID)
+			} else {
+				assert.NotEmpty(t, gotID)
+			}
+			assert.Equal(t, gotID, w.Header().Get("X-Request-ID"))
+			assert.Equal(t, tt.wantTraceparent, w.Header().Get("traceparent"))
+		})
+	}
+}
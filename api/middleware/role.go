@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"net/http"
+)
+
+// RequireRole is middleware that only lets through users having one of passed roles.
+// It should be used after ActiveUser, which stores role of the current user in context.
+func RequireRole(roles ...model.Role) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := curruser.Role(r.Context())
+
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			_, logger := logging.CtxLogger(r.Context())
+			logger.Error().Msgf("role %s is not allowed to access %s", role, r.URL.Path)
+			problem.Write(w, r, errRoleForbidden)
+		})
+	}
+}
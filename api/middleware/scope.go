@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"net/http"
+)
+
+// RequireScope is middleware that rejects requests authenticated by API key
+// that has not been granted passed scope. Requests authenticated by user session
+// are not limited by scopes.
+func RequireScope(scope model.APIKeyScope) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, limited := curruser.Scopes(r.Context())
+			if limited && !(model.APIKey{Scopes: scopes}).HasScope(scope) {
+				_, logger := logging.CtxLogger(r.Context())
+				logger.Error().Msgf("api key does not have scope %s", scope)
+				problem.Write(w, r, errMissingScope)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession is middleware that rejects requests authenticated by API key.
+// It protects routes that should only be available to users themselves,
+// such as API keys management.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, limited := curruser.Scopes(r.Context()); limited {
+			_, logger := logging.CtxLogger(r.Context())
+			logger.Error().Msg("route is not available for api keys")
+			problem.Write(w, r, errSessionRequired)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
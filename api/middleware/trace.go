@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// Trace is middleware that records a server span for every request. Span continues
+// trace passed by the client in traceparent header and is named after chi route pattern,
+// so that requests to the same route with different path parameters are grouped. Trace ID is
+// added to request logger, and correlation ID is added to the span.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := tracing.Tracer().Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethodKey.String(r.Method),
+				semconv.HTTPTargetKey.String(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		ctx = tracing.LinkLogger(ctx, span)
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		// Route pattern is only known after the request has been routed
+		if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil {
+			if route := routeCtx.RoutePattern(); route != "" {
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(semconv.HTTPRouteKey.String(route))
+			}
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
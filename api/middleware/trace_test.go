@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+func TestTrace(t *testing.T) {
+	recorder := tracing.RecordSpans()
+
+	r := chi.NewRouter()
+	r.Use(Trace)
+	r.Get("/api/user/orders/{number}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/user/orders/79927398713", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "GET /api/user/orders/{number}", span.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String(), "continues trace of the client")
+	assert.Contains(t, span.Attributes(), semconv.HTTPRouteKey.String("/api/user/orders/{number}"))
+	assert.Contains(t, span.Attributes(), semconv.HTTPStatusCodeKey.Int(http.StatusInternalServerError))
+	assert.Equal(t, codes.Error, span.Status().Code)
+}
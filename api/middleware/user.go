@@ -3,35 +3,56 @@ package middleware
 import (
 	"github.com/go-chi/jwtauth/v5"
 	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 	"github.com/soundrussian/go-practicum-diploma/pkg/curruser"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
 )
 
 // CurrentUser is middleware that gets user_id from JWT token (if it is present and signed)
-// and saves it in request context
+// and saves it in request context. Role claim of the token is not trusted, as tokens
+// outlive role changes: role is set from storage by ActiveUser. If current user has already
+// been set, e.g. by APIKeyUser, request is passed through as is.
 func CurrentUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID, err := curruser.CurrentUser(r.Context()); err == nil {
+			logging.SetAccessUser(r.Context(), userID)
+			trace.SpanFromContext(r.Context()).SetAttributes(tracing.UserID(userID))
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		ctx, logger := logging.CtxLogger(r.Context())
 
 		token, claims, err := jwtauth.FromContext(r.Context())
 
 		if err != nil {
 			logger.Err(err).Msg("failed to fetch token from request context")
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+			problem.Write(w, r, apperr.Wrap(errUnauthenticated, err))
 			return
 		}
 
 		if token == nil || jwt.Validate(token) != nil {
 			logger.Error().Msg("jwt token is nil or invalid")
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			problem.Write(w, r, errUnauthenticated)
+			return
+		}
+
+		// Tokens issued after password check of users with 2FA enabled
+		// only let them pass the second step of login
+		if _, ok := claims["2fa"]; ok {
+			logger.Error().Msg("second factor token cannot be used for authentication")
+			problem.Write(w, r, errUnauthenticated)
 			return
 		}
 
 		v, ok := claims["user_id"]
 		if !ok {
 			logger.Error().Msg("no user_id in jwt claims")
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			problem.Write(w, r, errUnauthenticated)
 			return
 		}
 
@@ -40,7 +61,7 @@ func CurrentUser(next http.Handler) http.Handler {
 		userIDAsFloat, ok := v.(float64)
 		if !ok {
 			logger.Error().Msgf("could not convert %v to float64", v)
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			problem.Write(w, r, errUnauthenticated)
 			return
 		}
 
@@ -49,6 +70,8 @@ func CurrentUser(next http.Handler) http.Handler {
 		logger = logger.With().Uint64(logging.CurrentUserKey, userID).Logger()
 		ctx = logging.SetCtxLogger(ctx, logger)
 		ctx = curruser.SetCurrentUser(ctx, userID)
+		logging.SetAccessUser(ctx, userID)
+		trace.SpanFromContext(ctx).SetAttributes(tracing.UserID(userID))
 
 		// Token is authenticated, pass it through
 		next.ServeHTTP(w, r.WithContext(ctx))
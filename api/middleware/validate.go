@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/problem"
+	"net/http"
+	"strings"
+)
+
+// ValidateRequest is middleware that checks parameters and body of the request
+// against OpenAPI operation matched by router, responding with 400 Bad Request
+// as problem details before the handler runs if they do not match the schema.
+//
+// Requests that match no operation are passed through, as well as bodies with
+// content type the operation does not declare: those are rejected by AllowContentType
+// with 415 Unsupported Media Type. Authentication is checked by other middlewares.
+func ValidateRequest(router routers.Router) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					ExcludeRequestBody: !declaresContentType(route.Operation, r),
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				},
+			}
+
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				_, logger := logging.CtxLogger(r.Context())
+				logger.Info().Err(err).Msgf("request to %s does not match OpenAPI spec", r.URL.Path)
+				problem.Write(w, r, validationError(err))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// declaresContentType reports whether operation has request body of content type the request is sent with
+func declaresContentType(operation *openapi3.Operation, r *http.Request) bool {
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return false
+	}
+
+	return operation.RequestBody.Value.Content.Get(r.Header.Get("Content-Type")) != nil
+}
+
+// validationError builds error for the client from validation error,
+// describing what is wrong without leaking internals of the validator
+func validationError(err error) error {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return apperr.Wrap(errInvalidRequest, err)
+	}
+
+	// Body that cannot be read, e.g. as it is too large, is rejected with the error of its reader
+	var bodyErr *apperr.Error
+	if requestErr.Parameter == nil && errors.As(requestErr.Err, &bodyErr) {
+		return bodyErr
+	}
+
+	var parseErr *openapi3filter.ParseError
+	if requestErr.Parameter == nil && errors.As(requestErr.Err, &parseErr) {
+		return apperr.Wrap(errInvalidJSON, err)
+	}
+
+	return apperr.Wrap(errInvalidRequest, err).WithPublic(validationMessage(requestErr))
+}
+
+// validationMessage describes what part of the request does not match the spec
+func validationMessage(requestErr *openapi3filter.RequestError) string {
+	if errors.Is(requestErr.Err, openapi3filter.ErrInvalidRequired) {
+		if requestErr.Parameter != nil {
+			return fmt.Sprintf("%s parameter %q is required", requestErr.Parameter.In, requestErr.Parameter.Name)
+		}
+		return "request body is required"
+	}
+
+	reason := requestErr.Reason
+	var schemaErr *openapi3.SchemaError
+	if errors.As(requestErr.Err, &schemaErr) {
+		reason = schemaErr.Reason
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+			reason = fmt.Sprintf("%s at /%s", reason, strings.Join(pointer, "/"))
+		}
+	}
+
+	if requestErr.Parameter != nil {
+		return fmt.Sprintf("%s parameter %q is invalid: %s", requestErr.Parameter.In, requestErr.Parameter.Name, reason)
+	}
+
+	return fmt.Sprintf("request body does not match schema: %s", reason)
+}
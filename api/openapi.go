@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"net/http"
+)
+
+// specYAML is OpenAPI document describing every route of the API.
+// It is the source of truth for request validation and must be updated along with routes.
+//
+//go:embed openapi.yaml
+var specYAML []byte
+
+// loadSpec parses and validates the embedded OpenAPI document
+// and builds router matching requests to its operations
+func loadSpec() (*openapi3.T, routers.Router, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(specYAML)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	if err := spec.Validate(context.Background()); err != nil {
+		return nil, nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	router, err := legacy.NewRouter(spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build OpenAPI router: %w", err)
+	}
+
+	return spec, router, nil
+}
+
+// HandleOpenAPI serves OpenAPI document of the API as JSON
+func (api *API) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	_, logger := logging.CtxLogger(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(api.spec); err != nil {
+		logger.Error().Err(err).Msg("failed to encode OpenAPI spec")
+		return
+	}
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/go-chi/chi/v5"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func init() {
+	// Export archive is only checked to be sent with content type declared in spec
+	openapi3filter.RegisterBodyDecoder("application/zip", openapi3filter.FileBodyDecoder)
+}
+
+// newTestServer starts server with routes of the API that checks every response
+// against OpenAPI spec and fails the test if the response does not match it
+func newTestServer(t *testing.T, api *API) *httptest.Server {
+	t.Helper()
+
+	routes := api.routes()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := api.specRouter.FindRoute(r)
+		if err != nil {
+			routes.ServeHTTP(w, r)
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, r)
+
+		if err := validateResponse(r, route, pathParams, rec); err != nil {
+			t.Errorf("response to %s %s does not match OpenAPI spec: %s", r.Method, r.URL.Path, err)
+		}
+
+		for key, values := range rec.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(rec.Code)
+		_, _ = w.Write(rec.Body.Bytes())
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func validateResponse(r *http.Request, route *routers.Route, pathParams map[string]string, rec *httptest.ResponseRecorder) error {
+	body := rec.Body.Bytes()
+	if rec.Header().Get("Content-Encoding") == "gzip" {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to decompress response: %w", err)
+		}
+		if body, err = ioutil.ReadAll(reader); err != nil {
+			return fmt.Errorf("failed to decompress response: %w", err)
+		}
+	}
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status:  rec.Code,
+		Header:  rec.Header(),
+		Body:    io.NopCloser(bytes.NewReader(body)),
+		Options: &openapi3filter.Options{IncludeResponseStatus: true},
+	}
+
+	return openapi3filter.ValidateResponse(r.Context(), input)
+}
+
+func TestOpenAPI_CoversAllRoutes(t *testing.T) {
+	a := testAPI(t)
+
+	err := chi.Walk(a.routes(), func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		path := a.spec.Paths.Find(route)
+		if assert.NotNil(t, path, "route %s is not described in OpenAPI spec", route) {
+			assert.NotNil(t, path.GetOperation(method), "%s %s is not described in OpenAPI spec", method, route)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestOpenAPI_DescribesOnlyExistingRoutes(t *testing.T) {
+	a := testAPI(t)
+	routes := a.routes()
+
+	for path, item := range a.spec.Paths {
+		for method := range item.Operations() {
+			rctx := chi.NewRouteContext()
+			assert.True(t, routes.Match(rctx, method, path), "%s %s from OpenAPI spec has no route", method, path)
+		}
+	}
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	ts := newTestServer(t, testAPI(t))
+
+	res, err := http.Get(ts.URL + "/openapi.json")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+	var doc map[string]interface{}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	assert.Contains(t, doc["paths"], "/api/user/balance/withdraw")
+}
+
+func TestValidateResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		valid       bool
+	}{
+		{
+			name:        "matches schema",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"current":500.5,"withdrawn":42}`,
+			valid:       true,
+		},
+		{
+			name:        "property of wrong type",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"current":"500.5","withdrawn":42}`,
+		},
+		{
+			name:   "missing content type",
+			status: http.StatusOK,
+			body:   `{"current":500.5,"withdrawn":42}`,
+		},
+		{
+			name:        "undocumented status",
+			status:      http.StatusTeapot,
+			contentType: "text/plain",
+			body:        "I'm a teapot",
+		},
+	}
+
+	a := testAPI(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/user/balance", nil)
+			route, pathParams, err := a.specRouter.FindRoute(r)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			if tt.contentType != "" {
+				rec.Header().Set("Content-Type", tt.contentType)
+			}
+			rec.WriteHeader(tt.status)
+			_, _ = rec.WriteString(tt.body)
+
+			err = validateResponse(r, route, pathParams, rec)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		status      int
+		code        string
+		detail      string
+	}{
+		{
+			name:        "missing required property",
+			path:        "/api/user/register",
+			contentType: "application/json",
+			body:        `{"login":"user"}`,
+			status:      http.StatusBadRequest,
+			code:        "invalid_request",
+			detail:      `request body does not match schema: property "password" is missing at /password`,
+		},
+		{
+			name:        "property of wrong type",
+			path:        "/api/user/login",
+			contentType: "application/json",
+			body:        `{"login":"user","password":42}`,
+			status:      http.StatusBadRequest,
+			code:        "invalid_request",
+			detail:      `request body does not match schema: Field must be set to string or not be present at /password`,
+		},
+		{
+			name:        "malformed JSON",
+			path:        "/api/user/login",
+			contentType: "application/json",
+			body:        `{"login":`,
+			status:      http.StatusBadRequest,
+			code:        "invalid_json",
+			detail:      "request body is not valid JSON",
+		},
+		{
+			name:        "undeclared content type is left to AllowContentType",
+			path:        "/api/user/login",
+			contentType: "text/plain",
+			body:        `login`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, testAPI(t))
+
+			res, err := http.Post(ts.URL+tt.path, tt.contentType, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.status, res.StatusCode)
+			if tt.code != "" {
+				assertProblem(t, res, tt.code, tt.detail)
+			}
+		})
+	}
+}
+
+func testAPI(t *testing.T) *API {
+	t.Helper()
+
+	a, err := New(DefaultConfig(), testAuth.TokenAuth(), new(authMock.Auth), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+	require.NoError(t, err)
+
+	return a
+}
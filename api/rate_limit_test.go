@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	authMock "github.com/soundrussian/go-practicum-diploma/service/auth/mock"
+	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPI_RateLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RateLimit.Orders.Requests = 2
+	cfg.RateLimit.Orders.Period = time.Minute
+
+	a, err := New(cfg, testAuth.TokenAuth(), activeUser(new(authMock.Auth)), new(balanceMock.Balance), orderSuccess(), new(webhookMock.Webhook))
+	require.NoError(t, err)
+
+	ts := newTestServer(t, a)
+	defer ts.Close()
+
+	upload := func(userID uint64, path string, contentType string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader("79927398713"))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token(userID)))
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+
+		return resp
+	}
+
+	resp := upload(100, "/api/user/orders", "text/plain")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "1", resp.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", resp.Header.Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60", resp.Header.Get("RateLimit-Policy"))
+
+	resp = upload(100, "/api/user/orders", "text/plain")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+
+	// Batch upload shares the limit with single uploads
+	resp = upload(100, "/api/user/orders/batch", "text/plain")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+	assertProblem(t, resp, "rate_limited", "too many requests, retry later")
+
+	resp = upload(200, "/api/user/orders", "text/plain")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, "users do not share limits")
+
+	// Limits are changed while the server is running
+	limits := cfg.RateLimit
+	limits.Orders.Requests = 3
+	require.NoError(t, a.SetRateLimits(limits))
+
+	resp = upload(300, "/api/user/orders", "text/plain")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "3", resp.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "2", resp.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "3;w=60", resp.Header.Get("RateLimit-Policy"))
+
+	resp = upload(100, "/api/user/orders", "text/plain")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "raised limit does not refill buckets at once")
+
+	limits.Orders.Requests = 0
+	require.NoError(t, a.SetRateLimits(limits))
+
+	resp = upload(100, "/api/user/orders", "text/plain")
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, "turned off limit lets requests through")
+	assert.Empty(t, resp.Header.Get("RateLimit-Limit"))
+
+	limits.Orders.Requests = -1
+	assert.Error(t, a.SetRateLimits(limits), "invalid limits are rejected")
+}
@@ -5,41 +5,138 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/jwtauth/v5"
 	customMiddleware "github.com/soundrussian/go-practicum-diploma/api/middleware"
-	auth "github.com/soundrussian/go-practicum-diploma/service/auth/v1"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/metrics"
+	"github.com/soundrussian/go-practicum-diploma/pkg/ratelimit"
+	"net/http"
 )
 
 func (api *API) routes() *chi.Mux {
 	r := chi.NewRouter()
+	r.Use(customMiddleware.RequestID)
+	r.Use(customMiddleware.Trace)
+	r.Use(customMiddleware.Metrics)
+	r.Use(customMiddleware.AccessLog(api.config.AccessLogSampleRate))
 	r.Use(middleware.Compress(5))
+	r.Use(customMiddleware.Decompress(api.config.MaxBodySize))
+	r.Use(customMiddleware.LimitBody(api.config.MaxBodySize))
 	r.Use(customMiddleware.LogRequest)
 
+	// Internal routes require client certificates once client CA is configured
+	var internal chi.Middlewares
+	if api.config.TLS.ClientCAFile != "" {
+		internal = append(internal, customMiddleware.RequireClientCert)
+	}
+
+	r.With(internal...).Method(http.MethodGet, "/metrics", metrics.Handler())
+	r.Get("/healthz", api.HandleLiveness)
+	r.Get("/readyz", api.HandleReadiness)
+	r.Get("/openapi.json", api.HandleOpenAPI)
+
+	authLimit := api.rateLimit(func(limits RateLimitConfig) ratelimit.Limit { return limits.Auth })
+	ordersLimit := api.rateLimit(func(limits RateLimitConfig) ratelimit.Limit { return limits.Orders })
+	withdrawLimit := api.rateLimit(func(limits RateLimitConfig) ratelimit.Limit { return limits.Withdraw })
+
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.AllowContentType("application/json"))
+		r.Use(customMiddleware.RateLimit("auth", authLimit, api.rateLimits, customMiddleware.ByIP))
+		r.Use(customMiddleware.AllowContentType("application/json"))
+		r.Use(customMiddleware.LogBody(logging.BodyRedacted))
+		r.Use(customMiddleware.ValidateRequest(api.specRouter))
 
 		r.Post("/api/user/register", api.HandleRegister)
 		r.Post("/api/user/login", api.HandleLogin)
+		r.Post("/api/user/login/2fa", api.HandleLoginSecondFactor)
 	})
 
 	r.Group(func(r chi.Router) {
-		r.Use(jwtauth.Verifier(auth.TokenAuth))
+		r.Use(jwtauth.Verifier(api.tokenAuth))
+		r.Use(customMiddleware.APIKeyUser(api.authService))
 		r.Use(customMiddleware.CurrentUser)
+		r.Use(customMiddleware.ActiveUser(api.authService))
+		r.Use(customMiddleware.ValidateRequest(api.specRouter))
 
-		r.Get("/api/user/balance", api.HandleBalance)
-		r.Get("/api/user/balance/withdrawals", api.HandleWithdrawals)
+		r.With(customMiddleware.RequireScope(model.ScopeBalanceRead)).Get("/api/user/balance", api.HandleBalance)
+		r.With(customMiddleware.RequireScope(model.ScopeBalanceRead)).Get("/api/user/balance/withdrawals", api.HandleWithdrawals)
 
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.AllowContentType("application/json"))
+			r.Use(customMiddleware.AllowContentType("application/json"))
+			r.Use(customMiddleware.RequireScope(model.ScopeWithdraw))
+			r.Use(customMiddleware.RateLimit("withdraw", withdrawLimit, api.rateLimits, customMiddleware.ByUser))
+			r.Use(customMiddleware.LogBody(logging.BodyRedacted))
 
 			r.Post("/api/user/balance/withdraw", api.HandleWithdraw)
 		})
 
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.AllowContentType("text/plain"))
+			r.Use(customMiddleware.AllowContentType("text/plain"))
+			r.Use(customMiddleware.RequireScope(model.ScopeOrdersWrite))
+			r.Use(customMiddleware.RateLimit("orders", ordersLimit, api.rateLimits, customMiddleware.ByUser))
+			r.Use(customMiddleware.LogBody(logging.BodyFull))
 
 			r.Post("/api/user/orders", api.HandleOrder)
 		})
 
-		r.Get("/api/user/orders", api.HandleOrders)
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddleware.AllowContentType("application/json", "text/plain"))
+			r.Use(customMiddleware.RequireScope(model.ScopeOrdersWrite))
+			r.Use(customMiddleware.RateLimit("orders", ordersLimit, api.rateLimits, customMiddleware.ByUser))
+			r.Use(customMiddleware.LogBody(logging.BodyFull))
+
+			r.Post("/api/user/orders/batch", api.HandleOrdersBatch)
+		})
+
+		r.With(customMiddleware.RequireScope(model.ScopeOrdersRead)).Get("/api/user/orders", api.HandleOrders)
+		r.With(customMiddleware.RequireScope(model.ScopeOrdersRead)).Get("/api/user/orders/{number}", api.HandleUserOrder)
+		r.With(customMiddleware.RequireScope(model.ScopeOrdersRead), customMiddleware.RequireScope(model.ScopeBalanceRead)).Get("/api/user/events", api.HandleEvents)
+
+		// Account, API keys and 2FA can only be managed by users themselves, not by API keys
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddleware.RequireSession)
+
+			r.Get("/api/user/export", api.HandleExport)
+			r.Delete("/api/user", api.HandleDeleteAccount)
+
+			r.With(customMiddleware.AllowContentType("application/json"), customMiddleware.LogBody(logging.BodyRedacted)).Post("/api/user/api-keys", api.HandleCreateAPIKey)
+			r.Get("/api/user/api-keys", api.HandleAPIKeys)
+			r.Delete("/api/user/api-keys/{id}", api.HandleRevokeAPIKey)
+
+			r.Post("/api/user/2fa/enroll", api.HandleEnrollTOTP)
+			r.With(customMiddleware.AllowContentType("application/json"), customMiddleware.LogBody(logging.BodyRedacted)).Post("/api/user/2fa/confirm", api.HandleConfirmTOTP)
+		})
+	})
+
+	// Admin routes are available to staff only and cannot be accessed with API keys
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Use(internal...)
+		r.Use(jwtauth.Verifier(api.tokenAuth))
+		r.Use(customMiddleware.CurrentUser)
+		r.Use(customMiddleware.ActiveUser(api.authService))
+		r.Use(customMiddleware.RequireRole(model.RoleSupport, model.RoleAdmin))
+		r.Use(customMiddleware.ValidateRequest(api.specRouter))
+
+		r.Get("/users/{userID}/orders", api.HandleAdminUserOrders)
+		r.Get("/users/{userID}/balance", api.HandleAdminUserBalance)
+
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddleware.RequireRole(model.RoleAdmin))
+			r.Use(customMiddleware.AllowContentType("application/json"))
+			r.Use(customMiddleware.LogBody(logging.BodyRedacted))
+
+			r.Put("/users/{userID}/role", api.HandleAdminUserRole)
+			r.Put("/log-level", api.HandleSetLogLevel)
+			r.Post("/webhooks", api.HandleCreateWebhook)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(customMiddleware.RequireRole(model.RoleAdmin))
+
+			r.Get("/log-level", api.HandleLogLevel)
+			r.Get("/webhooks", api.HandleWebhooks)
+			r.Delete("/webhooks/{webhookID}", api.HandleDeleteWebhook)
+			r.Get("/webhooks/{webhookID}/deliveries", api.HandleWebhookDeliveries)
+			r.Post("/webhooks/deliveries/{deliveryID}/redeliver", api.HandleRedeliverWebhook)
+		})
 	})
 
 	return r
@@ -2,31 +2,125 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tlscert"
+	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
-func (api *API) RunServer(ctx context.Context) (<-chan struct{}, error) {
-	ctx, logger := logging.CtxLogger(ctx)
-	server := http.Server{Addr: config.RunAddress, Handler: api.routes()}
-	c := make(chan struct{})
+// Start binds to config.RunAddress and serves requests in background, over HTTPS
+// if config.TLS has certificate.
+// Failure to bind the address is returned right away. Returned channel
+// receives an error if server stops on its own and is closed once serving stops.
+func (api *API) Start(ctx context.Context) (<-chan error, error) {
+	_, logger := logging.CtxLogger(ctx)
+
+	var tlsConfig *tls.Config
+	if api.config.TLS.Enabled() {
+		var err error
+		if tlsConfig, err = api.loadTLS(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("tcp", api.config.RunAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// Event streams are kept open for as long as clients listen, so server has no write timeout
+	api.server = &http.Server{
+		Handler:           api.routes(),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: api.config.ReadHeaderTimeout,
+		IdleTimeout:       api.config.IdleTimeout,
+		MaxHeaderBytes:    api.config.MaxHeaderBytes,
+	}
+	api.server.RegisterOnShutdown(api.closeStreams)
+	errs := make(chan error, 1)
 
 	go func() {
-		<-ctx.Done()
+		defer close(errs)
+
+		var err error
+		if tlsConfig != nil {
+			// Certificate is served by tlsConfig, HTTP/2 is negotiated by ServeTLS
+			err = api.server.ServeTLS(listener, "", "")
+		} else {
+			err = api.server.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+	}()
+
+	if tlsConfig != nil {
+		logger.Info().Msgf("started HTTPS server on address %s", listener.Addr())
+	} else {
+		logger.Info().Msgf("started server on address %s", listener.Addr())
+	}
+
+	return errs, nil
+}
 
-		shutdownTimeout, cancel := context.WithTimeout(context.Background(), config.ServerShutdownTimeout)
-		defer cancel()
+// loadTLS loads certificate, which is reloaded once its files change until ctx is done,
+// and CA of client certificates, if any
+func (api *API) loadTLS(ctx context.Context) (*tls.Config, error) {
+	cfg := api.config.TLS
 
-		logger.Info().Msg("waiting for all connections to shut down...")
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
 
-		if err := server.Shutdown(shutdownTimeout); err != nil {
-			logger.Err(err).Msg("error while shutting down server")
+	if cfg.ClientCAFile != "" {
+		clientCAs, err := tlscert.LoadCertPool(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client CA: %w", err)
 		}
+		// Client certificates are only required by some routes, see RequireClientCert,
+		// but those presented on any route must be valid
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		tlsConfig.ClientCAs = clientCAs
+	}
 
-		c <- struct{}{}
-	}()
+	certs, err := tlscert.NewReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	certs.Watch(ctx, cfg.ReloadInterval)
+	tlsConfig.GetCertificate = certs.GetCertificate
+
+	return tlsConfig, nil
+}
+
+// Shutdown fails readiness, gives load balancer config.ReadinessDrainDelay to notice it,
+// so that no new requests are sent to the server, and then waits for active
+// connections to finish for at most config.ServerShutdownTimeout.
+func (api *API) Shutdown(ctx context.Context) error {
+	ctx, logger := logging.CtxLogger(ctx)
+
+	if api.server == nil {
+		return nil
+	}
+
+	atomic.StoreInt32(&api.shuttingDown, 1)
+	logger.Info().Msgf("readiness is failing, waiting %s before shutting down", api.config.ReadinessDrainDelay)
+
+	drain := time.NewTimer(api.config.ReadinessDrainDelay)
+	defer drain.Stop()
+
+	select {
+	case <-drain.C:
+	case <-ctx.Done():
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, api.config.ServerShutdownTimeout)
+	defer cancel()
 
-	logger.Info().Msgf("starting server on address %s", config.RunAddress)
+	logger.Info().Msg("waiting for all connections to shut down...")
 
-	return c, server.ListenAndServe()
+	return api.server.Shutdown(ctx)
 }
@@ -2,47 +2,191 @@ package api
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"github.com/soundrussian/go-practicum-diploma/service/auth/mock"
 	balanceMock "github.com/soundrussian/go-practicum-diploma/service/balance/mock"
 	orderMock "github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	webhookMock "github.com/soundrussian/go-practicum-diploma/service/webhook/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"math/big"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
 
-func TestRunServer(t *testing.T) {
+func TestStart(t *testing.T) {
 	t.Run("it runs server on specified address", func(t *testing.T) {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+		api, _ := runServerOnFreePort(t, 0)
+		defer api.Shutdown(context.Background())
 
-		api, err := New(new(mock.Auth), new(balanceMock.Balance), new(orderMock.Order))
+		err := pingServer(api.config.RunAddress)
 		require.NoError(t, err)
 
-		runServerOnFreePort(t, ctx, api)
+		assert.Equal(t, api.config.ReadHeaderTimeout, api.server.ReadHeaderTimeout)
+		assert.Equal(t, api.config.IdleTimeout, api.server.IdleTimeout)
+		assert.Equal(t, api.config.MaxHeaderBytes, api.server.MaxHeaderBytes)
+	})
+
+	t.Run("it returns error if address is taken", func(t *testing.T) {
+		l, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+		defer l.Close()
 
-		err = pingServer()
+		cfg := DefaultConfig()
+		cfg.RunAddress = l.Addr().String()
+
+		api, err := New(cfg, testAuth.TokenAuth(), new(mock.Auth), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
 		require.NoError(t, err)
+
+		errs, err := api.Start(context.Background())
+		require.Error(t, err)
+		assert.Nil(t, errs)
 	})
 }
 
-func TestRunServer_StopThroughContext(t *testing.T) {
-	t.Run("it stops server by cancelling context", func(t *testing.T) {
-		ctx, cancel := context.WithCancel(context.Background())
+func TestStart_TLS(t *testing.T) {
+	certs := writeTestCerts(t)
 
-		api, err := New(new(mock.Auth), new(balanceMock.Balance), new(orderMock.Order))
+	freePort, err := getFreePort()
+	require.NoError(t, err)
+
+	cfg := DefaultConfig()
+	cfg.RunAddress = fmt.Sprintf("localhost:%d", freePort)
+	cfg.ReadinessDrainDelay = 0
+	cfg.TLS.CertFile = certs.serverCert
+	cfg.TLS.KeyFile = certs.serverKey
+	cfg.TLS.ClientCAFile = certs.ca
+
+	api, err := New(cfg, testAuth.TokenAuth(), new(mock.Auth), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = api.Start(ctx)
+	require.NoError(t, err)
+	defer api.Shutdown(context.Background())
+
+	get := func(path string, clientCert *tls.Certificate) *http.Response {
+		t.Helper()
+
+		tlsConfig := &tls.Config{RootCAs: certs.pool}
+		if clientCert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*clientCert}
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, ForceAttemptHTTP2: true}}
+		defer client.CloseIdleConnections()
+
+		resp, err := client.Get(fmt.Sprintf("https://%s%s", cfg.RunAddress, path))
 		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
 
-		runServerOnFreePort(t, ctx, api)
+		return resp
+	}
 
-		cancel()
+	t.Run("it serves HTTP/2 over TLS", func(t *testing.T) {
+		resp := get("/healthz", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 2, resp.ProtoMajor)
+	})
 
-		err = pingServer()
+	t.Run("it does not serve plain HTTP", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://%s/healthz", cfg.RunAddress))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("it requires client certificate for internal routes", func(t *testing.T) {
+		resp := get("/metrics", nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assertProblem(t, resp, "client_certificate_required", "route requires client certificate")
+
+		resp = get("/api/admin/log-level", nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = get("/metrics", &certs.client)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = get("/api/admin/log-level", &certs.client)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "admin routes still require authentication")
+	})
+
+	t.Run("it returns error if certificate cannot be loaded", func(t *testing.T) {
+		cfg := cfg
+		cfg.TLS.KeyFile = filepath.Join(t.TempDir(), "missing.key")
+
+		api, err := New(cfg, testAuth.TokenAuth(), new(mock.Auth), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+		require.NoError(t, err)
+
+		errs, err := api.Start(context.Background())
+		require.Error(t, err)
+		assert.Nil(t, errs)
+	})
+}
+
+func TestShutdown(t *testing.T) {
+	t.Run("it stops server", func(t *testing.T) {
+		api, errs := runServerOnFreePort(t, 0)
+
+		require.NoError(t, api.Shutdown(context.Background()))
+
+		_, open := <-errs
+		assert.False(t, open, "errors channel is closed without error")
+
+		err := pingServer(api.config.RunAddress)
 		require.Error(t, err)
 	})
+
+	t.Run("it does nothing if server has not been started", func(t *testing.T) {
+		api, err := New(DefaultConfig(), testAuth.TokenAuth(), new(mock.Auth), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+		require.NoError(t, err)
+
+		assert.NoError(t, api.Shutdown(context.Background()))
+	})
+}
+
+func TestShutdown_FailsReadinessBeforeShutdown(t *testing.T) {
+	api, _ := runServerOnFreePort(t, 500*time.Millisecond)
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/readyz", api.config.RunAddress))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	stopped := make(chan error)
+	go func() { stopped <- api.Shutdown(context.Background()) }()
+	time.Sleep(100 * time.Millisecond) // Let server start draining
+
+	resp, err = http.Get(fmt.Sprintf("http://%s/readyz", api.config.RunAddress))
+	require.NoError(t, err, "server keeps serving requests while draining")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	assert.NoError(t, <-stopped)
+}
+
+func TestShutdown_DrainIsCutShortByContext(t *testing.T) {
+	api, _ := runServerOnFreePort(t, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_ = api.Shutdown(ctx)
+
+	assert.Less(t, time.Since(started), time.Second)
+	require.Error(t, pingServer(api.config.RunAddress))
 }
 
 func getFreePort() (int, error) {
@@ -59,23 +203,27 @@ func getFreePort() (int, error) {
 	return l.Addr().(*net.TCPAddr).Port, nil
 }
 
-func runServerOnFreePort(t *testing.T, ctx context.Context, api *API) {
+// runServerOnFreePort starts server with given readiness drain delay and returns it with its errors channel
+func runServerOnFreePort(t *testing.T, drainDelay time.Duration) (*API, <-chan error) {
 	freePort, err := getFreePort()
 	require.NoError(t, err)
 
-	config.RunAddress = fmt.Sprintf("localhost:%d", freePort)
+	cfg := DefaultConfig()
+	cfg.RunAddress = fmt.Sprintf("localhost:%d", freePort)
+	cfg.ReadinessDrainDelay = drainDelay
 
-	go func() {
-		_, err = api.RunServer(ctx)
-		assert.ErrorIs(t, err, http.ErrServerClosed)
-	}()
+	api, err := New(cfg, testAuth.TokenAuth(), new(mock.Auth), new(balanceMock.Balance), new(orderMock.Order), new(webhookMock.Webhook))
+	require.NoError(t, err)
+
+	errs, err := api.Start(context.Background())
+	require.NoError(t, err)
 
-	time.Sleep(500 * time.Millisecond) // Wait for server to start
+	return api, errs
 }
 
-func pingServer() error {
+func pingServer(address string) error {
 	timeout := 1 * time.Second
-	conn, err := net.DialTimeout("tcp", config.RunAddress, timeout)
+	conn, err := net.DialTimeout("tcp", address, timeout)
 	defer func() {
 		if conn != nil {
 			conn.Close()
@@ -84,3 +232,80 @@ func pingServer() error {
 
 	return err
 }
+
+type testCerts struct {
+	// ca is file of CA that issues both server and client certificates
+	ca   string
+	pool *x509.CertPool
+	// serverCert and serverKey are files of certificate for localhost
+	serverCert string
+	serverKey  string
+	client     tls.Certificate
+}
+
+// writeTestCerts issues server and client certificates by a new CA and writes them to temporary files
+func writeTestCerts(t *testing.T) testCerts {
+	t.Helper()
+
+	dir := t.TempDir()
+	certs := testCerts{
+		ca:         filepath.Join(dir, "ca.crt"),
+		pool:       x509.NewCertPool(),
+		serverCert: filepath.Join(dir, "tls.crt"),
+		serverKey:  filepath.Join(dir, "tls.key"),
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err = x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	certs.pool.AddCert(ca)
+	require.NoError(t, os.WriteFile(certs.ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0o600))
+
+	issue := func(serial int64, template *x509.Certificate) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		template.SerialNumber = big.NewInt(serial)
+		template.NotBefore = ca.NotBefore
+		template.NotAfter = ca.NotAfter
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	}
+
+	serverCert, serverKey := issue(2, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	require.NoError(t, os.WriteFile(certs.serverCert, serverCert, 0o600))
+	require.NoError(t, os.WriteFile(certs.serverKey, serverKey, 0o600))
+
+	clientCert, clientKey := issue(3, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "prometheus"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	certs.client, err = tls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+
+	return certs
+}
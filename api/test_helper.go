@@ -4,10 +4,35 @@ import (
 	"context"
 	"github.com/soundrussian/go-practicum-diploma/model"
 	"github.com/soundrussian/go-practicum-diploma/service/auth/v1"
+	storageMock "github.com/soundrussian/go-practicum-diploma/storage/mock"
 )
 
+// testAuth issues tokens that API accepts when built with testAuth.TokenAuth()
+var testAuth, _ = v1.New(v1.DefaultConfig(), new(storageMock.Storage))
+
+// Users stored with staff roles by activeUser, as API checks roles stored rather than those in tokens
+const (
+	adminUserID   uint64 = 1
+	supportUserID uint64 = 2
+)
+
+// storedRole returns role activeUser stores user with
+func storedRole(userID uint64) model.Role {
+	switch userID {
+	case adminUserID:
+		return model.RoleAdmin
+	case supportUserID:
+		return model.RoleSupport
+	}
+
+	return model.RoleUser
+}
+
 func token(userID uint64) string {
-	a := &v1.Auth{}
-	t, _ := a.AuthToken(context.Background(), &model.User{ID: userID})
+	return tokenWithRole(userID, model.RoleUser)
+}
+
+func tokenWithRole(userID uint64, role model.Role) string {
+	t, _ := testAuth.AuthToken(context.Background(), &model.User{ID: userID, Role: role})
 	return *t
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"time"
+)
+
+// step is a single stage of graceful shutdown
+type step struct {
+	name string
+	// timeout limits how long stop may take, zero means stop sets its own limits
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+// lifecycle stops application components one by one in reverse order of adding them,
+// like defer does. Since components are added as they are started, each component
+// is stopped only after everything depending on it is.
+type lifecycle struct {
+	steps []step
+}
+
+// onShutdown adds a step to be run on shutdown
+func (l *lifecycle) onShutdown(name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	l.steps = append(l.steps, step{name: name, timeout: timeout, stop: stop})
+}
+
+// shutdown runs all steps, last added first. Failed step does not prevent next ones from running.
+// It returns the first error encountered.
+func (l *lifecycle) shutdown(ctx context.Context) error {
+	var firstErr error
+
+	for i := len(l.steps) - 1; i >= 0; i-- {
+		if err := l.run(ctx, l.steps[i]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (l *lifecycle) run(ctx context.Context, s step) error {
+	_, logger := logging.CtxLogger(ctx)
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	logger.Info().Msgf("shutting down %s", s.name)
+	started := time.Now()
+
+	if err := s.stop(ctx); err != nil {
+		logger.Err(err).Msgf("failed to shut down %s cleanly", s.name)
+		return err
+	}
+
+	logger.Info().Msgf("%s has been shut down in %s", s.name, time.Since(started))
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycle_shutdown(t *testing.T) {
+	t.Run("it runs steps in reverse order and returns first error", func(t *testing.T) {
+		var l lifecycle
+		var ran []string
+		errFirst := errors.New("first")
+
+		l.onShutdown("storage", 0, func(ctx context.Context) error {
+			ran = append(ran, "storage")
+			return nil
+		})
+		l.onShutdown("server", 0, func(ctx context.Context) error {
+			ran = append(ran, "server")
+			return errors.New("second")
+		})
+		l.onShutdown("accrual", 0, func(ctx context.Context) error {
+			ran = append(ran, "accrual")
+			return errFirst
+		})
+
+		err := l.shutdown(context.Background())
+
+		assert.ErrorIs(t, err, errFirst)
+		assert.Equal(t, []string{"accrual", "server", "storage"}, ran)
+	})
+
+	t.Run("it limits each step by its timeout", func(t *testing.T) {
+		var l lifecycle
+		var deadline time.Time
+		var hasDeadline bool
+
+		l.onShutdown("accrual", time.Minute, func(ctx context.Context) error {
+			deadline, hasDeadline = ctx.Deadline()
+			return nil
+		})
+
+		assert.NoError(t, l.shutdown(context.Background()))
+		assert.True(t, hasDeadline)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	})
+}
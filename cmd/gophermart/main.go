@@ -2,78 +2,164 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"github.com/soundrussian/go-practicum-diploma/accrual"
 	"github.com/soundrussian/go-practicum-diploma/api"
+	"github.com/soundrussian/go-practicum-diploma/config"
+	"github.com/soundrussian/go-practicum-diploma/outbox"
+	"github.com/soundrussian/go-practicum-diploma/pkg/events"
+	"github.com/soundrussian/go-practicum-diploma/pkg/health"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/metrics"
+	"github.com/soundrussian/go-practicum-diploma/pkg/ratelimit"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	authTraced "github.com/soundrussian/go-practicum-diploma/service/auth/traced"
 	auth "github.com/soundrussian/go-practicum-diploma/service/auth/v1"
+	balanceTraced "github.com/soundrussian/go-practicum-diploma/service/balance/traced"
 	balance "github.com/soundrussian/go-practicum-diploma/service/balance/v1"
+	orderTraced "github.com/soundrussian/go-practicum-diploma/service/order/traced"
 	order "github.com/soundrussian/go-practicum-diploma/service/order/v1"
+	webhookTraced "github.com/soundrussian/go-practicum-diploma/service/webhook/traced"
+	webhook "github.com/soundrussian/go-practicum-diploma/service/webhook/v1"
 	db "github.com/soundrussian/go-practicum-diploma/storage/psql"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 )
 
 func main() {
-	flag.Parse()
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err == nil {
+		err = logging.Configure(cfg.Log)
+	}
+	if err != nil {
+		// Logging may be not configured yet, so default logger is used
+		logger := logging.NewLogger()
+		logger.Fatal().Msgf("failed to load config: %s", err.Error())
+	}
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	ctx, logger := logging.CtxLogger(ctx)
+	logger.Info().Msgf("starting with config:\n%s", cfg)
+
+	if err := run(ctx, cfg); err != nil {
+		logger.Fatal().Msg(err.Error())
+	}
+}
+
+// run starts all components and blocks until ctx is done or server fails.
+// Components are then stopped in order: webhook dispatcher and accrual processor
+// stop taking new work and drain in-flight jobs, server finishes active requests, and only then
+// storage is closed and traces are flushed.
+func run(ctx context.Context, cfg config.Config) (err error) {
 	ctx, logger := logging.CtxLogger(ctx)
 
-	store, err := db.New()
+	var l lifecycle
 	defer func() {
-		if store != nil {
-			store.Close()
+		// ctx is done by now, so shutdown gets a context of its own
+		shutdownCtx := logging.SetCtxLogger(context.Background(), logger)
+		if shutdownErr := l.shutdown(shutdownCtx); shutdownErr != nil && err == nil {
+			err = fmt.Errorf("failed to shut down cleanly: %w", shutdownErr)
 		}
 	}()
 
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
 	if err != nil {
-		logger.Fatal().Msgf("failed to initialize storage: %s", err.Error())
-		return
+		return fmt.Errorf("failed to initialize tracing: %w", err)
 	}
+	l.onShutdown("tracing", 0, shutdownTracing)
 
-	authService, err := auth.New(store)
+	store, err := db.New(cfg.Database)
+	if store != nil {
+		l.onShutdown("storage", 0, func(context.Context) error {
+			store.Close()
+			return nil
+		})
+	}
 	if err != nil {
-		logger.Fatal().Msgf("error initializing auth service: %s", err.Error())
-		return
+		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	balanceService, err := balance.New(store)
+	authService, err := auth.New(cfg.Auth, store)
 	if err != nil {
-		logger.Fatal().Msgf("error initializing balance service: %s", err.Error())
-		return
+		return fmt.Errorf("error initializing auth service: %w", err)
+	}
+
+	// Services publish changes of user data, and API streams them to users
+	bus := events.NewBus(events.DefaultHistorySize)
+
+	balanceService, err := balance.New(store, bus)
+	if err != nil {
+		return fmt.Errorf("error initializing balance service: %w", err)
 	}
 
 	orderService, err := order.New(store)
 	if err != nil {
-		logger.Fatal().Msgf("error initializing order service: %s", err.Error())
-		return
+		return fmt.Errorf("error initializing order service: %w", err)
 	}
 
-	a, err := api.New(authService, balanceService, orderService)
+	webhookService, err := webhook.New(store)
 	if err != nil {
-		logger.Fatal().Msgf("error intializing API: %s", err.Error())
-		return
+		return fmt.Errorf("error initializing webhook service: %w", err)
 	}
 
-	processor, err := accrual.New(store)
+	a, err := api.New(cfg.Server, authService.TokenAuth(), authTraced.New(authService), balanceTraced.New(balanceService), orderTraced.New(orderService), webhookTraced.New(webhookService))
 	if err != nil {
-		logger.Fatal().Msgf("failed to start accrual processor: %s", err.Error())
-		return
+		return fmt.Errorf("error intializing API: %w", err)
+	}
+	a.SetEventBus(bus)
+	if cfg.Server.RateLimit.Store == api.RateLimitStorePostgres {
+		a.SetRateLimitStore(ratelimit.StoreFunc(store.TakeRateLimit))
 	}
 
-	processor.Run(ctx)
+	processor, err := accrual.New(cfg.Accrual, store, bus)
+	if err != nil {
+		return fmt.Errorf("failed to start accrual processor: %w", err)
+	}
 
-	serverDone, err := a.RunServer(ctx)
+	dispatcher, err := outbox.New(cfg.Webhooks, store)
+	if err != nil {
+		return fmt.Errorf("failed to start webhook dispatcher: %w", err)
+	}
 
-	if err != nil && err != http.ErrServerClosed {
-		logger.Fatal().Msgf("error starting server: %s", err)
-		return
+	a.AddReadinessCheck(
+		health.Check{Name: "database", Critical: true, Run: store.Ping},
+		health.Check{Name: "migrations", Critical: true, Run: store.CheckMigrations},
+		// Orders are accepted while accrual service is down and processed later,
+		// so it does not make the server unready
+		health.Check{Name: "accrual", Run: processor.CheckReachable},
+	)
+
+	if err := metrics.Register(processor.QueueCollector()); err != nil {
+		return fmt.Errorf("failed to register accrual metrics: %w", err)
 	}
 
-	<-serverDone
+	serverErrs, err := a.Start(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting server: %w", err)
+	}
+	l.onShutdown("server", 0, a.Shutdown)
+
+	processor.Run(ctx)
+	l.onShutdown("accrual processor", cfg.Accrual.DrainTimeout, processor.Stop)
+
+	dispatcher.Run(ctx)
+	l.onShutdown("webhook dispatcher", cfg.Webhooks.DrainTimeout, dispatcher.Stop)
+
+	watchConfig(ctx, cfg, a, processor)
+
+	select {
+	case <-ctx.Done():
+		logger.Info().Msg("received shutdown signal")
+		return nil
+	case err := <-serverErrs:
+		return fmt.Errorf("server has stopped unexpectedly: %w", err)
+	}
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"github.com/soundrussian/go-practicum-diploma/accrual"
+	"github.com/soundrussian/go-practicum-diploma/api"
+	"github.com/soundrussian/go-practicum-diploma/config"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"os"
+	"syscall"
+)
+
+// watchConfig reloads config on SIGHUP and passes safe changes to running components
+func watchConfig(ctx context.Context, cfg config.Config, a *api.API, processor *accrual.Accrual) {
+	reloader := config.NewReloader(cfg, func() (config.Config, error) {
+		return config.Load(os.Args[1:], os.LookupEnv)
+	})
+
+	reloader.Subscribe(func(ctx context.Context, previous config.Config, current config.Config) {
+		if previous.Log.Level == current.Log.Level {
+			return
+		}
+		// Level has been validated on load
+		level, _ := logging.ParseLevel(current.Log.Level)
+		logging.SetLevel(level)
+	})
+
+	reloader.Subscribe(func(ctx context.Context, previous config.Config, current config.Config) {
+		if previous.Accrual == current.Accrual {
+			return
+		}
+		if err := processor.Reconfigure(current.Accrual); err != nil {
+			_, logger := logging.CtxLogger(ctx)
+			logger.Err(err).Msg("failed to reconfigure accrual processor")
+		}
+	})
+
+	reloader.Subscribe(func(ctx context.Context, previous config.Config, current config.Config) {
+		if previous.Server.RateLimit == current.Server.RateLimit {
+			return
+		}
+		_, logger := logging.CtxLogger(ctx)
+		if previous.Server.RateLimit.Store != current.Server.RateLimit.Store {
+			logger.Warn().Msg("rate limit store cannot be changed without restart, keeping running store")
+		}
+		if err := a.SetRateLimits(current.Server.RateLimit); err != nil {
+			logger.Err(err).Msg("failed to change rate limits")
+		}
+	})
+
+	reloader.WatchSignals(ctx, syscall.SIGHUP)
+}
@@ -0,0 +1,98 @@
+// Package config gathers settings of all gophermart components in one place.
+//
+// Settings are read from several sources, each next one overriding the previous:
+//
+//  1. defaults of each component;
+//  2. YAML (.yaml, .yml) or TOML (.toml) file set by -c flag or CONFIG env variable;
+//  3. environment variables;
+//  4. command-line flags.
+//
+// Loaded config is validated as a whole, so that the server does not start
+// with any setting it cannot use. A few settings can be changed without restart
+// by sending SIGHUP to the process, see Reloader.
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/soundrussian/go-practicum-diploma/accrual"
+	"github.com/soundrussian/go-practicum-diploma/api"
+	"github.com/soundrussian/go-practicum-diploma/outbox"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	auth "github.com/soundrussian/go-practicum-diploma/service/auth/v1"
+	"github.com/soundrussian/go-practicum-diploma/storage/psql"
+	"gopkg.in/yaml.v3"
+)
+
+// Config contains settings of all gophermart components
+type Config struct {
+	Server   api.Config     `yaml:"server" toml:"server"`
+	Database psql.Config    `yaml:"database" toml:"database"`
+	Accrual  accrual.Config `yaml:"accrual" toml:"accrual"`
+	Webhooks outbox.Config  `yaml:"webhooks" toml:"webhooks"`
+	Auth     auth.Config    `yaml:"auth" toml:"auth"`
+	Log      logging.Config `yaml:"log" toml:"log"`
+	Tracing  tracing.Config `yaml:"tracing" toml:"tracing"`
+}
+
+// Default returns config with defaults of all components
+func Default() Config {
+	return Config{
+		Server:   api.DefaultConfig(),
+		Database: psql.DefaultConfig(),
+		Accrual:  accrual.DefaultConfig(),
+		Webhooks: outbox.DefaultConfig(),
+		Auth:     auth.DefaultConfig(),
+		Log:      logging.DefaultConfig(),
+		Tracing:  tracing.DefaultConfig(),
+	}
+}
+
+// Validate checks settings of all components and reports all invalid ones at once
+func (c Config) Validate() error {
+	sections := []struct {
+		name string
+		err  error
+	}{
+		{"server", c.Server.Validate()},
+		{"database", c.Database.Validate()},
+		{"accrual", c.Accrual.Validate()},
+		{"webhooks", c.Webhooks.Validate()},
+		{"auth", c.Auth.Validate()},
+		{"log", c.Log.Validate()},
+		{"tracing", c.Tracing.Validate()},
+	}
+
+	var problems []string
+	for _, section := range sections {
+		if section.err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", section.name, section.err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// Masked returns copy of config with secrets hidden
+func (c Config) Masked() Config {
+	c.Database = c.Database.Masked()
+	c.Auth = c.Auth.Masked()
+
+	return c
+}
+
+// String returns config as YAML with secrets hidden, so that it is safe to print
+func (c Config) String() string {
+	out, err := yaml.Marshal(c.Masked())
+	if err != nil {
+		return fmt.Sprintf("failed to print config: %s", err)
+	}
+
+	return string(out)
+}
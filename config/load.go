@@ -0,0 +1,301 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const fileEnvKey = "CONFIG"
+const fileFlagName = "c"
+
+// setting is a single config value that can be set from env and command line
+type setting struct {
+	env string
+	// flag is empty for secrets, which should not be visible in process list
+	flag  string
+	usage string
+	// value returns flag.Value that parses string into the setting of c
+	value func(c *Config) flag.Value
+}
+
+// settings lists everything that can be set from env and command line.
+// Short flags are the ones required by specification.
+var settings = []setting{
+	{"RUN_ADDRESS", "a", "address to run gophermart on",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Server.RunAddress) }},
+	{"SERVER_SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long active connections may take to finish on shutdown",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Server.ServerShutdownTimeout) }},
+	{"READINESS_DRAIN_DELAY", "readiness-drain-delay", "how long readiness fails before shutdown begins",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Server.ReadinessDrainDelay) }},
+	{"ACCESS_LOG_SAMPLE_RATE", "access-log-sample-rate", "share of successful requests written to access log, 0 to 1",
+		func(c *Config) flag.Value { return (*floatValue)(&c.Server.AccessLogSampleRate) }},
+	{"ORDER_BATCH_LIMIT", "order-batch-limit", "how many order numbers may be uploaded in a single batch",
+		func(c *Config) flag.Value { return (*intValue)(&c.Server.OrderBatchLimit) }},
+	{"MAX_BODY_SIZE", "max-body-size", "size in bytes request body may have, once decompressed if it is compressed",
+		func(c *Config) flag.Value { return (*int64Value)(&c.Server.MaxBodySize) }},
+	{"READ_HEADER_TIMEOUT", "read-header-timeout", "how long clients may take to send request headers",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Server.ReadHeaderTimeout) }},
+	{"IDLE_TIMEOUT", "idle-timeout", "how long keep-alive connections are kept open between requests",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Server.IdleTimeout) }},
+	{"MAX_HEADER_BYTES", "max-header-bytes", "size in bytes request line and headers may have",
+		func(c *Config) flag.Value { return (*intValue)(&c.Server.MaxHeaderBytes) }},
+	{"TLS_CERT_FILE", "tls-cert-file", "PEM certificate to serve HTTPS with, plain HTTP is served if empty",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Server.TLS.CertFile) }},
+	{"TLS_KEY_FILE", "tls-key-file", "PEM key of TLS certificate",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Server.TLS.KeyFile) }},
+	{"TLS_CLIENT_CA_FILE", "tls-client-ca-file", "PEM CA certificates client certificates for admin and metrics routes must be issued by",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Server.TLS.ClientCAFile) }},
+	{"TLS_RELOAD_INTERVAL", "tls-reload-interval", "how often TLS certificate files are checked for changes",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Server.TLS.ReloadInterval) }},
+	{"RATE_LIMIT_STORE", "rate-limit-store", "where rate limit buckets are kept: memory of each instance or postgres shared by all",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Server.RateLimit.Store) }},
+	{"RATE_LIMIT_AUTH_REQUESTS", "rate-limit-auth-requests", "how many registration and login attempts an IP may make per period, 0 for no limit",
+		func(c *Config) flag.Value { return (*intValue)(&c.Server.RateLimit.Auth.Requests) }},
+	{"RATE_LIMIT_AUTH_PERIOD", "rate-limit-auth-period", "period of registration and login rate limit",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Server.RateLimit.Auth.Period) }},
+	{"RATE_LIMIT_ORDERS_REQUESTS", "rate-limit-orders-requests", "how many order uploads a user may make per period, 0 for no limit",
+		func(c *Config) flag.Value { return (*intValue)(&c.Server.RateLimit.Orders.Requests) }},
+	{"RATE_LIMIT_ORDERS_PERIOD", "rate-limit-orders-period", "period of order uploads rate limit",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Server.RateLimit.Orders.Period) }},
+	{"RATE_LIMIT_WITHDRAW_REQUESTS", "rate-limit-withdraw-requests", "how many withdrawals a user may make per period, 0 for no limit",
+		func(c *Config) flag.Value { return (*intValue)(&c.Server.RateLimit.Withdraw.Requests) }},
+	{"RATE_LIMIT_WITHDRAW_PERIOD", "rate-limit-withdraw-period", "period of withdrawals rate limit",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Server.RateLimit.Withdraw.Period) }},
+	{"DATABASE_URI", "d", "database connection",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Database.URI) }},
+	{"ACCRUAL_SYSTEM_ADDRESS", "r", "accrual system address",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Accrual.Address) }},
+	{"ACCRUAL_BATCH_SIZE", "accrual-batch-size", "how many orders are sent to accrual system at once",
+		func(c *Config) flag.Value { return (*intValue)(&c.Accrual.BatchSize) }},
+	{"ACCRUAL_CONCURRENCY", "accrual-concurrency", "how many orders are sent to accrual system at the same time",
+		func(c *Config) flag.Value { return (*intValue)(&c.Accrual.Concurrency) }},
+	{"ACCRUAL_INTERVAL", "accrual-interval", "how often new orders are sent to accrual system",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Accrual.Interval) }},
+	{"ACCRUAL_DRAIN_TIMEOUT", "accrual-drain-timeout", "how long accrual jobs may take to finish on shutdown",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Accrual.DrainTimeout) }},
+	{"WEBHOOK_BATCH_SIZE", "webhook-batch-size", "how many webhook deliveries are sent at once",
+		func(c *Config) flag.Value { return (*intValue)(&c.Webhooks.BatchSize) }},
+	{"WEBHOOK_INTERVAL", "webhook-interval", "how often due webhook deliveries are sent",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Webhooks.Interval) }},
+	{"WEBHOOK_TIMEOUT", "webhook-timeout", "how long webhook may take to respond",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Webhooks.Timeout) }},
+	{"WEBHOOK_MAX_ATTEMPTS", "webhook-max-attempts", "how many times webhook delivery is sent before giving up",
+		func(c *Config) flag.Value { return (*intValue)(&c.Webhooks.MaxAttempts) }},
+	{"WEBHOOK_RETRY_BACKOFF", "webhook-retry-backoff", "delay before the first retry of webhook delivery, doubled on every next one",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Webhooks.RetryBackoff) }},
+	{"WEBHOOK_MAX_RETRY_BACKOFF", "webhook-max-retry-backoff", "maximum delay between retries of webhook delivery",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Webhooks.MaxRetryBackoff) }},
+	{"WEBHOOK_DRAIN_TIMEOUT", "webhook-drain-timeout", "how long webhook deliveries may take to finish on shutdown",
+		func(c *Config) flag.Value { return (*durationValue)(&c.Webhooks.DrainTimeout) }},
+	{"SECRET_KEY", "", "key to sign auth tokens with",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Auth.SecretKey) }},
+	{"LOG_LEVEL", "log-level", "minimum level of log entries",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Log.Level) }},
+	{"LOG_FORMAT", "log-format", "format of log entries, console or json",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Log.Format) }},
+	{"LOG_FILE", "log-file", "file to write logs to instead of stdout",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Log.File) }},
+	{"LOG_FILE_MAX_SIZE", "log-file-max-size", "size in bytes after which log file is rotated",
+		func(c *Config) flag.Value { return (*int64Value)(&c.Log.FileMaxSize) }},
+	{"LOG_FILE_MAX_BACKUPS", "log-file-max-backups", "number of rotated log files to keep",
+		func(c *Config) flag.Value { return (*intValue)(&c.Log.FileMaxBackups) }},
+	{"TRACING_EXPORTER", "tracing-exporter", "where spans are sent: none, otlp or stdout",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Tracing.Exporter) }},
+	{"TRACING_FILE", "tracing-file", "file to write spans to with stdout exporter",
+		func(c *Config) flag.Value { return (*stringValue)(&c.Tracing.File) }},
+}
+
+// Load reads config from file, env and command-line args, in order of increasing
+// precedence, and validates it. lookupEnv is os.LookupEnv outside of tests.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	flags, err := parseFlags(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+
+	path, ok := flags[fileFlagName]
+	if !ok {
+		path, _ = lookupEnv(fileEnvKey)
+	}
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := lookupEnv(s.env); ok {
+			if err := s.value(&cfg).Set(value); err != nil {
+				return Config{}, fmt.Errorf("invalid value %q of env %s: %w", value, s.env, err)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := flags[s.flag]; ok {
+			if err := s.value(&cfg).Set(value); err != nil {
+				return Config{}, fmt.Errorf("invalid value %q of flag -%s: %w", value, s.flag, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// rawFlag keeps flag value as is, to apply it after file and env are read
+type rawFlag struct {
+	value string
+	set   bool
+}
+
+func (f *rawFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *rawFlag) Set(value string) error {
+	f.value, f.set = value, true
+	return nil
+}
+
+// parseFlags returns values of flags that are set in args, by flag name
+func parseFlags(args []string) (map[string]string, error) {
+	fs := flag.NewFlagSet("gophermart", flag.ContinueOnError)
+	// Defaults are shown in usage, so secrets are hidden
+	defaults := Default().Masked()
+
+	raw := map[string]*rawFlag{fileFlagName: {}}
+	fs.Var(raw[fileFlagName], fileFlagName, fmt.Sprintf("YAML or TOML config file (env %s)", fileEnvKey))
+
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		raw[s.flag] = &rawFlag{value: s.value(&defaults).String()}
+		fs.Var(raw[s.flag], s.flag, fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]string)
+	for name, f := range raw {
+		if f.set {
+			flags[name] = f.value
+		}
+	}
+
+	return flags, nil
+}
+
+// loadFile reads settings present in file at path into cfg, keeping other settings intact.
+// Unknown settings are rejected, so that a misspelled one is not silently ignored.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, 0, len(undecoded))
+			for _, key := range undecoded {
+				keys = append(keys, key.String())
+			}
+			sort.Strings(keys)
+			return fmt.Errorf("unknown settings in config file %s: %s", path, strings.Join(keys, ", "))
+		}
+	default:
+		return fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+
+	return nil
+}
+
+type stringValue string
+
+func (v *stringValue) String() string { return string(*v) }
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+type durationValue time.Duration
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(d)
+	return nil
+}
+
+type floatValue float64
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*v = floatValue(f)
+	return nil
+}
+
+type intValue int
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+func (v *intValue) Set(s string) error {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(i)
+	return nil
+}
+
+type int64Value int64
+
+func (v *int64Value) String() string { return strconv.FormatInt(int64(*v), 10) }
+func (v *int64Value) Set(s string) error {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*v = int64Value(i)
+	return nil
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+)
+
+// reloadable lists settings that are safe to change while the server is running.
+// Changes of other settings are rejected until restart.
+var reloadable = map[string]bool{
+	"log.level":           true,
+	"accrual.batch_size":  true,
+	"accrual.interval":    true,
+	"accrual.concurrency": true,
+	// Store of rate limit buckets is kept until restart, limits themselves are applied
+	"server.rate_limit": true,
+}
+
+// Subscriber is notified of safe changes of config. It gets both previous
+// and new config, so that it could check whether its own settings have changed.
+type Subscriber func(ctx context.Context, previous Config, current Config)
+
+// Reloader reloads config and passes safe changes to subscribers
+type Reloader struct {
+	mu          sync.Mutex
+	current     Config
+	load        func() (Config, error)
+	subscribers []Subscriber
+}
+
+// NewReloader returns Reloader of running config current. load is called
+// on every reload and should read config the same way it was read on start.
+func NewReloader(current Config, load func() (Config, error)) *Reloader {
+	return &Reloader{current: current, load: load}
+}
+
+// Subscribe adds fn to be called on every reload that changes a safe setting
+func (r *Reloader) Subscribe(fn Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Current returns running config
+func (r *Reloader) Current() Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current
+}
+
+// Reload reads config again and applies changes of safe settings.
+// Changes of other settings are logged and ignored, running values are kept.
+// If new config cannot be loaded, running config is kept as a whole.
+func (r *Reloader) Reload(ctx context.Context) error {
+	_, logger := logging.CtxLogger(ctx)
+
+	loaded, err := r.load()
+	if err != nil {
+		logger.Err(err).Msg("config has not been reloaded, keeping running config")
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := r.current
+	next := previous
+	changed := false
+
+	for _, name := range diff(previous, loaded) {
+		if !reloadable[name] {
+			logger.Warn().Msgf("%s cannot be changed without restart, keeping running value", name)
+			continue
+		}
+
+		settingValue(&next, name).Set(settingValue(&loaded, name))
+		logger.Info().Msgf("%s has been changed from %v to %v", name, settingValue(&previous, name), settingValue(&next, name))
+		changed = true
+	}
+
+	if !changed {
+		logger.Info().Msg("config has been reloaded, no changes to apply")
+		return nil
+	}
+
+	r.current = next
+	for _, fn := range r.subscribers {
+		fn(ctx, previous, next)
+	}
+
+	return nil
+}
+
+// WatchSignals reloads config every time process receives one of signals, until ctx is done
+func (r *Reloader) WatchSignals(ctx context.Context, signals ...os.Signal) {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+
+	go func() {
+		defer signal.Stop(received)
+
+		for {
+			select {
+			case sig := <-received:
+				reloadCtx, logger := logging.NewCtxLogger(ctx)
+				logger.Info().Msgf("received %s, reloading config", sig)
+				_ = r.Reload(reloadCtx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// diff returns names of settings that differ in a and b, as section.setting
+func diff(a Config, b Config) []string {
+	var names []string
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		section := yamlName(va.Type().Field(i))
+		sa, sb := va.Field(i), vb.Field(i)
+
+		for j := 0; j < sa.NumField(); j++ {
+			if !reflect.DeepEqual(sa.Field(j).Interface(), sb.Field(j).Interface()) {
+				names = append(names, section+"."+yamlName(sa.Type().Field(j)))
+			}
+		}
+	}
+
+	return names
+}
+
+// settingValue returns addressable value of setting in cfg by name returned by diff
+func settingValue(cfg *Config, name string) reflect.Value {
+	sectionName, settingName := name, ""
+	if dot := strings.IndexByte(name, '.'); dot >= 0 {
+		sectionName, settingName = name[:dot], name[dot+1:]
+	}
+
+	section := fieldByYAMLName(reflect.ValueOf(cfg).Elem(), sectionName)
+	return fieldByYAMLName(section, settingName)
+}
+
+func fieldByYAMLName(v reflect.Value, name string) reflect.Value {
+	for i := 0; i < v.NumField(); i++ {
+		if yamlName(v.Type().Field(i)) == name {
+			return v.Field(i)
+		}
+	}
+
+	panic("unknown setting " + name)
+}
+
+func yamlName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+
+	return name
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader_Reload(t *testing.T) {
+	tests := []struct {
+		name           string
+		modify         func(c *Config)
+		wantCurrent    func(c *Config)
+		wantNotified   bool
+		wantLogEntries []string
+	}{
+		{
+			name: "applies safe changes and notifies subscribers",
+			modify: func(c *Config) {
+				c.Log.Level = "debug"
+				c.Accrual.Interval = 5 * time.Second
+			},
+			wantCurrent: func(c *Config) {
+				c.Log.Level = "debug"
+				c.Accrual.Interval = 5 * time.Second
+			},
+			wantNotified:   true,
+			wantLogEntries: []string{"log.level has been changed from info to debug", "accrual.interval has been changed from 1s to 5s"},
+		},
+		{
+			name: "rejects unsafe changes",
+			modify: func(c *Config) {
+				c.Server.RunAddress = "localhost:9090"
+				c.Database.URI = "postgres://localhost/other"
+			},
+			wantCurrent:    func(c *Config) {},
+			wantLogEntries: []string{"server.run_address cannot be changed without restart", "database.uri cannot be changed without restart"},
+		},
+		{
+			name: "applies safe changes and rejects unsafe ones at the same time",
+			modify: func(c *Config) {
+				c.Accrual.BatchSize = 50
+				c.Accrual.Address = "http://other:1337"
+			},
+			wantCurrent: func(c *Config) {
+				c.Accrual.BatchSize = 50
+			},
+			wantNotified:   true,
+			wantLogEntries: []string{"accrual.batch_size has been changed from 10 to 50", "accrual.address cannot be changed without restart"},
+		},
+		{
+			name: "applies changes of rate limits",
+			modify: func(c *Config) {
+				c.Server.RateLimit.Withdraw.Requests = 5
+				c.Server.RateLimit.Orders.Requests = 0
+			},
+			wantCurrent: func(c *Config) {
+				c.Server.RateLimit.Withdraw.Requests = 5
+				c.Server.RateLimit.Orders.Requests = 0
+			},
+			wantNotified:   true,
+			wantLogEntries: []string{"server.rate_limit has been changed"},
+		},
+		{
+			name:           "does nothing without changes",
+			modify:         func(c *Config) {},
+			wantCurrent:    func(c *Config) {},
+			wantLogEntries: []string{"no changes to apply"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded := Default()
+			tt.modify(&loaded)
+			want := Default()
+			tt.wantCurrent(&want)
+
+			r := NewReloader(Default(), func() (Config, error) { return loaded, nil })
+
+			var notified bool
+			r.Subscribe(func(ctx context.Context, previous Config, current Config) {
+				notified = true
+				assert.Equal(t, Default(), previous)
+				assert.Equal(t, want, current)
+			})
+
+			var out bytes.Buffer
+			ctx := logging.SetCtxLogger(context.Background(), zerolog.New(&out))
+
+			require.NoError(t, r.Reload(ctx))
+
+			assert.Equal(t, want, r.Current())
+			assert.Equal(t, tt.wantNotified, notified)
+			for _, entry := range tt.wantLogEntries {
+				assert.Contains(t, out.String(), entry)
+			}
+		})
+	}
+}
+
+func TestReloader_Reload_KeepsConfigIfLoadFails(t *testing.T) {
+	r := NewReloader(Default(), func() (Config, error) { return Config{}, errors.New("invalid config") })
+	r.Subscribe(func(context.Context, Config, Config) { t.Error("subscriber must not be notified") })
+
+	assert.Error(t, r.Reload(context.Background()))
+	assert.Equal(t, Default(), r.Current())
+}
+
+func TestReloadable(t *testing.T) {
+	cfg := Default()
+	for name := range reloadable {
+		assert.NotPanics(t, func() { settingValue(&cfg, name) }, "%s must be a known setting", name)
+	}
+}
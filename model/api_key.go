@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// APIKeyScope limits what requests authenticated with an API key can do
+type APIKeyScope string
+
+const (
+	ScopeOrdersWrite APIKeyScope = "orders:write"
+	ScopeOrdersRead  APIKeyScope = "orders:read"
+	ScopeBalanceRead APIKeyScope = "balance:read"
+	ScopeWithdraw    APIKeyScope = "withdraw"
+)
+
+// APIKeyScopes lists all scopes that can be granted to an API key
+var APIKeyScopes = []APIKeyScope{ScopeOrdersWrite, ScopeOrdersRead, ScopeBalanceRead, ScopeWithdraw}
+
+// APIKey is a named credential that lets machine-to-machine clients
+// act on behalf of a user without knowing the user's password.
+// Plaintext key is never stored, only its hash.
+type APIKey struct {
+	ID     uint64
+	UserID uint64
+	Name   string
+	// Prefix is the first characters of the key, so that users can tell their keys apart
+	Prefix    string
+	Hash      string
+	Scopes    []APIKeyScope
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// Valid checks that scope is one of known scopes
+func (s APIKeyScope) Valid() bool {
+	for _, scope := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasScope checks if the key is granted given scope
+func (k APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
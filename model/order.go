@@ -40,6 +40,37 @@ type Order struct {
 	UploadedAt time.Time
 }
 
+// OrderUploadStatus is outcome of uploading a single order number in a batch
+type OrderUploadStatus int
+
+const (
+	OrderUploadAccepted OrderUploadStatus = iota + 1
+	OrderUploadAlreadyUploaded
+	OrderUploadConflict
+	OrderUploadInvalid
+)
+
+func (status OrderUploadStatus) String() string {
+	switch status {
+	case OrderUploadAccepted:
+		return "ACCEPTED"
+	case OrderUploadAlreadyUploaded:
+		return "ALREADY_UPLOADED"
+	case OrderUploadConflict:
+		return "CONFLICT"
+	case OrderUploadInvalid:
+		return "INVALID"
+	}
+
+	return ""
+}
+
+// OrderUploadResult is outcome of uploading order number OrderID in a batch
+type OrderUploadResult struct {
+	OrderID string
+	Status  OrderUploadStatus
+}
+
 func (o Order) Validate() error {
 	n, err := strconv.Atoi(o.OrderID)
 	if err != nil {
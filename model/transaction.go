@@ -0,0 +1,14 @@
+package model
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// Transaction is a ledger entry. Accruals have positive amount,
+// withdrawals have negative amount.
+type Transaction struct {
+	OrderID   string
+	Amount    decimal.Decimal
+	CreatedAt time.Time
+}
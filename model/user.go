@@ -1,7 +1,42 @@
 package model
 
+import "time"
+
+// Role defines what a user is allowed to do
+type Role string
+
+const (
+	// RoleUser is a regular customer of the loyalty system
+	RoleUser Role = "user"
+	// RoleSupport can look up any user's orders and balance
+	RoleSupport Role = "support"
+	// RoleAdmin can do everything support can and manage roles of other users
+	RoleAdmin Role = "admin"
+)
+
+// Valid checks that role is one of known roles
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleSupport, RoleAdmin:
+		return true
+	}
+
+	return false
+}
+
 type User struct {
 	ID       uint64
 	Login    string
 	Password string
+	Role     Role
+	// TOTPSecret is base32-encoded secret for two-factor authentication.
+	// It is set on enrollment, but is only checked on login once TOTPEnabled is true.
+	TOTPSecret  string
+	TOTPEnabled bool
+	// SecondFactorLockedAt is when too many second factor codes were tried for the user.
+	// Second factor tokens issued before it are no longer accepted.
+	SecondFactorLockedAt *time.Time
+	// DeletedAt is set when user deletes the account. Deleted users are anonymized
+	// rather than removed, so that their ledger is kept for accounting.
+	DeletedAt *time.Time
 }
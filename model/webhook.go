@@ -0,0 +1,100 @@
+package model
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// WebhookEvent is a kind of event partners can subscribe to with a webhook
+type WebhookEvent string
+
+const (
+	// WebhookOrderCredited is sent when accrual for an order is credited to the user
+	WebhookOrderCredited WebhookEvent = "order.credited"
+	// WebhookPointsWithdrawn is sent when points are withdrawn to pay for an order
+	WebhookPointsWithdrawn WebhookEvent = "points.withdrawn"
+)
+
+// WebhookEvents lists all events webhooks can subscribe to
+var WebhookEvents = []WebhookEvent{WebhookOrderCredited, WebhookPointsWithdrawn}
+
+// Valid checks that event is one of known events
+func (e WebhookEvent) Valid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Webhook is a subscription of a partner to events of a user. Payloads of the events
+// are sent to URL signed with Secret, which is shared with the partner.
+type Webhook struct {
+	ID uint64
+	// UserID is the user whose events are sent to the webhook, events of other users never are
+	UserID    uint64
+	URL       string
+	Secret    string
+	Events    []WebhookEvent
+	CreatedAt time.Time
+}
+
+type WebhookDeliveryStatus int
+
+const (
+	WebhookDeliveryPending WebhookDeliveryStatus = iota + 1
+	WebhookDeliverySucceeded
+	WebhookDeliveryFailed
+)
+
+func (status WebhookDeliveryStatus) String() string {
+	switch status {
+	case WebhookDeliveryPending:
+		return "PENDING"
+	case WebhookDeliverySucceeded:
+		return "SUCCEEDED"
+	case WebhookDeliveryFailed:
+		return "FAILED"
+	}
+
+	return ""
+}
+
+// WebhookDelivery is a single event to be sent to a webhook. Deliveries are written
+// in the same transaction as the change they notify of, and then sent until
+// the webhook accepts them or attempts run out.
+type WebhookDelivery struct {
+	ID        uint64
+	WebhookID uint64
+	// UserID is the user the event is about, it is the same as the one of the webhook
+	UserID uint64
+	Event  WebhookEvent
+	// Payload is JSON body sent to the webhook
+	Payload  []byte
+	Status   WebhookDeliveryStatus
+	Attempts int
+	// NextAttemptAt is when pending delivery is sent next time
+	NextAttemptAt time.Time
+	LastAttemptAt *time.Time
+	// ResponseStatus is HTTP status of the last attempt, zero if request has failed
+	ResponseStatus int
+	// LastError describes why the last attempt has failed
+	LastError string
+	CreatedAt time.Time
+}
+
+// OrderCreditedPayload is sent with WebhookOrderCredited event
+type OrderCreditedPayload struct {
+	Order      string          `json:"order"`
+	Accrual    decimal.Decimal `json:"accrual"`
+	CreditedAt time.Time       `json:"credited_at"`
+}
+
+// PointsWithdrawnPayload is sent with WebhookPointsWithdrawn event
+type PointsWithdrawnPayload struct {
+	Order       string          `json:"order"`
+	Sum         decimal.Decimal `json:"sum"`
+	ProcessedAt time.Time       `json:"processed_at"`
+}
@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"errors"
+	"time"
+)
+
+// Config contains settings of webhook dispatcher
+type Config struct {
+	// BatchSize is how many deliveries are sent on one tick
+	BatchSize int `yaml:"batch_size" toml:"batch_size"`
+	// Interval is how often due deliveries are checked for
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Timeout limits time webhook may take to respond
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+	// MaxAttempts is how many times delivery is sent before it is marked as failed
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`
+	// RetryBackoff is delay before the first retry, it doubles with every next one
+	RetryBackoff time.Duration `yaml:"retry_backoff" toml:"retry_backoff"`
+	// MaxRetryBackoff limits delay between retries
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff" toml:"max_retry_backoff"`
+	// DrainTimeout is how long in-flight deliveries may take to finish on shutdown
+	DrainTimeout time.Duration `yaml:"drain_timeout" toml:"drain_timeout"`
+}
+
+const (
+	defaultBatchSize       = 50
+	defaultInterval        = time.Second
+	defaultTimeout         = 10 * time.Second
+	defaultMaxAttempts     = 10
+	defaultRetryBackoff    = 10 * time.Second
+	defaultMaxRetryBackoff = time.Hour
+	defaultDrainTimeout    = 10 * time.Second
+)
+
+// DefaultConfig returns webhook dispatcher settings used unless they are overridden
+func DefaultConfig() Config {
+	return Config{
+		BatchSize:       defaultBatchSize,
+		Interval:        defaultInterval,
+		Timeout:         defaultTimeout,
+		MaxAttempts:     defaultMaxAttempts,
+		RetryBackoff:    defaultRetryBackoff,
+		MaxRetryBackoff: defaultMaxRetryBackoff,
+		DrainTimeout:    defaultDrainTimeout,
+	}
+}
+
+// Validate returns error if any of the settings is invalid
+func (c Config) Validate() error {
+	if c.BatchSize <= 0 {
+		return errors.New("batch size must be positive")
+	}
+	if c.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	if c.Timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+	if c.MaxAttempts <= 0 {
+		return errors.New("max attempts must be positive")
+	}
+	if c.RetryBackoff <= 0 {
+		return errors.New("retry backoff must be positive")
+	}
+	if c.MaxRetryBackoff < c.RetryBackoff {
+		return errors.New("max retry backoff must not be less than retry backoff")
+	}
+	if c.DrainTimeout <= 0 {
+		return errors.New("drain timeout must be positive")
+	}
+
+	return nil
+}
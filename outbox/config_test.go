@@ -0,0 +1,68 @@
+package outbox
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{
+			name:   "accepts default config",
+			modify: func(c *Config) {},
+		},
+		{
+			name:    "rejects zero batch size",
+			modify:  func(c *Config) { c.BatchSize = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero interval",
+			modify:  func(c *Config) { c.Interval = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero timeout",
+			modify:  func(c *Config) { c.Timeout = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero max attempts",
+			modify:  func(c *Config) { c.MaxAttempts = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero retry backoff",
+			modify:  func(c *Config) { c.RetryBackoff = 0 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects max retry backoff less than retry backoff",
+			modify:  func(c *Config) { c.MaxRetryBackoff = c.RetryBackoff / 2 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects zero drain timeout",
+			modify:  func(c *Config) { c.DrainTimeout = 0 },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			tt.modify(&c)
+
+			err := c.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
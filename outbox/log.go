@@ -0,0 +1,15 @@
+package outbox
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+)
+
+// log returns logger with service field set.
+func (d *Dispatcher) log(ctx context.Context) *zerolog.Logger {
+	_, logger := logging.CtxLogger(ctx)
+	logger = logger.With().Str(logging.ServiceNameKey, "webhooks").Logger()
+
+	return &logger
+}
@@ -0,0 +1,313 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers sent with every delivery
+const (
+	// IDHeader contains ID of the delivery, which stays the same across retries,
+	// so that receivers can discard duplicates
+	IDHeader = "Webhook-ID"
+	// EventHeader contains event of the delivery
+	EventHeader = "Webhook-Event"
+	// TimestampHeader contains time of the attempt as Unix timestamp
+	TimestampHeader = "Webhook-Timestamp"
+	// SignatureHeader contains signature of the delivery made with Sign
+	SignatureHeader = "Webhook-Signature"
+)
+
+const (
+	// leaseMargin is added to request timeout when claiming deliveries, so that
+	// a delivery is not claimed again while its outcome is being saved
+	leaseMargin = 30 * time.Second
+	// maxErrorLength limits length of error saved with a failed attempt
+	maxErrorLength = 500
+)
+
+// Dispatcher sends webhook deliveries written to the outbox by storage.
+// Every delivery is sent at least once: receivers must be ready to get
+// the same delivery again, e.g. when dispatcher stops before saving outcome.
+type Dispatcher struct {
+	// storage is storage service to read and write from DB
+	storage storage.Storage
+	// client sends deliveries to webhooks
+	client *http.Client
+	cfg    Config
+
+	// stop is closed by Stop to stop taking new deliveries
+	stop     chan struct{}
+	stopOnce sync.Once
+	// done is closed once dispatching loop has exited and in-flight deliveries have finished
+	done chan struct{}
+	// cancelJobs cancels context of in-flight deliveries when they do not finish in time
+	cancelJobs context.CancelFunc
+}
+
+func New(cfg Config, store storage.Storage) (*Dispatcher, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid webhooks config: %w", err)
+	}
+
+	if store == nil {
+		return nil, errors.New("nil storage passed to Dispatcher constructor")
+	}
+
+	return &Dispatcher{
+		storage: store,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// Webhook must respond itself, redirects are considered failures
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// Run spins up timer ticking every cfg.Interval. On each tick dispatcher claims
+// pending deliveries that are due and sends them to their webhooks.
+// It runs until Stop is called. Deliveries are not sent on ctx,
+// so that they are not interrupted as soon as shutdown begins.
+func (d *Dispatcher) Run(ctx context.Context) {
+	timer := time.NewTicker(d.cfg.Interval)
+
+	jobsCtx, cancel := context.WithCancel(context.Background())
+	d.cancelJobs = cancel
+
+	go func() {
+		defer close(d.done)
+		defer timer.Stop()
+
+		for {
+			// Stop has priority over a tick that is due at the same time
+			select {
+			case <-d.stop:
+				d.log(ctx).Info().Msg("dispatcher stopped taking new deliveries")
+				return
+			default:
+			}
+
+			select {
+			case <-timer.C:
+				// Each tick runs with its own context and correlation ID
+				tickCtx, _ := logging.NewCtxLogger(jobsCtx)
+				if err := d.tick(tickCtx); err != nil {
+					d.log(tickCtx).Err(err).Msg("error during dispatcher tick")
+				}
+			case <-d.stop:
+				d.log(ctx).Info().Msg("dispatcher stopped taking new deliveries")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops taking new deliveries and waits for in-flight ones to finish.
+// If ctx is done first, they are cancelled. Cancelled deliveries stay pending
+// and are sent again once their lease expires.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.stopOnce.Do(func() { close(d.stop) })
+
+	// Dispatcher has never been run
+	if d.cancelJobs == nil {
+		return nil
+	}
+
+	select {
+	case <-d.done:
+		d.log(ctx).Info().Msg("all webhook deliveries have finished")
+		return nil
+	case <-ctx.Done():
+		d.log(ctx).Warn().Msg("webhook deliveries have not finished in time, cancelling them")
+		d.cancelJobs()
+		<-d.done
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) tick(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "webhooks.tick")
+	defer func() { tracing.End(span, err) }()
+	ctx = tracing.LinkLogger(ctx, span)
+
+	deliveries, err := d.storage.ClaimWebhookDeliveries(ctx, d.cfg.BatchSize, d.cfg.Timeout+leaseMargin)
+	if err != nil {
+		d.log(ctx).Err(err).Msg("failed to claim webhook deliveries")
+		return err
+	}
+
+	if len(deliveries) == 0 {
+		d.log(ctx).Debug().Msg("no webhook deliveries to send")
+		return nil
+	}
+
+	hooks, err := d.storage.Webhooks(ctx)
+	if err != nil {
+		d.log(ctx).Err(err).Msg("failed to fetch webhooks")
+		return err
+	}
+
+	byID := make(map[uint64]model.Webhook, len(hooks))
+	for _, hook := range hooks {
+		byID[hook.ID] = hook
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		hook, ok := byID[delivery.WebhookID]
+		if !ok {
+			// Webhook has been deleted after delivery was claimed, and delivery along with it
+			continue
+		}
+		if delivery.UserID != hook.UserID {
+			// Storage only writes deliveries for webhooks of the user the event is about,
+			// this makes sure events of a user never reach webhooks of another one
+			d.log(ctx).Error().Msgf("delivery %d of user %d does not belong to webhook %d of user %d, dropping it", delivery.ID, delivery.UserID, hook.ID, hook.UserID)
+			delivery.Status = model.WebhookDeliveryFailed
+			delivery.LastError = "delivery belongs to another user than webhook"
+			if err := d.storage.UpdateWebhookDelivery(ctx, delivery); err != nil {
+				d.log(ctx).Err(err).Msgf("failed to drop delivery %d", delivery.ID)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(hook model.Webhook, delivery model.WebhookDelivery) {
+			defer wg.Done()
+
+			jobCtx, _ := logging.NewCtxLogger(ctx)
+			if err := d.deliver(jobCtx, hook, delivery); err != nil {
+				d.log(jobCtx).Err(err).Msgf("error delivering %d to webhook %d", delivery.ID, hook.ID)
+			}
+		}(hook, delivery)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// deliver sends delivery to the webhook and saves outcome of the attempt.
+// Failed delivery is retried with exponential backoff until attempts run out.
+func (d *Dispatcher) deliver(ctx context.Context, hook model.Webhook, delivery model.WebhookDelivery) error {
+	status, err := d.send(ctx, hook, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+	delivery.LastError = ""
+
+	switch {
+	case err == nil:
+		delivery.Status = model.WebhookDeliverySucceeded
+		d.log(ctx).Info().Msgf("delivered %d to webhook %d", delivery.ID, hook.ID)
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.LastError = truncate(err.Error(), maxErrorLength)
+		d.log(ctx).Warn().Msgf("giving up delivering %d to webhook %d after %d attempts", delivery.ID, hook.ID, delivery.Attempts)
+	default:
+		delivery.NextAttemptAt = now.Add(d.backoff(delivery.Attempts))
+		delivery.LastError = truncate(err.Error(), maxErrorLength)
+		d.log(ctx).Info().Msgf("delivery %d to webhook %d will be retried at %s", delivery.ID, hook.ID, delivery.NextAttemptAt)
+	}
+
+	return d.storage.UpdateWebhookDelivery(ctx, delivery)
+}
+
+// send makes a single attempt to send delivery and returns status of the response,
+// if there was one. Any status other than 2xx is an error.
+func (d *Dispatcher) send(ctx context.Context, hook model.Webhook, delivery model.WebhookDelivery) (status int, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "webhooks.send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(tracing.WebhookID(hook.ID), tracing.WebhookDeliveryID(delivery.ID)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		d.log(ctx).Err(err).Msgf("failed to build request to webhook %d", hook.ID)
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(EventHeader, string(delivery.Event))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, timestamp, delivery.Payload))
+	tracing.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		d.log(ctx).Err(err).Msgf("failed to send delivery %d to webhook %d", delivery.ID, hook.ID)
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Body is not used, but reading it lets the connection be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff returns delay before the next attempt after attempts have failed.
+// Delay doubles with every attempt, but never exceeds cfg.MaxRetryBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.RetryBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.cfg.MaxRetryBackoff {
+			return d.cfg.MaxRetryBackoff
+		}
+	}
+
+	return delay
+}
+
+// Sign returns signature of payload sent at timestamp, which is sent in SignatureHeader.
+// It is hex-encoded HMAC-SHA256 of timestamp and payload joined with a dot,
+// keyed with secret of the webhook. Receivers compute the same signature to check
+// that delivery comes from us, and reject old timestamps to prevent replays.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+
+	return s[:length]
+}
@@ -0,0 +1,262 @@
+package outbox
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/soundrussian/go-practicum-diploma/model"
+	storageMock "github.com/soundrussian/go-practicum-diploma/storage/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "whsec_test"
+
+func TestDispatcher_Deliver(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		attempts       int
+		wantStatus     model.WebhookDeliveryStatus
+		wantAttempts   int
+		wantError      string
+		wantNextInSecs int
+	}{
+		{
+			name:         "marks delivery as succeeded if webhook accepts it",
+			status:       http.StatusNoContent,
+			wantStatus:   model.WebhookDeliverySucceeded,
+			wantAttempts: 1,
+		},
+		{
+			name:           "schedules retry if webhook fails",
+			status:         http.StatusInternalServerError,
+			attempts:       2,
+			wantStatus:     model.WebhookDeliveryPending,
+			wantAttempts:   3,
+			wantError:      "webhook responded with status 500",
+			wantNextInSecs: 40,
+		},
+		{
+			name:         "treats redirect as failure",
+			status:       http.StatusFound,
+			wantStatus:   model.WebhookDeliveryPending,
+			wantAttempts: 1,
+			wantError:    "webhook responded with status 302",
+			// First retry is made after RetryBackoff
+			wantNextInSecs: 10,
+		},
+		{
+			name:         "marks delivery as failed when attempts run out",
+			status:       http.StatusBadGateway,
+			attempts:     DefaultConfig().MaxAttempts - 1,
+			wantStatus:   model.WebhookDeliveryFailed,
+			wantAttempts: DefaultConfig().MaxAttempts,
+			wantError:    "webhook responded with status 502",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := []byte(`{"order":"79927398713","sum":100,"processed_at":"2022-03-07T09:05:11Z"}`)
+
+			var received *http.Request
+			var body []byte
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "/elsewhere")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			var saved model.WebhookDelivery
+			store := new(storageMock.Storage)
+			store.On("UpdateWebhookDelivery", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) { saved = args.Get(1).(model.WebhookDelivery) }).
+				Return(nil)
+
+			d := newTestDispatcher(t, store)
+			hook := model.Webhook{ID: 1, URL: ts.URL, Secret: testSecret}
+			delivery := model.WebhookDelivery{
+				ID:        42,
+				WebhookID: 1,
+				Event:     model.WebhookPointsWithdrawn,
+				Payload:   payload,
+				Status:    model.WebhookDeliveryPending,
+				Attempts:  tt.attempts,
+			}
+
+			start := time.Now()
+			require.NoError(t, d.deliver(context.Background(), hook, delivery))
+
+			require.NotNil(t, received)
+			assert.Equal(t, http.MethodPost, received.Method)
+			assert.Equal(t, payload, body)
+			assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+			assert.Equal(t, "42", received.Header.Get(IDHeader))
+			assert.Equal(t, "points.withdrawn", received.Header.Get(EventHeader))
+
+			timestamp, err := strconv.ParseInt(received.Header.Get(TimestampHeader), 10, 64)
+			require.NoError(t, err)
+			assert.Equal(t, Sign(testSecret, timestamp, payload), received.Header.Get(SignatureHeader))
+
+			assert.Equal(t, tt.wantStatus, saved.Status)
+			assert.Equal(t, tt.wantAttempts, saved.Attempts)
+			assert.Equal(t, tt.status, saved.ResponseStatus)
+			assert.Equal(t, tt.wantError, saved.LastError)
+			require.NotNil(t, saved.LastAttemptAt)
+			if tt.wantNextInSecs > 0 {
+				assert.WithinDuration(t, start.Add(time.Duration(tt.wantNextInSecs)*time.Second), saved.NextAttemptAt, time.Second)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Deliver_Unreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	url := ts.URL
+	ts.Close()
+
+	var saved model.WebhookDelivery
+	store := new(storageMock.Storage)
+	store.On("UpdateWebhookDelivery", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(model.WebhookDelivery) }).
+		Return(nil)
+
+	d := newTestDispatcher(t, store)
+	err := d.deliver(context.Background(), model.Webhook{ID: 1, URL: url}, model.WebhookDelivery{ID: 42, Status: model.WebhookDeliveryPending})
+	require.NoError(t, err)
+
+	assert.Equal(t, model.WebhookDeliveryPending, saved.Status)
+	assert.Equal(t, 1, saved.Attempts)
+	assert.Zero(t, saved.ResponseStatus)
+	assert.Contains(t, saved.LastError, "connection refused")
+}
+
+func TestDispatcher_Tick(t *testing.T) {
+	delivered := make(chan string, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- r.Header.Get(IDHeader)
+	}))
+	defer ts.Close()
+
+	store := new(storageMock.Storage)
+	store.On("ClaimWebhookDeliveries", mock.Anything, DefaultConfig().BatchSize, DefaultConfig().Timeout+leaseMargin).
+		Return([]model.WebhookDelivery{
+			{ID: 1, WebhookID: 1, Status: model.WebhookDeliveryPending},
+			// Webhook has been deleted since the delivery was claimed
+			{ID: 2, WebhookID: 2, Status: model.WebhookDeliveryPending},
+			{ID: 3, WebhookID: 1, Status: model.WebhookDeliveryPending},
+		}, nil)
+	store.On("Webhooks", mock.Anything).Return([]model.Webhook{{ID: 1, URL: ts.URL, Secret: testSecret}}, nil)
+	store.On("UpdateWebhookDelivery", mock.Anything, mock.MatchedBy(func(d model.WebhookDelivery) bool {
+		return d.Status == model.WebhookDeliverySucceeded
+	})).Return(nil).Twice()
+
+	d := newTestDispatcher(t, store)
+	require.NoError(t, d.tick(context.Background()))
+	close(delivered)
+
+	ids := make([]string, 0)
+	for id := range delivered {
+		ids = append(ids, id)
+	}
+	assert.ElementsMatch(t, []string{"1", "3"}, ids)
+	store.AssertExpectations(t)
+}
+
+func TestDispatcher_Tick_SendsEventsToWebhooksOfTheirUsers(t *testing.T) {
+	receiver := func(delivered chan<- string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			delivered <- r.Header.Get(IDHeader)
+		}))
+	}
+
+	deliveredToA := make(chan string, 3)
+	partnerA := receiver(deliveredToA)
+	defer partnerA.Close()
+
+	deliveredToB := make(chan string, 3)
+	partnerB := receiver(deliveredToB)
+	defer partnerB.Close()
+
+	store := new(storageMock.Storage)
+	store.On("ClaimWebhookDeliveries", mock.Anything, DefaultConfig().BatchSize, DefaultConfig().Timeout+leaseMargin).
+		Return([]model.WebhookDelivery{
+			{ID: 1, WebhookID: 1, UserID: 100, Status: model.WebhookDeliveryPending},
+			{ID: 2, WebhookID: 2, UserID: 200, Status: model.WebhookDeliveryPending},
+			// Event of user of partner B must never be sent to partner A
+			{ID: 3, WebhookID: 1, UserID: 200, Status: model.WebhookDeliveryPending},
+		}, nil)
+	store.On("Webhooks", mock.Anything).Return([]model.Webhook{
+		{ID: 1, UserID: 100, URL: partnerA.URL, Secret: testSecret},
+		{ID: 2, UserID: 200, URL: partnerB.URL, Secret: testSecret},
+	}, nil)
+	store.On("UpdateWebhookDelivery", mock.Anything, mock.MatchedBy(func(d model.WebhookDelivery) bool {
+		return d.ID != 3 && d.Status == model.WebhookDeliverySucceeded
+	})).Return(nil).Twice()
+	store.On("UpdateWebhookDelivery", mock.Anything, mock.MatchedBy(func(d model.WebhookDelivery) bool {
+		return d.ID == 3 && d.Status == model.WebhookDeliveryFailed
+	})).Return(nil).Once()
+
+	d := newTestDispatcher(t, store)
+	require.NoError(t, d.tick(context.Background()))
+	close(deliveredToA)
+	close(deliveredToB)
+
+	received := func(delivered <-chan string) []string {
+		ids := make([]string, 0)
+		for id := range delivered {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{"1"}, received(deliveredToA))
+	assert.Equal(t, []string{"2"}, received(deliveredToB))
+	store.AssertExpectations(t)
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 10 * time.Second},
+		{attempts: 2, want: 20 * time.Second},
+		{attempts: 3, want: 40 * time.Second},
+		{attempts: 9, want: 42*time.Minute + 40*time.Second},
+		{attempts: 10, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempts), func(t *testing.T) {
+			d := newTestDispatcher(t, new(storageMock.Storage))
+			assert.Equal(t, tt.want, d.backoff(tt.attempts))
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1646643911.{"order":"1"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=e8d09670f21f99e6767e8fff4c0039a5e3ae51646e5665200a020df2d6062b65",
+		Sign("secret", 1646643911, []byte(`{"order":"1"}`)),
+	)
+}
+
+func newTestDispatcher(t *testing.T, store *storageMock.Storage) *Dispatcher {
+	t.Helper()
+
+	d, err := New(DefaultConfig(), store)
+	require.NoError(t, err)
+
+	return d
+}
@@ -0,0 +1,187 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code classifies an error independently of the layer it was produced in
+type Code string
+
+const (
+	CodeInternal             Code = "internal"
+	CodeInvalidArgument      Code = "invalid_argument"
+	CodeUnauthenticated      Code = "unauthenticated"
+	CodeForbidden            Code = "forbidden"
+	CodeInsufficientFunds    Code = "insufficient_funds"
+	CodeNotFound             Code = "not_found"
+	CodeConflict             Code = "conflict"
+	CodeUnprocessable        Code = "unprocessable"
+	CodeTooLarge             Code = "too_large"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeTooManyRequests      Code = "too_many_requests"
+)
+
+// httpStatuses maps error codes to HTTP statuses handlers respond with
+var httpStatuses = map[Code]int{
+	CodeInternal:             http.StatusInternalServerError,
+	CodeInvalidArgument:      http.StatusBadRequest,
+	CodeUnauthenticated:      http.StatusUnauthorized,
+	CodeForbidden:            http.StatusForbidden,
+	CodeInsufficientFunds:    http.StatusPaymentRequired,
+	CodeNotFound:             http.StatusNotFound,
+	CodeConflict:             http.StatusConflict,
+	CodeUnprocessable:        http.StatusUnprocessableEntity,
+	CodeTooLarge:             http.StatusRequestEntityTooLarge,
+	CodeUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	CodeTooManyRequests:      http.StatusTooManyRequests,
+}
+
+// internalMessage is shown to clients instead of messages of internal errors
+const internalMessage = "internal error"
+
+// Error is an error that carries a Code and a message that is safe to show to clients.
+// Errors are usually declared once as package-level sentinels and then either returned
+// as they are or wrapped with the underlying cause using Wrap.
+type Error struct {
+	// Code classifies the error
+	Code Code
+	// Message describes the error and is used both in logs and in responses
+	Message string
+	// public, if set, is shown to clients instead of Message
+	public string
+	// reason is a stable identifier of the error clients can rely on, see WithReason
+	reason string
+	// cause is the underlying error, it is never shown to clients
+	cause error
+	// kind is the sentinel error this one was created from by Wrap
+	kind *Error
+}
+
+// New creates new error with given code and message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// WithPublic returns copy of the error that shows message to clients instead of e.Message.
+// It is used when e.Message reveals more than clients should know, e.g. whether login exists.
+func (e *Error) WithPublic(message string) *Error {
+	cp := *e
+	cp.public = message
+	return &cp
+}
+
+// WithReason returns copy of the error identified to clients by reason, e.g. "invalid_order_number".
+// Unlike messages, reasons never change, so that clients could tell errors with the same code apart.
+func (e *Error) WithReason(reason string) *Error {
+	cp := *e
+	cp.reason = reason
+	return &cp
+}
+
+// Wrap returns error of the same kind as sentinel that keeps cause for logging.
+// Result matches sentinel with errors.Is, and cause is available via errors.Unwrap.
+func Wrap(sentinel *Error, cause error) *Error {
+	return &Error{
+		Code:    sentinel.Code,
+		Message: sentinel.Message,
+		public:  sentinel.public,
+		reason:  sentinel.reason,
+		cause:   cause,
+		kind:    sentinel.origin(),
+	}
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+
+	return e.Message
+}
+
+// Unwrap returns underlying cause of the error
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is the sentinel the error was created from
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+
+	return e.origin() == t.origin()
+}
+
+// PublicMessage returns message that can be sent to clients
+func (e *Error) PublicMessage() string {
+	if e.Code == CodeInternal {
+		return internalMessage
+	}
+	if e.public != "" {
+		return e.public
+	}
+
+	return e.Message
+}
+
+// Reason returns stable identifier of the error. Errors without a reason
+// are identified by their code, and internal errors are never told apart.
+func (e *Error) Reason() string {
+	if e.Code == CodeInternal || e.reason == "" {
+		return string(e.Code)
+	}
+
+	return e.reason
+}
+
+func (e *Error) origin() *Error {
+	if e.kind != nil {
+		return e.kind
+	}
+
+	return e
+}
+
+// CodeOf returns code of the first *Error in err's chain.
+// Errors that do not carry a code are considered internal.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	return CodeInternal
+}
+
+// HTTPStatus returns HTTP status matching the code of err
+func HTTPStatus(err error) int {
+	if status, ok := httpStatuses[CodeOf(err)]; ok {
+		return status
+	}
+
+	return http.StatusInternalServerError
+}
+
+// PublicMessage returns message of err that is safe to send to clients.
+// Messages of errors without a code are never exposed.
+func PublicMessage(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.PublicMessage()
+	}
+
+	return internalMessage
+}
+
+// Reason returns stable identifier of err that can be sent to clients
+func Reason(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Reason()
+	}
+
+	return string(CodeInternal)
+}
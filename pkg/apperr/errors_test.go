@@ -0,0 +1,170 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+var (
+	errSentinel = New(CodeConflict, "sentinel error")
+	errOther    = New(CodeConflict, "sentinel error")
+	errHidden   = New(CodeUnauthenticated, "user not found").WithPublic("incorrect login or password")
+	errInternal = New(CodeInternal, "failed to save")
+)
+
+func TestError_Is(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{
+			name:   "matches the same sentinel",
+			err:    errSentinel,
+			target: errSentinel,
+			want:   true,
+		},
+		{
+			name:   "does not match another sentinel with the same code and message",
+			err:    errSentinel,
+			target: errOther,
+			want:   false,
+		},
+		{
+			name:   "wrapped error matches its sentinel",
+			err:    Wrap(errSentinel, errors.New("cause")),
+			target: errSentinel,
+			want:   true,
+		},
+		{
+			name:   "wrapped error matches its cause",
+			err:    Wrap(errSentinel, errOther),
+			target: errOther,
+			want:   true,
+		},
+		{
+			name:   "sentinel wrapped with fmt.Errorf matches the sentinel",
+			err:    fmt.Errorf("%w: details", errSentinel),
+			target: errSentinel,
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errors.Is(tt.err, tt.target))
+		})
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "returns status matching error code",
+			err:  errSentinel,
+			want: http.StatusConflict,
+		},
+		{
+			name: "returns status of wrapped error",
+			err:  fmt.Errorf("%w: details", New(CodeInsufficientFunds, "not enough")),
+			want: http.StatusPaymentRequired,
+		},
+		{
+			name: "returns 500 for errors without code",
+			err:  errors.New("plain error"),
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HTTPStatus(tt.err))
+		})
+	}
+}
+
+func TestPublicMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "returns message of the error",
+			err:  errSentinel,
+			want: "sentinel error",
+		},
+		{
+			name: "does not return message of the cause",
+			err:  Wrap(errSentinel, errors.New("pq: connection refused")),
+			want: "sentinel error",
+		},
+		{
+			name: "returns public message if it is set",
+			err:  errHidden,
+			want: "incorrect login or password",
+		},
+		{
+			name: "hides message of internal errors",
+			err:  errInternal,
+			want: internalMessage,
+		},
+		{
+			name: "hides message of errors without code",
+			err:  errors.New("pq: connection refused"),
+			want: internalMessage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PublicMessage(tt.err))
+		})
+	}
+}
+
+func TestReason(t *testing.T) {
+	errWithReason := New(CodeUnprocessable, "invalid order number").WithReason("invalid_order_number")
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "returns reason of the error",
+			err:  errWithReason,
+			want: "invalid_order_number",
+		},
+		{
+			name: "keeps reason of wrapped sentinel",
+			err:  fmt.Errorf("withdraw: %w", Wrap(errWithReason, errors.New("luhn check failed"))),
+			want: "invalid_order_number",
+		},
+		{
+			name: "falls back to code if reason is not set",
+			err:  errSentinel,
+			want: string(CodeConflict),
+		},
+		{
+			name: "does not tell internal errors apart",
+			err:  New(CodeInternal, "failed to save").WithReason("db_down"),
+			want: string(CodeInternal),
+		},
+		{
+			name: "treats errors without code as internal",
+			err:  errors.New("pq: connection refused"),
+			want: string(CodeInternal),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Reason(tt.err))
+		})
+	}
+}
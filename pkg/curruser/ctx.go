@@ -3,6 +3,7 @@ package curruser
 import (
 	"context"
 	"errors"
+	"github.com/soundrussian/go-practicum-diploma/model"
 )
 
 func CurrentUser(ctx context.Context) (uint64, error) {
@@ -20,3 +21,39 @@ func CurrentUser(ctx context.Context) (uint64, error) {
 func SetCurrentUser(ctx context.Context, userID uint64) context.Context {
 	return context.WithValue(ctx, CurrentUserKey, userID)
 }
+
+// Scopes returns scopes current request is limited to. If request has been
+// authenticated by user session rather than API key, ok is false and
+// request is not limited by scopes.
+func Scopes(ctx context.Context) (scopes []model.APIKeyScope, ok bool) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	scopes, ok = ctx.Value(CurrentScopesKey).([]model.APIKeyScope)
+	return scopes, ok
+}
+
+// SetScopes limits current request to given scopes
+func SetScopes(ctx context.Context, scopes []model.APIKeyScope) context.Context {
+	return context.WithValue(ctx, CurrentScopesKey, scopes)
+}
+
+// Role returns role of the current user. If role has not been set,
+// e.g. for requests authenticated by API key, the least privileged role is returned.
+func Role(ctx context.Context) model.Role {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if role, ok := ctx.Value(CurrentRoleKey).(model.Role); ok {
+		return role
+	}
+
+	return model.RoleUser
+}
+
+// SetRole stores role of the current user in the context
+func SetRole(ctx context.Context, role model.Role) context.Context {
+	return context.WithValue(ctx, CurrentRoleKey, role)
+}
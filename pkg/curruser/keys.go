@@ -3,5 +3,7 @@ package curruser
 import "github.com/soundrussian/go-practicum-diploma/pkg"
 
 const (
-	CurrentUserKey pkg.ContextKey = "current_user"
+	CurrentUserKey   pkg.ContextKey = "current_user"
+	CurrentScopesKey pkg.ContextKey = "current_scopes"
+	CurrentRoleKey   pkg.ContextKey = "current_role"
 )
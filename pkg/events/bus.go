@@ -0,0 +1,162 @@
+// Package events delivers changes of user data, such as order statuses and balance,
+// from services that make them to clients subscribed to them, e.g. via /api/user/events.
+package events
+
+import (
+	"sync"
+)
+
+// Type tells what kind of data has changed
+type Type string
+
+const (
+	// TypeOrder is published when status of order changes, its Data is model.Order
+	TypeOrder Type = "order"
+	// TypeBalance is published when balance changes, its Data is model.UserBalance
+	TypeBalance Type = "balance"
+)
+
+// DefaultHistorySize is how many recent events Bus keeps for replay by default
+const DefaultHistorySize = 1000
+
+// subscriberBuffer is how many events may wait for a subscriber to receive them
+// before the subscriber is considered too slow and dropped
+const subscriberBuffer = 16
+
+// Event is a change of data of a single user
+type Event struct {
+	// ID grows with every published event, so that subscribers could tell which events they missed
+	ID uint64
+	// UserID is the user the data belongs to, only the user's subscriptions receive the event
+	UserID uint64
+	Type   Type
+	Data   interface{}
+}
+
+// Publisher is used by services to publish changes they make
+type Publisher interface {
+	Publish(userID uint64, typ Type, data interface{})
+}
+
+var _ Publisher = (*Bus)(nil)
+
+// Bus is in-process event bus. It delivers published events to subscriptions
+// of their users and keeps recent events, so that subscribers reconnecting after
+// a disconnect could receive the ones they missed.
+type Bus struct {
+	mu sync.Mutex
+	// lastID is ID of the last published event
+	lastID uint64
+	// history contains at most historySize recent events, oldest first
+	history     []Event
+	historySize int
+	// subscriptions are active subscriptions by user ID
+	subscriptions map[uint64]map[*Subscription]struct{}
+}
+
+// NewBus creates bus that keeps historySize recent events for replay
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		historySize:   historySize,
+		history:       make([]Event, 0, historySize),
+		subscriptions: make(map[uint64]map[*Subscription]struct{}),
+	}
+}
+
+// Publish delivers event with data of the user to all subscriptions of the user.
+// It never blocks: subscriptions that do not keep up are closed, and their
+// subscribers are expected to subscribe again with the last event they received.
+func (b *Bus) Publish(userID uint64, typ Type, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{ID: b.lastID, UserID: userID, Type: typ, Data: data}
+
+	if b.historySize > 0 {
+		if len(b.history) == b.historySize {
+			copy(b.history, b.history[1:])
+			b.history = b.history[:len(b.history)-1]
+		}
+		b.history = append(b.history, event)
+	}
+
+	for sub := range b.subscriptions[userID] {
+		select {
+		case sub.events <- event:
+		default:
+			b.unsubscribe(sub)
+		}
+	}
+}
+
+// Subscribe starts delivering events of the user and returns events published after lastEventID
+// that are still kept by the bus. Pass zero lastEventID to skip replay. lastEventID greater
+// than ID of any event means it was issued before restart, so all kept events are replayed.
+func (b *Bus) Subscribe(userID uint64, lastEventID uint64) ([]Event, *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastEventID > b.lastID {
+		lastEventID = 0
+	} else if lastEventID == 0 {
+		lastEventID = b.lastID
+	}
+
+	replay := make([]Event, 0)
+	for _, event := range b.history {
+		if event.ID > lastEventID && event.UserID == userID {
+			replay = append(replay, event)
+		}
+	}
+
+	sub := &Subscription{
+		bus:    b,
+		userID: userID,
+		events: make(chan Event, subscriberBuffer),
+	}
+	if b.subscriptions[userID] == nil {
+		b.subscriptions[userID] = make(map[*Subscription]struct{})
+	}
+	b.subscriptions[userID][sub] = struct{}{}
+
+	return replay, sub
+}
+
+// unsubscribe removes subscription and closes its channel, b.mu must be held
+func (b *Bus) unsubscribe(sub *Subscription) {
+	subs, ok := b.subscriptions[sub.userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscriptions, sub.userID)
+	}
+	close(sub.events)
+}
+
+// Subscription receives events of a single user
+type Subscription struct {
+	bus    *Bus
+	userID uint64
+	events chan Event
+}
+
+// Events returns channel of published events. It is closed once subscription
+// is closed, either by Close or by the bus if subscriber does not keep up.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops delivering events to the subscription, it is safe to call more than once
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.unsubscribe(s)
+}
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus_Publish(t *testing.T) {
+	bus := NewBus(10)
+
+	_, sub := bus.Subscribe(100, 0)
+	defer sub.Close()
+	_, other := bus.Subscribe(200, 0)
+	defer other.Close()
+
+	bus.Publish(100, TypeOrder, "order")
+
+	select {
+	case event := <-sub.Events():
+		assert.Equal(t, Event{ID: 1, UserID: 100, Type: TypeOrder, Data: "order"}, event)
+	default:
+		t.Fatal("event has not been delivered to subscription of the user")
+	}
+
+	select {
+	case event := <-other.Events():
+		t.Fatalf("event of another user has been delivered: %+v", event)
+	default:
+	}
+}
+
+func TestBus_Subscribe(t *testing.T) {
+	tests := []struct {
+		name        string
+		lastEventID uint64
+		wantIDs     []uint64
+	}{
+		{
+			name:        "does not replay without last event ID",
+			lastEventID: 0,
+			wantIDs:     []uint64{},
+		},
+		{
+			name:        "replays events of the user published after last event ID",
+			lastEventID: 2,
+			wantIDs:     []uint64{4, 5},
+		},
+		{
+			name:        "replays only events still kept in history",
+			lastEventID: 1,
+			wantIDs:     []uint64{2, 4, 5},
+		},
+		{
+			name:        "replays all kept events if last event ID is from before restart",
+			lastEventID: 42,
+			wantIDs:     []uint64{2, 4, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewBus(4)
+			bus.Publish(100, TypeOrder, nil)   // 1, evicted from history
+			bus.Publish(100, TypeOrder, nil)   // 2
+			bus.Publish(200, TypeOrder, nil)   // 3, another user
+			bus.Publish(100, TypeBalance, nil) // 4
+			bus.Publish(100, TypeOrder, nil)   // 5
+
+			replay, sub := bus.Subscribe(100, tt.lastEventID)
+			defer sub.Close()
+
+			ids := make([]uint64, 0, len(replay))
+			for _, event := range replay {
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestBus_DropsSlowSubscription(t *testing.T) {
+	bus := NewBus(0)
+	_, sub := bus.Subscribe(100, 0)
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		bus.Publish(100, TypeOrder, nil)
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+
+	// Closing dropped subscription is a no-op
+	require.NotPanics(t, sub.Close)
+}
+
+func TestSubscription_Close(t *testing.T) {
+	bus := NewBus(0)
+	_, sub := bus.Subscribe(100, 0)
+
+	sub.Close()
+	sub.Close()
+
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.NotPanics(t, func() { bus.Publish(100, TypeOrder, nil) })
+}
@@ -0,0 +1,91 @@
+// Package health runs dependency checks for liveness and readiness endpoints
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status of a check or of all checks together
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+// DefaultTimeout limits how long a single check can run
+const DefaultTimeout = 2 * time.Second
+
+// Check is a single dependency check
+type Check struct {
+	// Name identifies the check in the report
+	Name string
+	// Critical checks make the whole report fail. Failures of other checks
+	// are reported, but the service is considered ready despite them.
+	Critical bool
+	// Run returns error if dependency is not healthy
+	Run func(ctx context.Context) error
+}
+
+// Result of a single check
+type Result struct {
+	Name      string  `json:"name"`
+	Status    Status  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report of all checks
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Run runs checks concurrently, each limited by timeout, and returns report
+// with results in the order checks were passed
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) Report {
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = run(ctx, timeout, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Critical && result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func run(ctx context.Context, timeout time.Duration, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+
+	result := Result{
+		Name:      check.Name,
+		Status:    StatusOK,
+		Critical:  check.Critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("connection refused") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantStatus Status
+		wantChecks []Status
+	}{
+		{
+			name:       "passes if all checks pass",
+			checks:     []Check{{Name: "database", Critical: true, Run: ok}, {Name: "accrual", Run: ok}},
+			wantStatus: StatusOK,
+			wantChecks: []Status{StatusOK, StatusOK},
+		},
+		{
+			name:       "fails if critical check fails",
+			checks:     []Check{{Name: "database", Critical: true, Run: failing}, {Name: "accrual", Run: ok}},
+			wantStatus: StatusFail,
+			wantChecks: []Status{StatusFail, StatusOK},
+		},
+		{
+			name:       "passes if only non-critical check fails",
+			checks:     []Check{{Name: "database", Critical: true, Run: ok}, {Name: "accrual", Run: failing}},
+			wantStatus: StatusOK,
+			wantChecks: []Status{StatusOK, StatusFail},
+		},
+		{
+			name:       "fails check that times out",
+			checks:     []Check{{Name: "database", Critical: true, Run: slow}},
+			wantStatus: StatusFail,
+			wantChecks: []Status{StatusFail},
+		},
+		{
+			name:       "passes without checks",
+			wantStatus: StatusOK,
+			wantChecks: []Status{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Run(context.Background(), 10*time.Millisecond, tt.checks...)
+
+			assert.Equal(t, tt.wantStatus, report.Status)
+			require.Len(t, report.Checks, len(tt.wantChecks))
+			for i, want := range tt.wantChecks {
+				assert.Equal(t, tt.checks[i].Name, report.Checks[i].Name)
+				assert.Equal(t, want, report.Checks[i].Status)
+				if want == StatusFail {
+					assert.NotEmpty(t, report.Checks[i].Error)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"context"
+	"github.com/soundrussian/go-practicum-diploma/pkg"
+)
+
+const contextKeyAccessEntry = pkg.ContextKey("AccessEntry")
+
+// AccessEntry collects request details for access log that become known
+// deeper in the middleware chain than access log middleware itself,
+// e.g. the user authenticated by request token.
+type AccessEntry struct {
+	UserID *uint64
+}
+
+// WithAccessEntry stores access log entry in context, so that
+// inner middleware could fill it in with SetAccessUser
+func WithAccessEntry(ctx context.Context, entry *AccessEntry) context.Context {
+	return context.WithValue(ctx, contextKeyAccessEntry, entry)
+}
+
+// SetAccessUser records user ID of the current request in access log entry.
+// It does nothing if context has no access log entry.
+func SetAccessUser(ctx context.Context, userID uint64) {
+	if entry, ok := ctx.Value(contextKeyAccessEntry).(*AccessEntry); ok {
+		entry.UserID = &userID
+	}
+}
@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/pkg"
+	"io"
+	"strings"
+)
+
+// BodyPolicy defines how request body is logged
+type BodyPolicy int
+
+const (
+	// BodyOmit means that body is not logged at all. It is the default,
+	// so that routes have to opt in to body logging.
+	BodyOmit BodyPolicy = iota
+	// BodyRedacted means that body is logged as JSON with values of sensitive fields masked.
+	// Bodies that are not valid JSON are not logged.
+	BodyRedacted
+	// BodyFull means that body is logged as is. It should only be used
+	// for routes that never receive secrets.
+	BodyFull
+)
+
+const (
+	// MaxLoggedBody is the maximum number of body bytes kept for logging
+	MaxLoggedBody = 4 << 10
+	// Redacted replaces values of sensitive fields
+	Redacted = "[REDACTED]"
+)
+
+const contextKeyBodyPolicy = pkg.ContextKey("BodyPolicy")
+
+// sensitiveFields are JSON fields whose values are never logged.
+// Fields are matched case-insensitively at any depth.
+var sensitiveFields = map[string]struct{}{
+	"password":         {},
+	"token":            {},
+	"two_factor_token": {},
+	"code":             {},
+	"key":              {},
+	"secret":           {},
+	"recovery_codes":   {},
+}
+
+// BodyCapture passes request body through to its reader and keeps
+// up to limit bytes of what has been read for logging. Unlike reading the whole
+// body upfront, it neither buffers unbounded bodies nor delays the handler.
+type BodyCapture struct {
+	body  io.ReadCloser
+	buf   bytes.Buffer
+	limit int
+	total int64
+}
+
+// NewBodyCapture wraps body keeping up to limit bytes of it
+func NewBodyCapture(body io.ReadCloser, limit int) *BodyCapture {
+	return &BodyCapture{body: body, limit: limit}
+}
+
+func (c *BodyCapture) Read(p []byte) (int, error) {
+	n, err := c.body.Read(p)
+	c.total += int64(n)
+
+	if room := c.limit - c.buf.Len(); room > 0 && n > 0 {
+		if n < room {
+			room = n
+		}
+		c.buf.Write(p[:room])
+	}
+
+	return n, err
+}
+
+func (c *BodyCapture) Close() error {
+	return c.body.Close()
+}
+
+// Loggable returns what has been read from body prepared for logging according to policy
+func (c *BodyCapture) Loggable(policy BodyPolicy) string {
+	if c.total == 0 {
+		return ""
+	}
+
+	truncated := c.total > int64(c.buf.Len())
+
+	switch policy {
+	case BodyFull:
+		if truncated {
+			return fmt.Sprintf("%s...[truncated, %d bytes total]", c.buf.String(), c.total)
+		}
+		return c.buf.String()
+	case BodyRedacted:
+		if truncated {
+			return fmt.Sprintf("[%d bytes, too large to redact]", c.total)
+		}
+		redacted, err := RedactJSON(c.buf.Bytes())
+		if err != nil {
+			return fmt.Sprintf("[%d bytes, not valid JSON]", c.total)
+		}
+		return redacted
+	}
+
+	return fmt.Sprintf("[%d bytes omitted]", c.total)
+}
+
+// RedactJSON masks values of sensitive fields in JSON document
+func RedactJSON(body []byte) (string, error) {
+	var doc interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return "", err
+	}
+
+	result, err := json.Marshal(redact(doc))
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, fieldValue := range v {
+			if _, ok := sensitiveFields[strings.ToLower(field)]; ok {
+				v[field] = Redacted
+				continue
+			}
+			v[field] = redact(fieldValue)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+
+	return value
+}
+
+// WithBodyPolicy stores holder of body policy in context, so that
+// route-level middleware could change the policy with SetBodyPolicy
+// after request logging middleware has run.
+func WithBodyPolicy(ctx context.Context, policy *BodyPolicy) context.Context {
+	return context.WithValue(ctx, contextKeyBodyPolicy, policy)
+}
+
+// SetBodyPolicy changes body policy of the current request.
+// It does nothing if context has no body policy holder.
+func SetBodyPolicy(ctx context.Context, policy BodyPolicy) {
+	if holder, ok := ctx.Value(contextKeyBodyPolicy).(*BodyPolicy); ok {
+		*holder = policy
+	}
+}
@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "masks password",
+			body: `{"login": "user", "password": "secret-password"}`,
+			want: `{"login":"user","password":"[REDACTED]"}`,
+		},
+		{
+			name: "masks tokens and codes",
+			body: `{"two_factor_token": "abc.def.ghi", "code": "123456"}`,
+			want: `{"code":"[REDACTED]","two_factor_token":"[REDACTED]"}`,
+		},
+		{
+			name: "matches fields case-insensitively",
+			body: `{"Password": "secret-password"}`,
+			want: `{"Password":"[REDACTED]"}`,
+		},
+		{
+			name: "masks nested fields",
+			body: `{"user": {"password": "secret-password"}, "keys": [{"key": "gm_secret"}]}`,
+			want: `{"keys":[{"key":"[REDACTED]"}],"user":{"password":"[REDACTED]"}}`,
+		},
+		{
+			name: "keeps numbers as is",
+			body: `{"order": "79927398713", "sum": 751.25}`,
+			want: `{"order":"79927398713","sum":751.25}`,
+		},
+		{
+			name:    "returns error for invalid json",
+			body:    `login=user&password=secret-password`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RedactJSON([]byte(tt.body))
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.NotContains(t, got, "secret-password")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBodyCapture_Loggable(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		limit  int
+		policy BodyPolicy
+		want   string
+	}{
+		{
+			name:   "omits body by default",
+			body:   `{"password": "secret-password"}`,
+			limit:  MaxLoggedBody,
+			policy: BodyOmit,
+			want:   "[31 bytes omitted]",
+		},
+		{
+			name:   "redacts body",
+			body:   `{"password": "secret-password"}`,
+			limit:  MaxLoggedBody,
+			policy: BodyRedacted,
+			want:   `{"password":"[REDACTED]"}`,
+		},
+		{
+			name:   "does not log truncated body that has to be redacted",
+			body:   `{"password": "secret-password"}`,
+			limit:  20,
+			policy: BodyRedacted,
+			want:   "[31 bytes, too large to redact]",
+		},
+		{
+			name:   "does not log invalid json body that has to be redacted",
+			body:   `password=secret-password`,
+			limit:  MaxLoggedBody,
+			policy: BodyRedacted,
+			want:   "[24 bytes, not valid JSON]",
+		},
+		{
+			name:   "logs full body",
+			body:   "79927398713",
+			limit:  MaxLoggedBody,
+			policy: BodyFull,
+			want:   "79927398713",
+		},
+		{
+			name:   "truncates full body",
+			body:   "79927398713",
+			limit:  5,
+			policy: BodyFull,
+			want:   "79927...[truncated, 11 bytes total]",
+		},
+		{
+			name:   "returns empty string for empty body",
+			limit:  MaxLoggedBody,
+			policy: BodyFull,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capture := NewBodyCapture(ioutil.NopCloser(strings.NewReader(tt.body)), tt.limit)
+
+			read, err := ioutil.ReadAll(capture)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(read), "body must be passed through unchanged")
+
+			assert.Equal(t, tt.want, capture.Loggable(tt.policy))
+		})
+	}
+}
+
+func TestSetBodyPolicy(t *testing.T) {
+	t.Run("changes policy stored in context", func(t *testing.T) {
+		policy := BodyOmit
+		ctx := WithBodyPolicy(context.Background(), &policy)
+
+		SetBodyPolicy(ctx, BodyRedacted)
+
+		assert.Equal(t, BodyRedacted, policy)
+	})
+
+	t.Run("does nothing without policy in context", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			SetBodyPolicy(context.Background(), BodyFull)
+		})
+	})
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+)
+
+// Format defines how log entries are written
+type Format string
+
+const (
+	// FormatConsole writes human-readable colored entries, handy in a terminal
+	FormatConsole Format = "console"
+	// FormatJSON writes one JSON object per entry, as expected by log shippers
+	FormatJSON Format = "json"
+)
+
+// Config contains logging settings
+type Config struct {
+	// Level is the minimum level of entries to be written: trace, debug, info, warn or error.
+	// It can be changed at runtime with SetLevel.
+	Level string `yaml:"level" toml:"level"`
+	// Format of log entries
+	Format Format `yaml:"format" toml:"format"`
+	// File to write logs to instead of os.Stdout, if not empty
+	File string `yaml:"file" toml:"file"`
+	// FileMaxSize is the size in bytes after which log file is rotated
+	FileMaxSize int64 `yaml:"file_max_size" toml:"file_max_size"`
+	// FileMaxBackups is the number of rotated files to keep
+	FileMaxBackups int `yaml:"file_max_backups" toml:"file_max_backups"`
+}
+
+const (
+	defaultLevel          = zerolog.InfoLevel
+	defaultFormat         = FormatConsole
+	defaultFileMaxSize    = 100 << 20
+	defaultFileMaxBackups = 5
+)
+
+// DefaultConfig returns logging settings used until Configure is called
+func DefaultConfig() Config {
+	return Config{
+		Level:          defaultLevel.String(),
+		Format:         defaultFormat,
+		FileMaxSize:    defaultFileMaxSize,
+		FileMaxBackups: defaultFileMaxBackups,
+	}
+}
+
+func init() {
+	zerolog.SetGlobalLevel(defaultLevel)
+}
+
+// ParseLevel returns zerolog level by its name, rejecting unknown and empty ones
+func ParseLevel(name string) (zerolog.Level, error) {
+	level, err := zerolog.ParseLevel(name)
+	if err != nil || level == zerolog.NoLevel {
+		return zerolog.NoLevel, fmt.Errorf("unknown log level %q", name)
+	}
+
+	return level, nil
+}
+
+// Validate returns error if any of the settings is invalid
+func (c Config) Validate() error {
+	if _, err := ParseLevel(c.Level); err != nil {
+		return err
+	}
+	if c.Format != FormatConsole && c.Format != FormatJSON {
+		return fmt.Errorf("unknown log format %q", c.Format)
+	}
+	if c.FileMaxSize <= 0 {
+		return errors.New("log file max size must be positive")
+	}
+	if c.FileMaxBackups < 0 {
+		return errors.New("log file max backups must not be negative")
+	}
+
+	return nil
+}
+
+// Configure sets level of all loggers and output of loggers created afterwards.
+// Loggers created before keep writing to previous output.
+func Configure(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	level, _ := ParseLevel(cfg.Level)
+
+	if err := setOutput(cfg); err != nil {
+		return err
+	}
+	SetLevel(level)
+
+	return nil
+}
+
+// Level returns current minimum level of log entries
+func Level() zerolog.Level {
+	return zerolog.GlobalLevel()
+}
+
+// SetLevel changes minimum level of log entries for all loggers, including already created ones
+func SetLevel(level zerolog.Level) {
+	zerolog.SetGlobalLevel(level)
+}
@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{
+			name:   "accepts default config",
+			modify: func(c *Config) {},
+		},
+		{
+			name:    "rejects unknown level",
+			modify:  func(c *Config) { c.Level = "loud" },
+			wantErr: true,
+		},
+		{
+			name:    "rejects empty level",
+			modify:  func(c *Config) { c.Level = "" },
+			wantErr: true,
+		},
+		{
+			name:    "rejects unknown format",
+			modify:  func(c *Config) { c.Format = "xml" },
+			wantErr: true,
+		},
+		{
+			name:    "rejects non-positive file size",
+			modify:  func(c *Config) { c.FileMaxSize = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "rejects negative backups",
+			modify:  func(c *Config) { c.FileMaxBackups = -1 },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := DefaultConfig()
+			tt.modify(&c)
+
+			err := c.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	initial := Level()
+	defer func() {
+		require.NoError(t, Configure(DefaultConfig()))
+		SetLevel(initial)
+	}()
+
+	path := filepath.Join(t.TempDir(), "gophermart.log")
+	cfg := DefaultConfig()
+	cfg.Level = "warn"
+	cfg.Format = FormatJSON
+	cfg.File = path
+
+	require.NoError(t, Configure(cfg))
+	assert.Equal(t, zerolog.WarnLevel, Level())
+
+	logger := NewLogger()
+	logger.Info().Msg("filtered out")
+	logger.Warn().Msg("written")
+
+	written, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(written), `"message":"written"`)
+	assert.NotContains(t, string(written), "filtered out")
+}
+
+func TestConfigure_RejectsInvalidConfig(t *testing.T) {
+	initial := Level()
+	defer SetLevel(initial)
+
+	cfg := DefaultConfig()
+	cfg.Level = "loud"
+
+	assert.Error(t, Configure(cfg))
+	assert.Equal(t, initial, Level(), "level is not changed by invalid config")
+}
+
+func TestSetLevel(t *testing.T) {
+	initial := Level()
+	defer SetLevel(initial)
+
+	SetLevel(zerolog.ErrorLevel)
+
+	assert.Equal(t, zerolog.ErrorLevel, Level())
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("debug")
+	require.NoError(t, err)
+	assert.Equal(t, zerolog.DebugLevel, level)
+
+	_, err = ParseLevel("")
+	assert.Error(t, err)
+
+	_, err = ParseLevel("loud")
+	assert.Error(t, err)
+}
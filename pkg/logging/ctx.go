@@ -31,8 +31,7 @@ func CtxLogger(ctx context.Context) (context.Context, zerolog.Logger) {
 	// If there is no correlation ID in the context, set it
 	correlationID, err := CorrelationID(ctx)
 	if err != nil {
-		correlationUUID, _ := uuid.NewUUID()
-		correlationID = correlationUUID.String()
+		correlationID = NewCorrelationID()
 		ctx = SetCorrelationID(ctx, correlationID)
 	}
 
@@ -41,6 +40,21 @@ func CtxLogger(ctx context.Context) (context.Context, zerolog.Logger) {
 	return SetCtxLogger(ctx, logger), logger
 }
 
+// NewCtxLogger stores a new correlation ID and logger with it in the passed context,
+// replacing ones that might be already stored there. It is used to start a unit
+// of work that should be traced on its own, e.g. an accrual job.
+func NewCtxLogger(ctx context.Context) (context.Context, zerolog.Logger) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	correlationID := NewCorrelationID()
+	ctx = SetCorrelationID(ctx, correlationID)
+
+	logger := NewLogger().With().Str(CorrelationIDKey, correlationID).Logger()
+	return SetCtxLogger(ctx, logger), logger
+}
+
 // SetCtxLogger stores logger in the ctx
 func SetCtxLogger(ctx context.Context, logger zerolog.Logger) context.Context {
 	return context.WithValue(ctx, contextKeyLogger, logger)
@@ -61,3 +75,9 @@ func CorrelationID(ctx context.Context) (string, error) {
 func SetCorrelationID(ctx context.Context, id string) context.Context {
 	return context.WithValue(ctx, contextKeyCorrelationID, id)
 }
+
+// NewCorrelationID generates a new random correlation ID
+func NewCorrelationID() string {
+	correlationUUID, _ := uuid.NewUUID()
+	return correlationUUID.String()
+}
@@ -136,3 +136,20 @@ func TestCorrelationID(t *testing.T) {
 		})
 	}
 }
+
+func TestNewCtxLogger(t *testing.T) {
+	t.Run("replaces correlation id and logger stored in context", func(t *testing.T) {
+		parent, parentLogger := CtxLogger(SetCorrelationID(context.Background(), "parent-correlation-id"))
+
+		ctx, logger := NewCtxLogger(parent)
+
+		got, err := CorrelationID(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, "parent-correlation-id", got)
+		assert.NotEmpty(t, got)
+
+		_, stored := CtxLogger(ctx)
+		assert.Equal(t, logger, stored)
+		assert.NotEqual(t, parentLogger, stored)
+	})
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// RequestIDHeader carries correlation ID between services and is echoed in responses
+	RequestIDHeader = "X-Request-ID"
+	// TraceparentHeader is W3C Trace Context header. Its trace ID is used
+	// as correlation ID when request has no RequestIDHeader.
+	TraceparentHeader = "traceparent"
+)
+
+// maxRequestIDLength limits request IDs accepted from clients,
+// so that they could not flood logs through the header
+const maxRequestIDLength = 128
+
+var (
+	requestIDPattern   = regexp.MustCompile(`^[A-Za-z0-9._:/+=-]+$`)
+	traceparentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-[0-9a-f]{16}-[0-9a-f]{2}$`)
+)
+
+// CorrelationIDFromHeaders returns correlation ID passed by the client in RequestIDHeader or,
+// if there is none, trace ID from TraceparentHeader. Values that are malformed are ignored.
+// The second return value is false if no usable ID has been passed.
+func CorrelationIDFromHeaders(requestID string, traceparent string) (string, bool) {
+	requestID = strings.TrimSpace(requestID)
+	if requestID != "" && len(requestID) <= maxRequestIDLength && requestIDPattern.MatchString(requestID) {
+		return requestID, true
+	}
+
+	matches := traceparentPattern.FindStringSubmatch(strings.TrimSpace(traceparent))
+	// All-zero trace ID is invalid according to W3C Trace Context
+	if matches != nil && matches[1] != strings.Repeat("0", 32) {
+		return matches[1], true
+	}
+
+	return "", false
+}
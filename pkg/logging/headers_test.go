@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCorrelationIDFromHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestID   string
+		traceparent string
+		want        string
+		wantOK      bool
+	}{
+		{
+			name:      "uses request id",
+			requestID: "f1a2b3c4-request",
+			want:      "f1a2b3c4-request",
+			wantOK:    true,
+		},
+		{
+			name:        "prefers request id over traceparent",
+			requestID:   "f1a2b3c4-request",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:        "f1a2b3c4-request",
+			wantOK:      true,
+		},
+		{
+			name:        "uses trace id from traceparent",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:        "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantOK:      true,
+		},
+		{
+			name:        "falls back to traceparent if request id is malformed",
+			requestID:   "id with spaces\n",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:        "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantOK:      true,
+		},
+		{
+			name:      "ignores too long request id",
+			requestID: strings.Repeat("a", maxRequestIDLength+1),
+		},
+		{
+			name:        "ignores malformed traceparent",
+			traceparent: "00-not-a-trace-01",
+		},
+		{
+			name:        "ignores all-zero trace id",
+			traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		{
+			name: "returns false without headers",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := CorrelationIDFromHeaders(tt.requestID, tt.traceparent)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -5,4 +5,7 @@ const (
 	ServiceNameKey   = "service"
 	HandlerNameKey   = "handler"
 	CurrentUserKey   = "user_id"
+	APIKeyIDKey      = "api_key_id"
+	RoleKey          = "role"
+	TraceIDKey       = "trace_id"
 )
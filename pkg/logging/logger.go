@@ -1,9 +1,13 @@
 package logging
 
 import (
-	"github.com/rs/zerolog"
+	"fmt"
+	"io"
 	"os"
+	"sync"
 	"time"
+
+	"github.com/rs/zerolog"
 )
 
 // LoggerOption is a function that can be passed to NewLogger
@@ -19,14 +23,61 @@ func WithLogLevel(level zerolog.Level) LoggerOption {
 	}
 }
 
-// NewLogger creates a new zerolog logger that writes to os.Stdout
-// via zerolog.ConsoleWriter and has logging level set to zerolog.TraceLevel.
+// WithOutput returns LoggerOption that makes logger write entries
+// to w in the given format
+func WithOutput(w io.Writer, format Format) LoggerOption {
+	return func(logger zerolog.Logger) zerolog.Logger {
+		if format == FormatConsole {
+			w = zerolog.ConsoleWriter{Out: w, TimeFormat: time.RFC3339, NoColor: w != os.Stdout}
+		}
+		return logger.Output(w)
+	}
+}
+
+var (
+	outputMu sync.RWMutex
+	// output and format are used by loggers created with NewLogger
+	output io.Writer = os.Stdout
+	format           = defaultFormat
+)
+
+// setOutput makes new loggers write to file set in cfg, or to os.Stdout if it is empty,
+// in cfg format. Previously opened log file is closed.
+func setOutput(cfg Config) error {
+	var w io.Writer = os.Stdout
+	if cfg.File != "" {
+		file, err := NewRotatingFile(cfg.File, cfg.FileMaxSize, cfg.FileMaxBackups)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		w = file
+	}
+
+	outputMu.Lock()
+	previous := output
+	output, format = w, cfg.Format
+	outputMu.Unlock()
+
+	if file, ok := previous.(*RotatingFile); ok {
+		return file.Close()
+	}
+
+	return nil
+}
+
+// NewLogger creates a new zerolog logger that writes to output and in format
+// set by Configure, os.Stdout and zerolog.ConsoleWriter by default.
+// Logger has level set to zerolog.TraceLevel, entries are filtered by global level
+// set by Configure, so that it could be changed at runtime with SetLevel.
 // It also adds timestamps to logger entries.
 //
 // It accepts a slice of LoggerOption and applies it to built logger.
 func NewLogger(opts ...LoggerOption) zerolog.Logger {
-	logger := zerolog.New(os.Stdout).
-		Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}).
+	outputMu.RLock()
+	w, f := output, format
+	outputMu.RUnlock()
+
+	logger := WithOutput(w, f)(zerolog.New(os.Stdout)).
 		Level(zerolog.TraceLevel).
 		With().
 		Timestamp().
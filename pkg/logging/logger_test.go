@@ -1,7 +1,11 @@
 package logging
 
 import (
+	"bytes"
+	"encoding/json"
 	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 )
 
@@ -33,3 +37,27 @@ func TestNewLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestWithOutput(t *testing.T) {
+	t.Run("writes json entries", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := NewLogger(WithOutput(&out, FormatJSON))
+
+		logger.Error().Str("key", "value").Msg("message")
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+		assert.Equal(t, "message", entry["message"])
+		assert.Equal(t, "value", entry["key"])
+		assert.Equal(t, "error", entry["level"])
+	})
+
+	t.Run("writes console entries without colors", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := NewLogger(WithOutput(&out, FormatConsole))
+
+		logger.Error().Str("key", "value").Msg("message")
+
+		assert.Contains(t, out.String(), "ERR message key=value")
+	})
+}
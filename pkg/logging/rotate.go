@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is io.Writer that appends to a file and rotates it once it
+// grows beyond maxSize bytes. Rotated files get numeric suffixes, path.1 being
+// the most recent one, and only maxBackups of them are kept.
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingFile opens file at path for appending, creating it if needed
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	rf := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+
+	return n, err
+}
+
+// Close closes underlying file
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	return rf.file.Close()
+}
+
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", rf.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file %s: %w", rf.path, err)
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+
+	return nil
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file %s: %w", rf.path, err)
+	}
+
+	if rf.maxBackups == 0 {
+		if err := os.Remove(rf.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove log file %s: %w", rf.path, err)
+		}
+		return rf.open()
+	}
+
+	// Shift backups: path.(n-1) -> path.n, ..., path -> path.1.
+	// The oldest backup is overwritten.
+	for i := rf.maxBackups - 1; i > 0; i-- {
+		from := fmt.Sprintf("%s.%d", rf.path, i)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", rf.path, i+1)); err != nil {
+			return fmt.Errorf("failed to rotate log file %s: %w", from, err)
+		}
+	}
+
+	if err := os.Rename(rf.path, rf.path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate log file %s: %w", rf.path, err)
+	}
+
+	return rf.open()
+}
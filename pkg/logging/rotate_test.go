@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile_Write(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gophermart.log")
+
+	rf, err := NewRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	defer rf.Close()
+
+	for _, entry := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := rf.Write([]byte(entry))
+		require.NoError(t, err)
+	}
+
+	assertFile(t, path, "fourth\n")
+	assertFile(t, path+".1", "third\n")
+	assertFile(t, path+".2", "second\n")
+	assert.NoFileExists(t, path+".3", "only maxBackups rotated files are kept")
+}
+
+func TestRotatingFile_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gophermart.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0o644))
+
+	rf, err := NewRotatingFile(path, 100, 1)
+	require.NoError(t, err)
+	defer rf.Close()
+
+	_, err = rf.Write([]byte("new\n"))
+	require.NoError(t, err)
+
+	assertFile(t, path, "old\nnew\n")
+}
+
+func TestRotatingFile_NoBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gophermart.log")
+
+	rf, err := NewRotatingFile(path, 5, 0)
+	require.NoError(t, err)
+	defer rf.Close()
+
+	for _, entry := range []string{"first\n", "second\n"} {
+		_, err := rf.Write([]byte(entry))
+		require.NoError(t, err)
+	}
+
+	assertFile(t, path, "second\n")
+	assert.NoFileExists(t, path+".1")
+}
+
+func assertFile(t *testing.T, path string, want string) {
+	t.Helper()
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+}
@@ -0,0 +1,118 @@
+// Package metrics contains Prometheus metrics of gophermart and registry they are exposed from
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gophermart"
+
+// Registry holds all metrics exposed by Handler. It is a registry of its own rather than
+// prometheus.DefaultRegisterer, so that dependencies could not add metrics unnoticed.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts handled requests by method, route pattern and status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of handled HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration observes request handling latency by method, route pattern and status
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of handling HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// HTTPRateLimited counts requests rejected with 429 Too Many Requests by rate limit they have exceeded
+	HTTPRateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rate_limited_total",
+		Help:      "Number of requests rejected for exceeding rate limit.",
+	}, []string{"limit"})
+
+	// AccrualFetchesInFlight is the number of requests to accrual service being made right now
+	AccrualFetchesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "fetches_in_flight",
+		Help:      "Number of requests to accrual service in progress.",
+	})
+
+	// AccrualFetchDuration observes latency of requests to accrual service by result
+	AccrualFetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "fetch_duration_seconds",
+		Help:      "Latency of requests to accrual service.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	// AccrualRateLimited counts responses of accrual service with 429 Too Many Requests status
+	AccrualRateLimited = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "accrual",
+		Name:      "rate_limited_total",
+		Help:      "Number of requests to accrual service rejected with 429 Too Many Requests.",
+	})
+
+	// PointsCredited counts loyalty points credited to users for processed orders
+	PointsCredited = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "balance",
+		Name:      "points_credited_total",
+		Help:      "Loyalty points credited for processed orders.",
+	})
+
+	// PointsWithdrawn counts loyalty points withdrawn by users
+	PointsWithdrawn = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "balance",
+		Name:      "points_withdrawn_total",
+		Help:      "Loyalty points withdrawn by users.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		HTTPRateLimited,
+		AccrualFetchesInFlight,
+		AccrualFetchDuration,
+		AccrualRateLimited,
+		PointsCredited,
+		PointsWithdrawn,
+	)
+}
+
+// Register adds collector to Registry. Collector that has already been registered,
+// e.g. by a component constructed more than once, is not an error.
+func Register(collector prometheus.Collector) error {
+	err := Registry.Register(collector)
+
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		return nil
+	}
+
+	return err
+}
+
+// Handler serves metrics from Registry in Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
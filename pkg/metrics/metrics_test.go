@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	opts := prometheus.GaugeOpts{Name: "test_register_gauge", Help: "Gauge registered twice."}
+
+	require.NoError(t, Register(prometheus.NewGauge(opts)))
+	assert.NoError(t, Register(prometheus.NewGauge(opts)), "registering the same metric again is not an error")
+
+	assert.Error(t, Register(prometheus.NewCounter(prometheus.CounterOpts{Name: "test_register_gauge", Help: "Conflicting metric."})))
+}
+
+func TestHandler(t *testing.T) {
+	PointsWithdrawn.Add(0)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "gophermart_balance_points_withdrawn_total")
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
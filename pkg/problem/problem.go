@@ -0,0 +1,70 @@
+// Package problem renders errors as RFC 7807 problem details,
+// so that every error response of the API has the same shape.
+package problem
+
+import (
+	"encoding/json"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"net/http"
+)
+
+// ContentType is media type of problem details
+const ContentType = "application/problem+json"
+
+// typePrefix is prepended to reason of the error to build type URI of the problem
+const typePrefix = "urn:gophermart:problem:"
+
+// Details is problem details object sent in response body
+type Details struct {
+	// Type identifies the problem type, it is built from Code
+	Type string `json:"type"`
+	// Title is a short summary of the problem, it is the same for all problems with the same status
+	Title string `json:"title"`
+	// Status is HTTP status of the response
+	Status int `json:"status"`
+	// Detail is human-readable explanation of this occurrence of the problem
+	Detail string `json:"detail"`
+	// Instance is path of the request that failed
+	Instance string `json:"instance,omitempty"`
+	// Code is stable identifier of the problem clients can rely on, e.g. "insufficient_funds"
+	Code string `json:"code"`
+	// CorrelationID is ID of the request to be quoted when reporting the problem
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// FromError builds problem details of err occurred while handling r.
+// Only public message and reason of err are exposed, see apperr.
+func FromError(r *http.Request, err error) Details {
+	status := apperr.HTTPStatus(err)
+	code := apperr.Reason(err)
+	correlationID, _ := logging.CorrelationID(r.Context())
+
+	return Details{
+		Type:          typePrefix + code,
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        apperr.PublicMessage(err),
+		Instance:      r.URL.Path,
+		Code:          code,
+		CorrelationID: correlationID,
+	}
+}
+
+// Write responds to r with problem details of err
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	details := FromError(r, err)
+
+	h := w.Header()
+	// Headers set for successful response must not leak into error response
+	h.Del("Content-Length")
+	h.Set("Content-Type", ContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(details.Status)
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(&details); err != nil {
+		_, logger := logging.CtxLogger(r.Context())
+		logger.Error().Err(err).Msg("failed to encode problem details")
+	}
+}
@@ -0,0 +1,68 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	errNotEnough := apperr.New(apperr.CodeInsufficientFunds, "not enough balance").WithReason("insufficient_funds")
+
+	tests := []struct {
+		name string
+		err  error
+		want Details
+	}{
+		{
+			name: "renders error with reason",
+			err:  apperr.Wrap(errNotEnough, errors.New("balance 10 < 20")),
+			want: Details{
+				Type:          "urn:gophermart:problem:insufficient_funds",
+				Title:         "Payment Required",
+				Status:        http.StatusPaymentRequired,
+				Detail:        "not enough balance",
+				Instance:      "/api/user/balance/withdraw",
+				Code:          "insufficient_funds",
+				CorrelationID: "correlation-id",
+			},
+		},
+		{
+			name: "does not leak errors without code",
+			err:  errors.New("pq: connection refused"),
+			want: Details{
+				Type:          "urn:gophermart:problem:internal",
+				Title:         "Internal Server Error",
+				Status:        http.StatusInternalServerError,
+				Detail:        "internal error",
+				Instance:      "/api/user/balance/withdraw",
+				Code:          "internal",
+				CorrelationID: "correlation-id",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", nil)
+			r = r.WithContext(logging.SetCorrelationID(r.Context(), "correlation-id"))
+			w := httptest.NewRecorder()
+			w.Header().Set("Content-Length", "42")
+
+			Write(w, r, tt.err)
+
+			assert.Equal(t, tt.want.Status, w.Code)
+			assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+			assert.Empty(t, w.Header().Get("Content-Length"))
+
+			var got Details
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops buckets that have refilled
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in memory of the process, so limits are enforced
+// by every instance separately and are reset on restart
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	// swept is when buckets have been swept last time, zero until the first Take
+	swept time.Time
+	// now returns current time, it is replaced in tests
+	now func() time.Time
+}
+
+type memoryBucket struct {
+	Bucket
+	// fullAt is when bucket can be dropped, see Bucket.FullAt
+	fullAt time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{}
+		s.buckets[key] = bucket
+	}
+
+	result := bucket.Take(limit, now)
+	bucket.fullAt = bucket.FullAt(limit)
+
+	return result, nil
+}
+
+// sweep drops buckets that have refilled, so that memory is not held by clients
+// that have gone. It is called with s.mu locked.
+func (s *MemoryStore) sweep(now time.Time) {
+	if s.swept.IsZero() {
+		s.swept = now
+	}
+	if now.Sub(s.swept) < sweepInterval {
+		return
+	}
+
+	for key, bucket := range s.buckets {
+		if !bucket.fullAt.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+	s.swept = now
+}
@@ -0,0 +1,119 @@
+// Package ratelimit limits how often clients may make requests using token buckets.
+//
+// Every client has a bucket of its own, identified by key. Bucket holds up to
+// Limit.Requests tokens and refills evenly over Limit.Period, each request takes
+// a token, and requests are rejected while the bucket is empty. Buckets are kept
+// in a Store, which may be shared by several instances of the server.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Limit allows Requests per Period, up to all of them at once
+type Limit struct {
+	// Requests is capacity of the bucket, zero turns the limit off
+	Requests int `yaml:"requests" toml:"requests"`
+	// Period is how long empty bucket takes to refill
+	Period time.Duration `yaml:"period" toml:"period"`
+}
+
+// Enabled reports whether requests are limited at all
+func (l Limit) Enabled() bool {
+	return l.Requests > 0
+}
+
+// Validate returns error if limit cannot be enforced
+func (l Limit) Validate() error {
+	if l.Requests < 0 {
+		return errors.New("requests must not be negative")
+	}
+	if l.Enabled() && l.Period <= 0 {
+		return errors.New("period must be positive")
+	}
+
+	return nil
+}
+
+// refill returns how long a single token takes to refill
+func (l Limit) refill() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// Result describes outcome of taking a token
+type Result struct {
+	// Allowed reports whether there was a token for the request
+	Allowed bool
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+	// RetryAfter is how long until the next token, zero if request is allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps buckets of all keys. Take must be atomic for a key,
+// so that concurrent requests could not take the same token.
+type Store interface {
+	// Take takes a token from the bucket of key, creating full bucket if there is none
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// StoreFunc adapts function to Store
+type StoreFunc func(ctx context.Context, key string, limit Limit) (Result, error)
+
+func (f StoreFunc) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return f(ctx, key, limit)
+}
+
+// Bucket is the state of bucket of a single key. Stores keep buckets
+// and use Take to change them, so that limits work the same with any store.
+type Bucket struct {
+	// Tokens is the number of tokens as of UpdatedAt, including fractions of a token being refilled
+	Tokens float64
+	// UpdatedAt is when the bucket has been taken from last time, zero for a new bucket
+	UpdatedAt time.Time
+}
+
+// Take refills the bucket for the time passed since it was updated
+// and takes a token from it at now, if there is one
+func (b *Bucket) Take(limit Limit, now time.Time) Result {
+	capacity := float64(limit.Requests)
+	refill := limit.refill()
+
+	switch {
+	case b.UpdatedAt.IsZero():
+		b.Tokens = capacity
+		b.UpdatedAt = now
+	case now.After(b.UpdatedAt):
+		// Clocks of instances sharing a store may differ a bit, so time never goes back
+		b.Tokens += float64(now.Sub(b.UpdatedAt)) / float64(refill)
+		if b.Tokens > capacity {
+			b.Tokens = capacity
+		}
+		b.UpdatedAt = now
+	}
+
+	var result Result
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.Tokens) * float64(refill))
+	}
+
+	result.Remaining = int(b.Tokens)
+	result.Reset = b.FullAt(limit).Sub(b.UpdatedAt)
+
+	return result
+}
+
+// FullAt returns when the bucket is full again if no tokens are taken.
+// Bucket that is full is no different from a missing one, so stores can drop it after that.
+func (b *Bucket) FullAt(limit Limit) time.Time {
+	missing := float64(limit.Requests) - b.Tokens
+
+	return b.UpdatedAt.Add(time.Duration(missing * float64(limit.refill())))
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimit_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   Limit
+		wantErr bool
+	}{
+		{
+			name:  "accepts limit",
+			limit: Limit{Requests: 10, Period: time.Minute},
+		},
+		{
+			name:  "accepts turned off limit without period",
+			limit: Limit{},
+		},
+		{
+			name:    "rejects negative requests",
+			limit:   Limit{Requests: -1, Period: time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "rejects limit without period",
+			limit:   Limit{Requests: 10},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limit.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBucket_Take(t *testing.T) {
+	limit := Limit{Requests: 3, Period: 30 * time.Second}
+	start := time.Date(2022, 3, 7, 9, 5, 11, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		bucket Bucket
+		now    time.Time
+		want   Result
+	}{
+		{
+			name: "new bucket is full",
+			now:  start,
+			want: Result{Allowed: true, Remaining: 2, Reset: 10 * time.Second},
+		},
+		{
+			name:   "empty bucket rejects request until token refills",
+			bucket: Bucket{Tokens: 0, UpdatedAt: start},
+			now:    start.Add(4 * time.Second),
+			want:   Result{Allowed: false, Remaining: 0, RetryAfter: 6 * time.Second, Reset: 26 * time.Second},
+		},
+		{
+			name:   "refilled token is taken",
+			bucket: Bucket{Tokens: 0, UpdatedAt: start},
+			now:    start.Add(15 * time.Second),
+			want:   Result{Allowed: true, Remaining: 0, Reset: 25 * time.Second},
+		},
+		{
+			name:   "bucket does not refill over capacity",
+			bucket: Bucket{Tokens: 1, UpdatedAt: start},
+			now:    start.Add(time.Hour),
+			want:   Result{Allowed: true, Remaining: 2, Reset: 10 * time.Second},
+		},
+		{
+			name:   "bucket is not refilled if clock is behind",
+			bucket: Bucket{Tokens: 0.5, UpdatedAt: start},
+			now:    start.Add(-time.Minute),
+			want:   Result{Allowed: false, Remaining: 0, RetryAfter: 5 * time.Second, Reset: 25 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.bucket.Take(limit, tt.now))
+		})
+	}
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	limit := Limit{Requests: 2, Period: time.Minute}
+	now := time.Date(2022, 3, 7, 9, 5, 11, 0, time.UTC)
+
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	take := func(key string) Result {
+		t.Helper()
+		result, err := store.Take(context.Background(), key, limit)
+		require.NoError(t, err)
+		return result
+	}
+
+	assert.True(t, take("user:100").Allowed)
+	assert.True(t, take("user:100").Allowed)
+	assert.False(t, take("user:100").Allowed)
+	assert.True(t, take("user:200").Allowed, "keys do not share buckets")
+
+	now = now.Add(30 * time.Second)
+	assert.True(t, take("user:100").Allowed)
+
+	// Bucket of user:200 is full by now, and it is dropped
+	now = now.Add(sweepInterval)
+	take("user:100")
+	assert.NotContains(t, store.buckets, "user:200")
+	assert.Contains(t, store.buckets, "user:100")
+}
@@ -0,0 +1,143 @@
+// Package tlscert serves TLS certificates that are reloaded once their files change,
+// so that renewed certificates are picked up without restarting the server
+package tlscert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader keeps certificate loaded from a pair of files, see NewReloader
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	// version identifies files cert has been loaded from, so that unchanged files are not loaded again
+	version fileVersion
+}
+
+// fileVersion tells whether certificate or key file has been replaced or rewritten
+type fileVersion struct {
+	certModTime time.Time
+	certSize    int64
+	keyModTime  time.Time
+	keySize     int64
+}
+
+// NewReloader loads PEM encoded certificate and its key from certFile and keyFile.
+// Certificate files may contain intermediate certificates after the leaf one.
+func NewReloader(certFile string, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate returns current certificate, it is meant for tls.Config
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Reload loads certificate again if either of its files has changed and reports whether it has.
+// Current certificate is kept if files cannot be loaded, e.g. while only one of them has been renewed.
+func (r *Reloader) Reload() (bool, error) {
+	version, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && version == r.version
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load certificate from %s and %s: %w", r.certFile, r.keyFile, err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.version = version
+	r.mu.Unlock()
+
+	return true, nil
+}
+
+// Watch checks files for changes every interval in background until ctx is done
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ctx, logger := logging.CtxLogger(ctx)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			reloaded, err := r.Reload()
+			if err != nil {
+				logger.Err(err).Msg("failed to reload TLS certificate, keeping current one")
+				continue
+			}
+			if reloaded {
+				logger.Info().Msgf("reloaded TLS certificate from %s", r.certFile)
+			}
+		}
+	}()
+}
+
+func (r *Reloader) stat() (fileVersion, error) {
+	cert, err := os.Stat(r.certFile)
+	if err != nil {
+		return fileVersion{}, err
+	}
+
+	key, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fileVersion{}, err
+	}
+
+	return fileVersion{
+		certModTime: cert.ModTime(),
+		certSize:    cert.Size(),
+		keyModTime:  key.ModTime(),
+		keySize:     key.Size(),
+	}, nil
+}
+
+// LoadCertPool reads PEM encoded CA certificates from file
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in " + file)
+	}
+
+	return pool, nil
+}
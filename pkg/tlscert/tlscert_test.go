@@ -0,0 +1,146 @@
+package tlscert
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	_, err := NewReloader(certFile, keyFile)
+	require.Error(t, err, "files must exist")
+
+	writeCert(t, certFile, keyFile, "first")
+	r, err := NewReloader(certFile, keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, "first", commonName(t, r))
+
+	reloaded, err := r.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded, "unchanged files are not loaded again")
+
+	writeCert(t, certFile, keyFile, "second")
+	touch(t, certFile, keyFile)
+	reloaded, err = r.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, "second", commonName(t, r))
+
+	// Certificate is renewed before its key is
+	renewed := filepath.Join(dir, "renewed.key")
+	writeCert(t, certFile, renewed, "third")
+	touch(t, certFile)
+	reloaded, err = r.Reload()
+	require.Error(t, err)
+	assert.False(t, reloaded)
+	assert.Equal(t, "second", commonName(t, r), "current certificate is kept")
+
+	require.NoError(t, os.Rename(renewed, keyFile))
+	touch(t, keyFile)
+	reloaded, err = r.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, "third", commonName(t, r))
+}
+
+func TestReloader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	writeCert(t, certFile, keyFile, "first")
+	r, err := NewReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Watch(ctx, 10*time.Millisecond)
+
+	writeCert(t, certFile, keyFile, "second")
+	touch(t, certFile, keyFile)
+
+	assert.Eventually(t, func() bool { return commonName(t, r) == "second" }, time.Second, 10*time.Millisecond)
+}
+
+func TestLoadCertPool(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "ca.crt")
+	keyFile := filepath.Join(dir, "ca.key")
+
+	_, err := LoadCertPool(certFile)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0o600))
+	_, err = LoadCertPool(certFile)
+	require.Error(t, err)
+
+	writeCert(t, certFile, keyFile, "ca")
+	pool, err := LoadCertPool(certFile)
+	require.NoError(t, err)
+	assert.NotNil(t, pool)
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+// writeCert writes self-signed certificate with commonName and its key to files
+func writeCert(t *testing.T, certFile string, keyFile string, commonName string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+// touch moves modification time of files forward, so that rewrites are noticed
+// even if file system keeps modification time with low resolution
+func touch(t *testing.T, files ...string) {
+	t.Helper()
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+
+		modTime := info.ModTime().Add(time.Second)
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+}
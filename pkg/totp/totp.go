@@ -0,0 +1,127 @@
+// Package totp implements time-based one-time passwords as defined in RFC 6238
+// with parameters supported by all common authenticator apps: HMAC-SHA1,
+// 6 digits and 30 seconds period.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period is how long each code is valid for
+	Period = 30 * time.Second
+	// Digits is the length of generated codes
+	Digits = 6
+	// secretSize is the size of generated secrets in bytes, as recommended by RFC 4226
+	secretSize = 20
+	// skew is how many periods before and after the current one are accepted
+	// to tolerate clock drift between server and user's device
+	skew = 1
+)
+
+// encoding is used for secrets, as expected by authenticator apps
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns new random base32-encoded secret
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// Code returns code for base32-encoded secret at time t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hotp(key, counter(t), Digits), nil
+}
+
+// Validate checks that code matches secret at time t, or at adjacent periods
+func Validate(secret string, code string, t time.Time) bool {
+	_, ok := Match(secret, code, t)
+	return ok
+}
+
+// Match checks code like Validate does and returns time step the code has been generated for.
+// Callers must store the step and reject codes of steps up to it, so that a code
+// accepted once cannot be used again while it is still valid, see section 5.2 of RFC 6238.
+func Match(secret string, code string, t time.Time) (uint64, bool) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	current := counter(t)
+	for i := -skew; i <= skew; i++ {
+		step := uint64(int64(current) + int64(i))
+		expected := hotp(key, step, Digits)
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// URI returns otpauth:// URI that authenticator apps can import, usually from a QR code.
+// See https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func URI(issuer string, account string, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	return encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+}
+
+func counter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(Period.Seconds()))
+}
+
+// hotp implements HOTP algorithm from RFC 4226
+func hotp(key []byte, counter uint64, digits int) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, see section 5.3 of RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
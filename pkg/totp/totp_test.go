@@ -0,0 +1,123 @@
+package totp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed used in test vectors of RFC 6238, Appendix B
+const rfcSecret = "12345678901234567890"
+
+func TestHOTP_RFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "94287082"},
+		{unix: 1111111109, want: "07081804"},
+		{unix: 1111111111, want: "14050471"},
+		{unix: 1234567890, want: "89005924"},
+		{unix: 2000000000, want: "69279037"},
+		{unix: 20000000000, want: "65353130"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := hotp([]byte(rfcSecret), counter(time.Unix(tt.unix, 0)), 8)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCode(t *testing.T) {
+	secret := encoding.EncodeToString([]byte(rfcSecret))
+
+	// Last 6 digits of the RFC 6238 vector for T = 1111111109
+	got, err := Code(secret, time.Unix(1111111109, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "081804", got)
+}
+
+func TestValidate(t *testing.T) {
+	secret := encoding.EncodeToString([]byte(rfcSecret))
+	now := time.Unix(1111111109, 0)
+
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		want bool
+	}{
+		{
+			name: "accepts code for current period",
+			code: "081804",
+			at:   now,
+			want: true,
+		},
+		{
+			name: "accepts code from the previous period",
+			code: "081804",
+			at:   now.Add(Period),
+			want: true,
+		},
+		{
+			name: "rejects code from two periods ago",
+			code: "081804",
+			at:   now.Add(2 * Period),
+			want: false,
+		},
+		{
+			name: "rejects wrong code",
+			code: "123456",
+			at:   now,
+			want: false,
+		},
+		{
+			name: "rejects code of wrong length",
+			code: "07081804",
+			at:   now,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Validate(secret, tt.code, tt.at))
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	secret := encoding.EncodeToString([]byte(rfcSecret))
+	now := time.Unix(1111111109, 0)
+
+	step, ok := Match(secret, "081804", now)
+	require.True(t, ok)
+	assert.Equal(t, uint64(1111111109/30), step)
+
+	// Code of the previous period is reported with its own step rather than the current one
+	step, ok = Match(secret, "081804", now.Add(Period))
+	require.True(t, ok)
+	assert.Equal(t, uint64(1111111109/30), step)
+
+	_, ok = Match(secret, "123456", now)
+	assert.False(t, ok)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	key, err := decodeSecret(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, secretSize)
+}
+
+func TestURI(t *testing.T) {
+	got := URI("Gophermart", "john.doe@example.com", "JBSWY3DPEHPK3PXP")
+	assert.Equal(
+		t,
+		"otpauth://totp/Gophermart:john.doe@example.com?algorithm=SHA1&digits=6&issuer=Gophermart&period=30&secret=JBSWY3DPEHPK3PXP",
+		got,
+	)
+}
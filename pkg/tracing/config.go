@@ -0,0 +1,40 @@
+package tracing
+
+import "fmt"
+
+// Exporter defines where spans are sent
+type Exporter string
+
+const (
+	// ExporterNone disables span export. Trace context is still propagated.
+	ExporterNone Exporter = "none"
+	// ExporterOTLP sends spans over OTLP/HTTP. Endpoint and headers are configured
+	// with standard OTEL_EXPORTER_OTLP_* environment variables.
+	ExporterOTLP Exporter = "otlp"
+	// ExporterStdout writes spans as JSON to stdout or to File, for local runs
+	ExporterStdout Exporter = "stdout"
+)
+
+// Config contains tracing settings
+type Config struct {
+	Exporter Exporter `yaml:"exporter" toml:"exporter"`
+	// File to write spans to with ExporterStdout instead of os.Stdout, if not empty
+	File string `yaml:"file" toml:"file"`
+}
+
+const defaultExporter = ExporterNone
+
+// DefaultConfig returns tracing settings used unless they are overridden
+func DefaultConfig() Config {
+	return Config{Exporter: defaultExporter}
+}
+
+// Validate returns error if any of the settings is invalid
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case ExporterNone, ExporterOTLP, ExporterStdout:
+		return nil
+	}
+
+	return fmt.Errorf("unknown tracing exporter %q", c.Exporter)
+}
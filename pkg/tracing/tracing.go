@@ -0,0 +1,179 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is reported as service.name resource attribute of all spans
+const ServiceName = "gophermart"
+
+const instrumentationName = "github.com/soundrussian/go-practicum-diploma"
+
+// Attribute keys used across spans
+const (
+	UserIDKey        = attribute.Key("user.id")
+	OrderNumberKey   = attribute.Key("order.number")
+	OrderStatusKey   = attribute.Key("order.status")
+	BatchSizeKey     = attribute.Key("batch.size")
+	WebhookIDKey     = attribute.Key("webhook.id")
+	DeliveryIDKey    = attribute.Key("webhook.delivery.id")
+	CorrelationIDKey = attribute.Key("correlation_id")
+)
+
+// Init sets up global tracer provider with exporter from cfg and W3C Trace Context
+// propagator. It returns function that flushes remaining spans and releases exporter,
+// it should be called on shutdown.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closer, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without exporter global no-op provider is kept
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterStdout:
+		if cfg.File == "" {
+			exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+			return exporter, nil, err
+		}
+
+		file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open tracing file %s: %w", cfg.File, err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, nil, err
+		}
+		return exporter, file, nil
+	}
+
+	return nil, nil, nil
+}
+
+// Tracer returns tracer of the application
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a new span that is a child of span in ctx, if any
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err, if it is not nil, and ends span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject writes trace context of ctx into outbound request headers
+func Inject(ctx context.Context, header propagation.HeaderCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, header)
+}
+
+// Extract reads trace context from inbound request headers
+func Extract(ctx context.Context, header propagation.HeaderCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, header)
+}
+
+// UserID returns attribute with user ID
+func UserID(userID uint64) attribute.KeyValue {
+	return UserIDKey.Int64(int64(userID))
+}
+
+// OrderNumber returns attribute with order number
+func OrderNumber(number string) attribute.KeyValue {
+	return OrderNumberKey.String(number)
+}
+
+// OrderStatus returns attribute with order status
+func OrderStatus(status string) attribute.KeyValue {
+	return OrderStatusKey.String(status)
+}
+
+// BatchSize returns attribute with number of items processed at once
+func BatchSize(size int) attribute.KeyValue {
+	return BatchSizeKey.Int(size)
+}
+
+// WebhookID returns attribute with webhook ID
+func WebhookID(webhookID uint64) attribute.KeyValue {
+	return WebhookIDKey.Int64(int64(webhookID))
+}
+
+// WebhookDeliveryID returns attribute with ID of webhook delivery
+func WebhookDeliveryID(deliveryID uint64) attribute.KeyValue {
+	return DeliveryIDKey.Int64(int64(deliveryID))
+}
+
+// LinkLogger joins logs and traces of ctx: span gets correlation ID from ctx as attribute,
+// and logger stored in ctx gets trace ID of the span as field.
+func LinkLogger(ctx context.Context, span trace.Span) context.Context {
+	ctx, logger := logging.CtxLogger(ctx)
+
+	if correlationID, err := logging.CorrelationID(ctx); err == nil {
+		span.SetAttributes(CorrelationIDKey.String(correlationID))
+	}
+
+	if spanContext := span.SpanContext(); spanContext.HasTraceID() {
+		logger = logger.With().Str(logging.TraceIDKey, spanContext.TraceID().String()).Logger()
+		ctx = logging.SetCtxLogger(ctx, logger)
+	}
+
+	return ctx
+}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestEnd(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus codes.Code
+	}{
+		{
+			name:       "records error",
+			err:        errors.New("failure"),
+			wantStatus: codes.Error,
+		},
+		{
+			name:       "leaves status unset without error",
+			wantStatus: codes.Unset,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := RecordSpans()
+
+			_, span := Start(context.Background(), "operation", OrderNumber("79927398713"))
+			End(span, tt.err)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "operation", spans[0].Name())
+			assert.Equal(t, tt.wantStatus, spans[0].Status().Code)
+			assert.Contains(t, spans[0].Attributes(), OrderNumberKey.String("79927398713"))
+		})
+	}
+}
+
+func TestLinkLogger(t *testing.T) {
+	recorder := RecordSpans()
+
+	var out bytes.Buffer
+	ctx := logging.SetCorrelationID(context.Background(), "test-correlation-id")
+	ctx = logging.SetCtxLogger(ctx, zerolog.New(&out))
+
+	ctx, span := Start(ctx, "operation")
+	ctx = LinkLogger(ctx, span)
+	span.End()
+
+	_, logger := logging.CtxLogger(ctx)
+	logger.Info().Msg("linked")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("correlation_id", "test-correlation-id"))
+	assert.Contains(t, out.String(), `"trace_id":"`+spans[0].SpanContext().TraceID().String()+`"`)
+}
@@ -1,13 +1,28 @@
 package auth
 
-import "errors"
+import "github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 
 var (
-	ErrInvalidLogin              = errors.New("login must be non-empty string")
-	ErrInvalidPassword           = errors.New("password must be non-empty string")
-	ErrUserAlreadyRegistered     = errors.New("user already registered")
-	ErrRegistrationInternalError = errors.New("failed to register user")
-	ErrUserNotFound              = errors.New("user not found")
-	ErrAuthenticateInternalError = errors.New("internal error while authenticating")
-	ErrPasswordIncorrect         = errors.New("incorrect password")
+	ErrInvalidLogin              = apperr.New(apperr.CodeInvalidArgument, "login must be non-empty string").WithReason("invalid_login")
+	ErrInvalidPassword           = apperr.New(apperr.CodeInvalidArgument, "password must be non-empty string").WithReason("invalid_password")
+	ErrUserAlreadyRegistered     = apperr.New(apperr.CodeConflict, "user already registered").WithReason("login_taken")
+	ErrRegistrationInternalError = apperr.New(apperr.CodeInternal, "failed to register user")
+	ErrUserNotFound              = apperr.New(apperr.CodeUnauthenticated, "user not found").WithPublic("incorrect login or password").WithReason("invalid_credentials")
+	ErrAuthenticateInternalError = apperr.New(apperr.CodeInternal, "internal error while authenticating")
+	ErrPasswordIncorrect         = apperr.New(apperr.CodeUnauthenticated, "incorrect password").WithPublic("incorrect login or password").WithReason("invalid_credentials")
+	ErrInvalidRole               = apperr.New(apperr.CodeInvalidArgument, "unknown role").WithReason("invalid_role")
+	ErrUnknownUser               = apperr.New(apperr.CodeNotFound, "user does not exist").WithReason("user_not_found")
+	ErrAccountInternalError      = apperr.New(apperr.CodeInternal, "internal error while managing account")
+	ErrRoleInternalError         = apperr.New(apperr.CodeInternal, "internal error while setting role")
+	ErrTOTPAlreadyEnabled        = apperr.New(apperr.CodeConflict, "two-factor authentication is already enabled").WithReason("two_factor_already_enabled")
+	ErrTOTPNotEnrolled           = apperr.New(apperr.CodeConflict, "two-factor enrollment has not been started").WithReason("two_factor_not_enrolled")
+	ErrTOTPCodeInvalid           = apperr.New(apperr.CodeUnprocessable, "invalid two-factor code").WithReason("invalid_two_factor_code")
+	ErrSecondFactorInvalid       = apperr.New(apperr.CodeUnauthenticated, "invalid two-factor code or token").WithReason("invalid_second_factor")
+	ErrSecondFactorLocked        = apperr.New(apperr.CodeTooManyRequests, "too many invalid two-factor codes").WithPublic("too many invalid two-factor codes, log in again later").WithReason("second_factor_locked")
+	ErrTOTPInternalError         = apperr.New(apperr.CodeInternal, "internal error while managing two-factor authentication")
+	ErrInvalidAPIKeyName         = apperr.New(apperr.CodeInvalidArgument, "api key name must be non-empty string").WithReason("invalid_api_key_name")
+	ErrInvalidAPIKeyScope        = apperr.New(apperr.CodeInvalidArgument, "api key scopes must be non-empty list of known scopes").WithReason("invalid_api_key_scope")
+	ErrAPIKeyNotFound            = apperr.New(apperr.CodeNotFound, "api key not found").WithReason("api_key_not_found")
+	ErrAPIKeyInvalid             = apperr.New(apperr.CodeUnauthenticated, "api key is invalid or revoked").WithReason("invalid_api_key")
+	ErrAPIKeyInternalError       = apperr.New(apperr.CodeInternal, "internal error while managing api keys")
 )
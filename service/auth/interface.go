@@ -9,4 +9,15 @@ type Auth interface {
 	Register(ctx context.Context, login string, password string) (*model.User, error)
 	Authenticate(ctx context.Context, login string, password string) (*model.User, error)
 	AuthToken(ctx context.Context, user *model.User) (*string, error)
+	User(ctx context.Context, userID uint64) (*model.User, error)
+	DeleteAccount(ctx context.Context, userID uint64) error
+	SetRole(ctx context.Context, userID uint64, role model.Role) error
+	EnrollTOTP(ctx context.Context, userID uint64) (secret string, uri string, err error)
+	ConfirmTOTP(ctx context.Context, userID uint64, code string) ([]string, error)
+	SecondFactorToken(ctx context.Context, user *model.User) (*string, error)
+	VerifySecondFactor(ctx context.Context, token string, code string) (*model.User, error)
+	CreateAPIKey(ctx context.Context, userID uint64, name string, scopes []model.APIKeyScope) (*model.APIKey, string, error)
+	APIKeys(ctx context.Context, userID uint64) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID uint64, keyID uint64) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error)
 }
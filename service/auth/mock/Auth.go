@@ -14,6 +14,29 @@ type Auth struct {
 	mock.Mock
 }
 
+// APIKeys provides a mock function with given fields: ctx, userID
+func (_m *Auth) APIKeys(ctx context.Context, userID uint64) ([]model.APIKey, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []model.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) []model.APIKey); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AuthToken provides a mock function with given fields: ctx, user
 func (_m *Auth) AuthToken(ctx context.Context, user *model.User) (*string, error) {
 	ret := _m.Called(ctx, user)
@@ -60,6 +83,124 @@ func (_m *Auth) Authenticate(ctx context.Context, login string, password string)
 	return r0, r1
 }
 
+// AuthenticateAPIKey provides a mock function with given fields: ctx, key
+func (_m *Auth) AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *model.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.APIKey); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConfirmTOTP provides a mock function with given fields: ctx, userID, code
+func (_m *Auth) ConfirmTOTP(ctx context.Context, userID uint64, code string) ([]string, error) {
+	ret := _m.Called(ctx, userID, code)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) []string); ok {
+		r0 = rf(ctx, userID, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, string) error); ok {
+		r1 = rf(ctx, userID, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAPIKey provides a mock function with given fields: ctx, userID, name, scopes
+func (_m *Auth) CreateAPIKey(ctx context.Context, userID uint64, name string, scopes []model.APIKeyScope) (*model.APIKey, string, error) {
+	ret := _m.Called(ctx, userID, name, scopes)
+
+	var r0 *model.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string, []model.APIKeyScope) *model.APIKey); ok {
+		r0 = rf(ctx, userID, name, scopes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.APIKey)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, string, []model.APIKeyScope) string); ok {
+		r1 = rf(ctx, userID, name, scopes)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uint64, string, []model.APIKeyScope) error); ok {
+		r2 = rf(ctx, userID, name, scopes)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// DeleteAccount provides a mock function with given fields: ctx, userID
+func (_m *Auth) DeleteAccount(ctx context.Context, userID uint64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnrollTOTP provides a mock function with given fields: ctx, userID
+func (_m *Auth) EnrollTOTP(ctx context.Context, userID uint64) (string, string, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) string); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) string); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, uint64) error); ok {
+		r2 = rf(ctx, userID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // Register provides a mock function with given fields: ctx, login, password
 func (_m *Auth) Register(ctx context.Context, login string, password string) (*model.User, error) {
 	ret := _m.Called(ctx, login, password)
@@ -82,3 +223,100 @@ func (_m *Auth) Register(ctx context.Context, login string, password string) (*m
 
 	return r0, r1
 }
+
+// RevokeAPIKey provides a mock function with given fields: ctx, userID, keyID
+func (_m *Auth) RevokeAPIKey(ctx context.Context, userID uint64, keyID uint64) error {
+	ret := _m.Called(ctx, userID, keyID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) error); ok {
+		r0 = rf(ctx, userID, keyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SecondFactorToken provides a mock function with given fields: ctx, user
+func (_m *Auth) SecondFactorToken(ctx context.Context, user *model.User) (*string, error) {
+	ret := _m.Called(ctx, user)
+
+	var r0 *string
+	if rf, ok := ret.Get(0).(func(context.Context, *model.User) *string); ok {
+		r0 = rf(ctx, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.User) error); ok {
+		r1 = rf(ctx, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetRole provides a mock function with given fields: ctx, userID, role
+func (_m *Auth) SetRole(ctx context.Context, userID uint64, role model.Role) error {
+	ret := _m.Called(ctx, userID, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, model.Role) error); ok {
+		r0 = rf(ctx, userID, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// User provides a mock function with given fields: ctx, userID
+func (_m *Auth) User(ctx context.Context, userID uint64) (*model.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *model.User
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *model.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifySecondFactor provides a mock function with given fields: ctx, token, code
+func (_m *Auth) VerifySecondFactor(ctx context.Context, token string, code string) (*model.User, error) {
+	ret := _m.Called(ctx, token, code)
+
+	var r0 *model.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.User); ok {
+		r0 = rf(ctx, token, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, token, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
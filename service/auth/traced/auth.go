@@ -0,0 +1,135 @@
+// Package traced provides auth.Auth that records spans for calls of wrapped service
+package traced
+
+import (
+	"context"
+
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"github.com/soundrussian/go-practicum-diploma/service/auth"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ auth.Auth = (*Auth)(nil)
+
+// Auth records a span for every call of wrapped auth service.
+// Credentials, tokens and codes are never added to spans.
+type Auth struct {
+	next auth.Auth
+}
+
+// New wraps auth service with tracing
+func New(next auth.Auth) *Auth {
+	return &Auth{next: next}
+}
+
+func (a *Auth) Register(ctx context.Context, login string, password string) (user *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Register")
+	defer func() { endWithUser(span, user, err) }()
+
+	return a.next.Register(ctx, login, password)
+}
+
+func (a *Auth) Authenticate(ctx context.Context, login string, password string) (user *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Authenticate")
+	defer func() { endWithUser(span, user, err) }()
+
+	return a.next.Authenticate(ctx, login, password)
+}
+
+func (a *Auth) AuthToken(ctx context.Context, user *model.User) (token *string, err error) {
+	ctx, span := tracing.Start(ctx, "auth.AuthToken")
+	defer func() { endWithUser(span, user, err) }()
+
+	return a.next.AuthToken(ctx, user)
+}
+
+func (a *Auth) User(ctx context.Context, userID uint64) (user *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "auth.User", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return a.next.User(ctx, userID)
+}
+
+func (a *Auth) DeleteAccount(ctx context.Context, userID uint64) (err error) {
+	ctx, span := tracing.Start(ctx, "auth.DeleteAccount", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return a.next.DeleteAccount(ctx, userID)
+}
+
+func (a *Auth) SetRole(ctx context.Context, userID uint64, role model.Role) (err error) {
+	ctx, span := tracing.Start(ctx, "auth.SetRole", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return a.next.SetRole(ctx, userID, role)
+}
+
+func (a *Auth) EnrollTOTP(ctx context.Context, userID uint64) (secret string, uri string, err error) {
+	ctx, span := tracing.Start(ctx, "auth.EnrollTOTP", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return a.next.EnrollTOTP(ctx, userID)
+}
+
+func (a *Auth) ConfirmTOTP(ctx context.Context, userID uint64, code string) (recoveryCodes []string, err error) {
+	ctx, span := tracing.Start(ctx, "auth.ConfirmTOTP", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return a.next.ConfirmTOTP(ctx, userID, code)
+}
+
+func (a *Auth) SecondFactorToken(ctx context.Context, user *model.User) (token *string, err error) {
+	ctx, span := tracing.Start(ctx, "auth.SecondFactorToken")
+	defer func() { endWithUser(span, user, err) }()
+
+	return a.next.SecondFactorToken(ctx, user)
+}
+
+func (a *Auth) VerifySecondFactor(ctx context.Context, token string, code string) (user *model.User, err error) {
+	ctx, span := tracing.Start(ctx, "auth.VerifySecondFactor")
+	defer func() { endWithUser(span, user, err) }()
+
+	return a.next.VerifySecondFactor(ctx, token, code)
+}
+
+func (a *Auth) CreateAPIKey(ctx context.Context, userID uint64, name string, scopes []model.APIKeyScope) (key *model.APIKey, plain string, err error) {
+	ctx, span := tracing.Start(ctx, "auth.CreateAPIKey", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return a.next.CreateAPIKey(ctx, userID, name, scopes)
+}
+
+func (a *Auth) APIKeys(ctx context.Context, userID uint64) (keys []model.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "auth.APIKeys", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return a.next.APIKeys(ctx, userID)
+}
+
+func (a *Auth) RevokeAPIKey(ctx context.Context, userID uint64, keyID uint64) (err error) {
+	ctx, span := tracing.Start(ctx, "auth.RevokeAPIKey", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return a.next.RevokeAPIKey(ctx, userID, keyID)
+}
+
+func (a *Auth) AuthenticateAPIKey(ctx context.Context, key string) (apiKey *model.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "auth.AuthenticateAPIKey")
+	defer func() {
+		if apiKey != nil {
+			span.SetAttributes(tracing.UserID(apiKey.UserID))
+		}
+		tracing.End(span, err)
+	}()
+
+	return a.next.AuthenticateAPIKey(ctx, key)
+}
+
+// endWithUser adds ID of user, if known, to span and ends it
+func endWithUser(span trace.Span, user *model.User, err error) {
+	if user != nil {
+		span.SetAttributes(tracing.UserID(user.ID))
+	}
+	tracing.End(span, err)
+}
@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	auth2 "github.com/soundrussian/go-practicum-diploma/service/auth"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"strings"
+)
+
+const (
+	// apiKeyPrefix makes keys recognizable, e.g. by secret scanners
+	apiKeyPrefix = "gm_"
+	// apiKeyBytes is the number of random bytes in a key
+	apiKeyBytes = 32
+	// apiKeyVisiblePrefix is the number of key characters stored in plaintext
+	// so that users can tell their keys apart
+	apiKeyVisiblePrefix = 8
+)
+
+// CreateAPIKey generates new API key for the user. Plaintext key is returned only once,
+// only its SHA-256 hash is stored. Keys are random and long enough, so there is
+// no need in slow hashing functions like the one used for passwords.
+func (a *Auth) CreateAPIKey(ctx context.Context, userID uint64, name string, scopes []model.APIKeyScope) (*model.APIKey, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, "", auth2.ErrInvalidAPIKeyName
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", auth2.ErrInvalidAPIKeyScope
+	}
+
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, "", auth2.ErrInvalidAPIKeyScope
+		}
+	}
+
+	random := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(random); err != nil {
+		a.Log(ctx).Err(err).Msg("failed to generate api key")
+		return nil, "", apperr.Wrap(auth2.ErrAPIKeyInternalError, err)
+	}
+	plaintext := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	key, err := a.storage.CreateAPIKey(ctx, model.APIKey{
+		UserID: userID,
+		Name:   name,
+		Prefix: plaintext[:len(apiKeyPrefix)+apiKeyVisiblePrefix],
+		Hash:   hashAPIKey(plaintext),
+		Scopes: scopes,
+	})
+	if err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to store api key for user %d", userID)
+		return nil, "", apperr.Wrap(auth2.ErrAPIKeyInternalError, err)
+	}
+
+	return key, plaintext, nil
+}
+
+func (a *Auth) APIKeys(ctx context.Context, userID uint64) ([]model.APIKey, error) {
+	keys, err := a.storage.UserAPIKeys(ctx, userID)
+	if err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to fetch api keys of user %d", userID)
+		return nil, apperr.Wrap(auth2.ErrAPIKeyInternalError, err)
+	}
+
+	return keys, nil
+}
+
+func (a *Auth) RevokeAPIKey(ctx context.Context, userID uint64, keyID uint64) error {
+	if err := a.storage.RevokeAPIKey(ctx, userID, keyID); err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to revoke api key %d of user %d", keyID, userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return auth2.ErrAPIKeyNotFound
+		}
+		return apperr.Wrap(auth2.ErrAPIKeyInternalError, err)
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey returns active API key matching passed plaintext key
+func (a *Auth) AuthenticateAPIKey(ctx context.Context, key string) (*model.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, auth2.ErrAPIKeyInvalid
+	}
+
+	apiKey, err := a.storage.FetchAPIKey(ctx, hashAPIKey(key))
+	if err != nil {
+		a.Log(ctx).Err(err).Msg("failed to fetch api key")
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, auth2.ErrAPIKeyInvalid
+		}
+		return nil, apperr.Wrap(auth2.ErrAPIKeyInternalError, err)
+	}
+
+	return apiKey, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,119 @@
+package v1
+
+import (
+	"context"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/service/auth"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"github.com/soundrussian/go-practicum-diploma/storage/mock"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestAuth_CreateAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyName string
+		scopes  []model.APIKeyScope
+		wantErr error
+	}{
+		{
+			name:    "returns ErrInvalidAPIKeyName if name is blank",
+			keyName: " ",
+			scopes:  []model.APIKeyScope{model.ScopeOrdersWrite},
+			wantErr: auth.ErrInvalidAPIKeyName,
+		},
+		{
+			name:    "returns ErrInvalidAPIKeyScope if no scopes are given",
+			keyName: "POS",
+			wantErr: auth.ErrInvalidAPIKeyScope,
+		},
+		{
+			name:    "returns ErrInvalidAPIKeyScope if scope is unknown",
+			keyName: "POS",
+			scopes:  []model.APIKeyScope{"everything"},
+			wantErr: auth.ErrInvalidAPIKeyScope,
+		},
+		{
+			name:    "stores hash of the key and returns plaintext key",
+			keyName: "POS",
+			scopes:  []model.APIKeyScope{model.ScopeOrdersWrite},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mock.Storage)
+			store.On("CreateAPIKey", testifyMock.Anything, testifyMock.Anything).Return(
+				func(_ context.Context, key model.APIKey) *model.APIKey { return &key },
+				nil,
+			)
+			a := &Auth{storage: store}
+
+			key, plaintext, err := a.CreateAPIKey(context.Background(), 100, tt.keyName, tt.scopes)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				store.AssertNotCalled(t, "CreateAPIKey", testifyMock.Anything, testifyMock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(plaintext, apiKeyPrefix))
+			assert.True(t, strings.HasPrefix(plaintext, key.Prefix))
+			assert.Equal(t, hashAPIKey(plaintext), key.Hash)
+			assert.NotContains(t, key.Hash, plaintext)
+			assert.Equal(t, uint64(100), key.UserID)
+		})
+	}
+}
+
+func TestAuth_AuthenticateAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		storage func() *mock.Storage
+		wantErr error
+	}{
+		{
+			name:    "returns ErrAPIKeyInvalid if key has no known prefix",
+			key:     "not-a-key",
+			storage: func() *mock.Storage { return new(mock.Storage) },
+			wantErr: auth.ErrAPIKeyInvalid,
+		},
+		{
+			name: "returns ErrAPIKeyInvalid if key is not found or revoked",
+			key:  "gm_revoked",
+			storage: func() *mock.Storage {
+				m := new(mock.Storage)
+				m.On("FetchAPIKey", testifyMock.Anything, hashAPIKey("gm_revoked")).Return(nil, storage.ErrNotFound)
+				return m
+			},
+			wantErr: auth.ErrAPIKeyInvalid,
+		},
+		{
+			name: "returns key looked up by hash",
+			key:  "gm_active",
+			storage: func() *mock.Storage {
+				m := new(mock.Storage)
+				m.On("FetchAPIKey", testifyMock.Anything, hashAPIKey("gm_active")).Return(&model.APIKey{ID: 1, UserID: 100}, nil)
+				return m
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Auth{storage: tt.storage()}
+
+			key, err := a.AuthenticateAPIKey(context.Background(), tt.key)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, uint64(100), key.UserID)
+		})
+	}
+}
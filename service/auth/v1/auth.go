@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/go-chi/jwtauth/v5"
 	"github.com/rs/zerolog"
 	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
 	auth2 "github.com/soundrussian/go-practicum-diploma/service/auth"
 	"github.com/soundrussian/go-practicum-diploma/storage"
@@ -15,37 +17,55 @@ import (
 var _ auth2.Auth = (*Auth)(nil)
 
 type Auth struct {
-	storage storage.Storage
+	storage   storage.Storage
+	secretKey string
+	tokenAuth *jwtauth.JWTAuth
 }
 
-func New(storage storage.Storage) (*Auth, error) {
-	if secretKey == nil {
-		return nil, errors.New("secretKey has not been initialized")
-	}
-
-	if TokenAuth == nil {
-		return nil, errors.New("TokenAuth has not been initialized")
+func New(cfg Config, storage storage.Storage) (*Auth, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid auth config: %w", err)
 	}
 
 	if storage == nil {
 		return nil, errors.New("nil storage passed to Auth service constructor")
 	}
 
-	auth := &Auth{storage: storage}
+	auth := &Auth{
+		storage:   storage,
+		secretKey: cfg.SecretKey,
+		tokenAuth: NewTokenAuth(cfg.SecretKey),
+	}
 
 	return auth, nil
 }
 
+// TokenAuth returns verifier of tokens issued by AuthToken
+func (a *Auth) TokenAuth() *jwtauth.JWTAuth {
+	return a.tokenAuth
+}
+
 func (a *Auth) Register(ctx context.Context, login string, password string) (*model.User, error) {
+	if login == "" {
+		return nil, auth2.ErrInvalidLogin
+	}
+
+	if password == "" {
+		return nil, auth2.ErrInvalidPassword
+	}
+
 	hashedPassword, err := a.hashedPassword(ctx, password)
 	if err != nil {
-		return nil, auth2.ErrRegistrationInternalError
+		return nil, apperr.Wrap(auth2.ErrRegistrationInternalError, err)
 	}
 
 	user, err := a.storage.CreateUser(ctx, login, hashedPassword)
 	if err != nil {
 		a.Log(ctx).Err(err).Msg("failed to create user")
-		return nil, fmt.Errorf("failed to store user: %w", err)
+		if errors.Is(err, storage.ErrLoginAlreadyExists) {
+			return nil, auth2.ErrUserAlreadyRegistered
+		}
+		return nil, apperr.Wrap(auth2.ErrRegistrationInternalError, err)
 	}
 
 	return user, nil
@@ -58,19 +78,27 @@ func (a *Auth) Authenticate(ctx context.Context, login string, password string)
 		if errors.Is(err, storage.ErrNotFound) {
 			return nil, auth2.ErrUserNotFound
 		}
-		return nil, auth2.ErrAuthenticateInternalError
+		return nil, apperr.Wrap(auth2.ErrAuthenticateInternalError, err)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password+*secretKey)); err != nil {
-		a.Log(ctx).Err(err).Msgf("provided password %s is not valid for user %s", password, login)
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password+a.secretKey)); err != nil {
+		a.Log(ctx).Err(err).Msgf("provided password is not valid for user %s", login)
 		return nil, auth2.ErrPasswordIncorrect
 	}
 
 	return user, nil
 }
 
+// AuthToken returns signed JWT token with user ID and role in claims.
+// Role claim only tells clients the role user had at login: API checks
+// the role stored for the user on each request.
 func (a *Auth) AuthToken(ctx context.Context, user *model.User) (*string, error) {
-	_, tokenString, err := TokenAuth.Encode(map[string]interface{}{"user_id": user.ID})
+	role := user.Role
+	if role == "" {
+		role = model.RoleUser
+	}
+
+	_, tokenString, err := a.tokenAuth.Encode(map[string]interface{}{"user_id": user.ID, "role": string(role)})
 	if err != nil {
 		return nil, err
 	}
@@ -78,6 +106,54 @@ func (a *Auth) AuthToken(ctx context.Context, user *model.User) (*string, error)
 	return &tokenString, nil
 }
 
+// User returns active user with given ID. Deleted users are reported as unknown.
+func (a *Auth) User(ctx context.Context, userID uint64) (*model.User, error) {
+	user, err := a.storage.FetchUserByID(ctx, userID)
+	if err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to fetch user %d", userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, auth2.ErrUnknownUser
+		}
+		return nil, apperr.Wrap(auth2.ErrAccountInternalError, err)
+	}
+
+	if user.DeletedAt != nil {
+		return nil, auth2.ErrUnknownUser
+	}
+
+	return user, nil
+}
+
+// DeleteAccount anonymizes user and revokes their API keys. Auth tokens
+// of deleted users are rejected by ActiveUser middleware.
+func (a *Auth) DeleteAccount(ctx context.Context, userID uint64) error {
+	if err := a.storage.DeleteUser(ctx, userID); err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to delete user %d", userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return auth2.ErrUnknownUser
+		}
+		return apperr.Wrap(auth2.ErrAccountInternalError, err)
+	}
+
+	return nil
+}
+
+func (a *Auth) SetRole(ctx context.Context, userID uint64, role model.Role) error {
+	if !role.Valid() {
+		return auth2.ErrInvalidRole
+	}
+
+	if err := a.storage.UpdateUserRole(ctx, userID, role); err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to set role %s for user %d", role, userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return auth2.ErrUnknownUser
+		}
+		return apperr.Wrap(auth2.ErrRoleInternalError, err)
+	}
+
+	return nil
+}
+
 // Log returns logger with service field set.
 func (a Auth) Log(ctx context.Context) *zerolog.Logger {
 	_, logger := logging.CtxLogger(ctx)
@@ -87,7 +163,7 @@ func (a Auth) Log(ctx context.Context) *zerolog.Logger {
 }
 
 func (a Auth) hashedPassword(ctx context.Context, password string) (string, error) {
-	result, err := bcrypt.GenerateFromPassword([]byte(password+*secretKey), bcrypt.DefaultCost)
+	result, err := bcrypt.GenerateFromPassword([]byte(password+a.secretKey), bcrypt.DefaultCost)
 	if err != nil {
 		a.Log(ctx).Err(err).Msg("failed to generate hashed password")
 		return "", err
@@ -1,16 +1,28 @@
 package v1
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/service/auth"
 	"github.com/soundrussian/go-practicum-diploma/storage"
 	"github.com/soundrussian/go-practicum-diploma/storage/mock"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
 	type args struct {
-		secretKey *string
-		storage   storage.Storage
+		cfg     Config
+		storage storage.Storage
 	}
 	tests := []struct {
 		name    string
@@ -19,7 +31,7 @@ func TestNew(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name: "returns error if secretKey is nil",
+			name: "returns error if secret key is empty",
 			args: args{
 				storage: new(mock.Storage),
 			},
@@ -29,8 +41,8 @@ func TestNew(t *testing.T) {
 		{
 			name: "returns error if storage is nil",
 			args: args{
-				secretKey: secretKey,
-				storage:   nil,
+				cfg:     DefaultConfig(),
+				storage: nil,
 			},
 			want:    nil,
 			wantErr: true,
@@ -38,17 +50,16 @@ func TestNew(t *testing.T) {
 		{
 			name: "returns initialized auth service with passed storage",
 			args: args{
-				secretKey: secretKey,
-				storage:   new(mock.Storage),
+				cfg:     DefaultConfig(),
+				storage: new(mock.Storage),
 			},
-			want:    &Auth{storage: new(mock.Storage)},
+			want:    newTestAuth(new(mock.Storage)),
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			secretKey = tt.args.secretKey
-			got, err := New(tt.args.storage)
+			got, err := New(tt.args.cfg, tt.args.storage)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -59,3 +70,247 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestAuth_Register(t *testing.T) {
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		storage  storage.Storage
+		wantErr  error
+	}{
+		{
+			name:     "returns ErrInvalidLogin if login is empty",
+			login:    "",
+			password: "topsecret",
+			storage:  new(mock.Storage),
+			wantErr:  auth.ErrInvalidLogin,
+		},
+		{
+			name:     "returns ErrInvalidPassword if password is empty",
+			login:    "john.doe@example.com",
+			password: "",
+			storage:  new(mock.Storage),
+			wantErr:  auth.ErrInvalidPassword,
+		},
+		{
+			name:     "returns ErrUserAlreadyRegistered if storage reports duplicate login",
+			login:    "john.doe@example.com",
+			password: "topsecret",
+			storage:  createUserStorage(nil, storage.ErrLoginAlreadyExists),
+			wantErr:  auth.ErrUserAlreadyRegistered,
+		},
+		{
+			name:     "returns ErrRegistrationInternalError if storage fails",
+			login:    "john.doe@example.com",
+			password: "topsecret",
+			storage:  createUserStorage(nil, errors.New("mock error")),
+			wantErr:  auth.ErrRegistrationInternalError,
+		},
+		{
+			name:     "returns no error if user is stored",
+			login:    "john.doe@example.com",
+			password: "topsecret",
+			storage:  createUserStorage(&model.User{ID: 100, Login: "john.doe@example.com"}, nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(DefaultConfig(), tt.storage)
+			require.NoError(t, err)
+
+			_, err = a.Register(context.Background(), tt.login, tt.password)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// newTestAuth returns auth service with default config and passed storage
+func newTestAuth(store storage.Storage) *Auth {
+	return &Auth{storage: store, secretKey: defaultSecretKey, tokenAuth: NewTokenAuth(defaultSecretKey)}
+}
+
+func createUserStorage(user *model.User, err error) *mock.Storage {
+	m := new(mock.Storage)
+	m.On("CreateUser", testifyMock.Anything, testifyMock.Anything, testifyMock.Anything).Return(user, err)
+	return m
+}
+
+func TestAuth_Authenticate_DoesNotLogPassword(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("correct-password"+defaultSecretKey), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	store := new(mock.Storage)
+	store.On("FetchUser", testifyMock.Anything, "user").Return(&model.User{ID: 100, Login: "user", Password: string(hashed)}, nil)
+	a := newTestAuth(store)
+
+	var out bytes.Buffer
+	ctx := logging.SetCtxLogger(context.Background(), zerolog.New(&out))
+
+	_, err = a.Authenticate(ctx, "user", "wrong-secret-password")
+	require.ErrorIs(t, err, auth.ErrPasswordIncorrect)
+
+	assert.Contains(t, out.String(), "provided password is not valid")
+	assert.NotContains(t, out.String(), "wrong-secret-password")
+}
+
+func TestAuth_AuthToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *model.User
+		wantRole string
+	}{
+		{
+			name:     "stores role of the user in claims",
+			user:     &model.User{ID: 100, Role: model.RoleAdmin},
+			wantRole: "admin",
+		},
+		{
+			name:     "stores user role if user has no role set",
+			user:     &model.User{ID: 100},
+			wantRole: "user",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAuth(nil)
+
+			token, err := a.AuthToken(context.Background(), tt.user)
+			require.NoError(t, err)
+
+			decoded, err := a.TokenAuth().Decode(*token)
+			require.NoError(t, err)
+
+			role, ok := decoded.Get("role")
+			require.True(t, ok)
+			assert.Equal(t, tt.wantRole, role)
+		})
+	}
+}
+
+func TestAuth_SetRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    model.Role
+		storage storage.Storage
+		wantErr error
+	}{
+		{
+			name:    "returns ErrInvalidRole if role is unknown",
+			role:    "superuser",
+			storage: new(mock.Storage),
+			wantErr: auth.ErrInvalidRole,
+		},
+		{
+			name:    "returns ErrUnknownUser if user does not exist",
+			role:    model.RoleSupport,
+			storage: updateRoleStorage(storage.ErrNotFound),
+			wantErr: auth.ErrUnknownUser,
+		},
+		{
+			name:    "returns no error if role is updated",
+			role:    model.RoleSupport,
+			storage: updateRoleStorage(nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Auth{storage: tt.storage}
+
+			err := a.SetRole(context.Background(), 100, tt.role)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func updateRoleStorage(err error) *mock.Storage {
+	m := new(mock.Storage)
+	m.On("UpdateUserRole", testifyMock.Anything, uint64(100), testifyMock.Anything).Return(err)
+	return m
+}
+
+func TestAuth_User(t *testing.T) {
+	deletedAt := time.Now()
+
+	tests := []struct {
+		name    string
+		user    *model.User
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "returns ErrUnknownUser if user does not exist",
+			err:     storage.ErrNotFound,
+			wantErr: auth.ErrUnknownUser,
+		},
+		{
+			name:    "returns ErrUnknownUser if user has deleted the account",
+			user:    &model.User{ID: 100, DeletedAt: &deletedAt},
+			wantErr: auth.ErrUnknownUser,
+		},
+		{
+			name: "returns active user",
+			user: &model.User{ID: 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mock.Storage)
+			m.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(tt.user, tt.err)
+			a := &Auth{storage: m}
+
+			got, err := a.User(context.Background(), 100)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.user, got)
+		})
+	}
+}
+
+func TestAuth_DeleteAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "returns ErrUnknownUser if user does not exist or is already deleted",
+			err:     storage.ErrNotFound,
+			wantErr: auth.ErrUnknownUser,
+		},
+		{
+			name:    "returns ErrAccountInternalError if storage fails",
+			err:     errors.New("mock error"),
+			wantErr: auth.ErrAccountInternalError,
+		},
+		{
+			name: "returns no error if user is deleted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mock.Storage)
+			m.On("DeleteUser", testifyMock.Anything, uint64(100)).Return(tt.err)
+			a := &Auth{storage: m}
+
+			err := a.DeleteAccount(context.Background(), 100)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,41 +1,42 @@
 package v1
 
 import (
+	"errors"
 	"github.com/go-chi/jwtauth/v5"
-	"os"
 )
 
-var secretKey *string
-var TokenAuth *jwtauth.JWTAuth
+// Config contains settings of auth service
+type Config struct {
+	// SecretKey signs auth tokens and is mixed into password hashes
+	SecretKey string `yaml:"secret_key" toml:"secret_key"`
+}
 
 const defaultSecretKey = "veGmwk4gcgKKRgQwMnw4t*_s"
 
-const secretKeyEnvKey = "SECRET_KEY"
-
-func init() {
-	// Config from env is read by a separate function so that we could call it once
-	// again in tests, after setting env variable inside the test
-	readConfig()
+// DefaultConfig returns auth settings used unless they are overridden
+func DefaultConfig() Config {
+	return Config{SecretKey: defaultSecretKey}
 }
 
-// readConfig initializes config if it is not yet set,
-// then reads env variable from databaseConnectionEnvKey
-// if the variable is set and config has just been initialized
-func readConfig() {
-	// If databaseConnection has not been set yet, initialize it
-	if secretKey == nil {
-		defaultKey := defaultSecretKey
-		secretKey = &defaultKey
+// Validate returns error if any of the settings is invalid
+func (c Config) Validate() error {
+	if c.SecretKey == "" {
+		return errors.New("secret key is empty")
 	}
 
-	// Here we check for two things:
-	// 1. There is secretKeyEnvKey environment variable
-	// 2. secretKey equals defaultSecretKey, which means
-	// that it has just been initialized and was not overwritten previously
-	// by flag.Parse(). This is required for tests.
-	if key, ok := os.LookupEnv(secretKeyEnvKey); ok && *secretKey == defaultSecretKey {
-		secretKey = &key
+	return nil
+}
+
+// NewTokenAuth returns JWT encoder and verifier of tokens signed with secretKey
+func NewTokenAuth(secretKey string) *jwtauth.JWTAuth {
+	return jwtauth.New("HS256", []byte(secretKey), nil)
+}
+
+// Masked returns copy of config with secret key hidden, so that it could be printed
+func (c Config) Masked() Config {
+	if c.SecretKey != "" {
+		c.SecretKey = "******"
 	}
 
-	TokenAuth = jwtauth.New("HS256", []byte(*secretKey), nil)
+	return c
 }
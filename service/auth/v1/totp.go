@@ -0,0 +1,252 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/totp"
+	auth2 "github.com/soundrussian/go-practicum-diploma/service/auth"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"strings"
+	"time"
+)
+
+const (
+	// totpIssuer is shown in authenticator apps next to user's login
+	totpIssuer = "Gophermart"
+	// secondFactorTTL is how long user has to enter the code after password has been accepted
+	secondFactorTTL = 5 * time.Minute
+	// maxSecondFactorAttempts is how many codes may be tried before user is locked out of second factor
+	maxSecondFactorAttempts = 5
+	// secondFactorLockout is how long user is locked out of second factor after too many attempts
+	secondFactorLockout = 15 * time.Minute
+	// recoveryCodesCount is how many recovery codes are issued when 2FA is enabled
+	recoveryCodesCount = 10
+	// recoveryCodeBytes is the number of random bytes in each recovery code
+	recoveryCodeBytes = 5
+)
+
+// EnrollTOTP generates new TOTP secret for the user. Secret is not checked
+// on login until user confirms it with a code from authenticator app.
+func (a *Auth) EnrollTOTP(ctx context.Context, userID uint64) (string, string, error) {
+	user, err := a.storage.FetchUserByID(ctx, userID)
+	if err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to fetch user %d for totp enrollment", userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", "", auth2.ErrUnknownUser
+		}
+		return "", "", apperr.Wrap(auth2.ErrTOTPInternalError, err)
+	}
+
+	if user.TOTPEnabled {
+		return "", "", auth2.ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		a.Log(ctx).Err(err).Msg("failed to generate totp secret")
+		return "", "", apperr.Wrap(auth2.ErrTOTPInternalError, err)
+	}
+
+	if err := a.storage.SetTOTPSecret(ctx, userID, secret); err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to store totp secret for user %d", userID)
+		return "", "", apperr.Wrap(auth2.ErrTOTPInternalError, err)
+	}
+
+	return secret, totp.URI(totpIssuer, user.Login, secret), nil
+}
+
+// ConfirmTOTP enables 2FA if code matches the secret generated by EnrollTOTP.
+// It returns recovery codes that let user log in without authenticator app.
+// Recovery codes are returned only once, only their hashes are stored.
+func (a *Auth) ConfirmTOTP(ctx context.Context, userID uint64, code string) ([]string, error) {
+	user, err := a.storage.FetchUserByID(ctx, userID)
+	if err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to fetch user %d for totp confirmation", userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, auth2.ErrUnknownUser
+		}
+		return nil, apperr.Wrap(auth2.ErrTOTPInternalError, err)
+	}
+
+	if user.TOTPEnabled {
+		return nil, auth2.ErrTOTPAlreadyEnabled
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, auth2.ErrTOTPNotEnrolled
+	}
+
+	step, ok := totp.Match(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, auth2.ErrTOTPCodeInvalid
+	}
+
+	// Code confirming 2FA must not be usable to log in
+	if err := a.storage.UseTOTPStep(ctx, userID, step); err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to use totp code of user %d", userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, auth2.ErrTOTPCodeInvalid
+		}
+		return nil, apperr.Wrap(auth2.ErrTOTPInternalError, err)
+	}
+
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		recoveryCode, err := generateRecoveryCode()
+		if err != nil {
+			a.Log(ctx).Err(err).Msg("failed to generate recovery code")
+			return nil, apperr.Wrap(auth2.ErrTOTPInternalError, err)
+		}
+		codes = append(codes, recoveryCode)
+		hashes = append(hashes, hashRecoveryCode(recoveryCode))
+	}
+
+	if err := a.storage.EnableTOTP(ctx, userID, hashes); err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to enable totp for user %d", userID)
+		return nil, apperr.Wrap(auth2.ErrTOTPInternalError, err)
+	}
+
+	return codes, nil
+}
+
+// SecondFactorToken returns short-lived token proving that user has entered correct password.
+// The token is exchanged for auth token by VerifySecondFactor and is not accepted
+// by CurrentUser middleware.
+func (a *Auth) SecondFactorToken(ctx context.Context, user *model.User) (*string, error) {
+	claims := map[string]interface{}{"user_id": user.ID, "2fa": true}
+	jwtauth.SetIssuedNow(claims)
+	jwtauth.SetExpiryIn(claims, secondFactorTTL)
+
+	_, tokenString, err := a.tokenAuth.Encode(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenString, nil
+}
+
+// VerifySecondFactor checks token issued by SecondFactorToken and code,
+// which is either TOTP code or one of unused recovery codes. Each TOTP code
+// is accepted once: codes of time steps up to the last accepted one are rejected.
+// After maxSecondFactorAttempts codes have been tried without success, user is locked out
+// for secondFactorLockout and tokens issued before the lockout are rejected.
+func (a *Auth) VerifySecondFactor(ctx context.Context, token string, code string) (*model.User, error) {
+	userID, issuedAt, err := a.secondFactorUserID(token)
+	if err != nil {
+		a.Log(ctx).Err(err).Msg("invalid second factor token")
+		return nil, apperr.Wrap(auth2.ErrSecondFactorInvalid, err)
+	}
+
+	user, err := a.storage.FetchUserByID(ctx, userID)
+	if err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to fetch user %d for second factor", userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, auth2.ErrSecondFactorInvalid
+		}
+		return nil, apperr.Wrap(auth2.ErrAuthenticateInternalError, err)
+	}
+
+	if !user.TOTPEnabled {
+		return nil, auth2.ErrSecondFactorInvalid
+	}
+
+	if user.SecondFactorLockedAt != nil && !issuedAt.After(*user.SecondFactorLockedAt) {
+		a.Log(ctx).Error().Msgf("second factor token of user %d has been issued before lockout", userID)
+		return nil, auth2.ErrSecondFactorInvalid
+	}
+
+	if err := a.storage.TakeSecondFactorAttempt(ctx, userID, maxSecondFactorAttempts, time.Now().Add(-secondFactorLockout)); err != nil {
+		a.Log(ctx).Err(err).Msgf("user %d is locked out of second factor", userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, auth2.ErrSecondFactorLocked
+		}
+		return nil, apperr.Wrap(auth2.ErrAuthenticateInternalError, err)
+	}
+
+	if step, ok := totp.Match(user.TOTPSecret, code, time.Now()); ok {
+		if err := a.storage.UseTOTPStep(ctx, userID, step); err != nil {
+			a.Log(ctx).Err(err).Msgf("totp code of user %d has already been used", userID)
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil, auth2.ErrSecondFactorInvalid
+			}
+			return nil, apperr.Wrap(auth2.ErrAuthenticateInternalError, err)
+		}
+		return a.resetSecondFactorAttempts(ctx, user)
+	}
+
+	if err := a.storage.UseRecoveryCode(ctx, userID, hashRecoveryCode(code)); err != nil {
+		a.Log(ctx).Err(err).Msgf("second factor code is not valid for user %d", userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, auth2.ErrSecondFactorInvalid
+		}
+		return nil, apperr.Wrap(auth2.ErrAuthenticateInternalError, err)
+	}
+
+	a.Log(ctx).Info().Msgf("user %d logged in with recovery code", userID)
+
+	return a.resetSecondFactorAttempts(ctx, user)
+}
+
+// resetSecondFactorAttempts gives user full number of attempts again after accepted code
+func (a *Auth) resetSecondFactorAttempts(ctx context.Context, user *model.User) (*model.User, error) {
+	if err := a.storage.ResetSecondFactorAttempts(ctx, user.ID); err != nil {
+		a.Log(ctx).Err(err).Msgf("failed to reset second factor attempts of user %d", user.ID)
+		return nil, apperr.Wrap(auth2.ErrAuthenticateInternalError, err)
+	}
+
+	return user, nil
+}
+
+// secondFactorUserID returns user and issue time of second factor token
+func (a *Auth) secondFactorUserID(tokenString string) (uint64, time.Time, error) {
+	token, err := a.tokenAuth.Decode(tokenString)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	if err := jwt.Validate(token); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	// Regular auth tokens have no expiration, so they must not be accepted here
+	if pending, ok := token.PrivateClaims()["2fa"].(bool); !ok || !pending {
+		return 0, time.Time{}, errors.New("not a second factor token")
+	}
+
+	userID, ok := token.PrivateClaims()["user_id"].(float64)
+	if !ok {
+		return 0, time.Time{}, errors.New("no user_id in second factor token")
+	}
+
+	return uint64(userID), token.IssuedAt(), nil
+}
+
+// generateRecoveryCode returns random code formatted as xxxx-xxxx
+func generateRecoveryCode() (string, error) {
+	random := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(random))
+
+	return code[:4] + "-" + code[4:], nil
+}
+
+// hashRecoveryCode normalizes code so that users can enter it without dash
+// or in upper case, and hashes it
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,302 @@
+package v1
+
+import (
+	"context"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/totp"
+	"github.com/soundrussian/go-practicum-diploma/service/auth"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"github.com/soundrussian/go-practicum-diploma/storage/mock"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestAuth_EnrollTOTP(t *testing.T) {
+	t.Run("stores new secret and returns otpauth uri", func(t *testing.T) {
+		store := new(mock.Storage)
+		store.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(&model.User{ID: 100, Login: "john"}, nil)
+		store.On("SetTOTPSecret", testifyMock.Anything, uint64(100), testifyMock.Anything).Return(nil)
+		a := &Auth{storage: store}
+
+		secret, uri, err := a.EnrollTOTP(context.Background(), 100)
+		require.NoError(t, err)
+
+		store.AssertCalled(t, "SetTOTPSecret", testifyMock.Anything, uint64(100), secret)
+		assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Gophermart:john?"))
+		assert.Contains(t, uri, "secret="+secret)
+	})
+
+	t.Run("returns ErrTOTPAlreadyEnabled if 2FA is enabled", func(t *testing.T) {
+		store := new(mock.Storage)
+		store.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(&model.User{ID: 100, TOTPEnabled: true}, nil)
+		a := &Auth{storage: store}
+
+		_, _, err := a.EnrollTOTP(context.Background(), 100)
+		assert.ErrorIs(t, err, auth.ErrTOTPAlreadyEnabled)
+	})
+}
+
+func TestAuth_ConfirmTOTP(t *testing.T) {
+	validCode, err := totp.Code(testTOTPSecret, time.Now())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		user    *model.User
+		code    string
+		wantErr error
+	}{
+		{
+			name:    "returns ErrTOTPNotEnrolled if user has no secret",
+			user:    &model.User{ID: 100},
+			code:    validCode,
+			wantErr: auth.ErrTOTPNotEnrolled,
+		},
+		{
+			name:    "returns ErrTOTPCodeInvalid if code does not match",
+			user:    &model.User{ID: 100, TOTPSecret: testTOTPSecret},
+			code:    "000000",
+			wantErr: auth.ErrTOTPCodeInvalid,
+		},
+		{
+			name: "enables 2FA and returns recovery codes",
+			user: &model.User{ID: 100, TOTPSecret: testTOTPSecret},
+			code: validCode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mock.Storage)
+			store.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(tt.user, nil)
+			store.On("EnableTOTP", testifyMock.Anything, uint64(100), testifyMock.Anything).Return(nil)
+			store.On("UseTOTPStep", testifyMock.Anything, uint64(100), testifyMock.Anything).Return(nil)
+			a := &Auth{storage: store}
+
+			codes, err := a.ConfirmTOTP(context.Background(), 100, tt.code)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				store.AssertNotCalled(t, "EnableTOTP", testifyMock.Anything, testifyMock.Anything, testifyMock.Anything)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, codes, recoveryCodesCount)
+
+			step, _ := totp.Match(testTOTPSecret, tt.code, time.Now())
+			store.AssertCalled(t, "UseTOTPStep", testifyMock.Anything, uint64(100), step)
+
+			hashes := make([]string, 0, len(codes))
+			for _, code := range codes {
+				hashes = append(hashes, hashRecoveryCode(code))
+			}
+			store.AssertCalled(t, "EnableTOTP", testifyMock.Anything, uint64(100), hashes)
+		})
+	}
+}
+
+func TestAuth_VerifySecondFactor(t *testing.T) {
+	a := newTestAuth(nil)
+	user := &model.User{ID: 100, TOTPSecret: testTOTPSecret, TOTPEnabled: true}
+
+	secondFactorToken, err := a.SecondFactorToken(context.Background(), user)
+	require.NoError(t, err)
+
+	authToken, err := a.AuthToken(context.Background(), user)
+	require.NoError(t, err)
+
+	validCode, err := totp.Code(testTOTPSecret, time.Now())
+	require.NoError(t, err)
+	validStep, _ := totp.Match(testTOTPSecret, validCode, time.Now())
+
+	tests := []struct {
+		name    string
+		token   string
+		code    string
+		storage func() *mock.Storage
+		wantErr error
+	}{
+		{
+			name:    "returns ErrSecondFactorInvalid for regular auth token",
+			token:   *authToken,
+			code:    validCode,
+			storage: func() *mock.Storage { return new(mock.Storage) },
+			wantErr: auth.ErrSecondFactorInvalid,
+		},
+		{
+			name:  "returns user if totp code is valid",
+			token: *secondFactorToken,
+			code:  validCode,
+			storage: func() *mock.Storage {
+				m := new(mock.Storage)
+				m.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(user, nil)
+				m.On("TakeSecondFactorAttempt", testifyMock.Anything, uint64(100), maxSecondFactorAttempts, testifyMock.Anything).Return(nil)
+				m.On("ResetSecondFactorAttempts", testifyMock.Anything, uint64(100)).Return(nil)
+				m.On("UseTOTPStep", testifyMock.Anything, uint64(100), validStep).Return(nil)
+				return m
+			},
+		},
+		{
+			name:  "returns ErrSecondFactorInvalid if totp code has already been used",
+			token: *secondFactorToken,
+			code:  validCode,
+			storage: func() *mock.Storage {
+				m := new(mock.Storage)
+				m.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(user, nil)
+				m.On("TakeSecondFactorAttempt", testifyMock.Anything, uint64(100), maxSecondFactorAttempts, testifyMock.Anything).Return(nil)
+				m.On("ResetSecondFactorAttempts", testifyMock.Anything, uint64(100)).Return(nil)
+				m.On("UseTOTPStep", testifyMock.Anything, uint64(100), validStep).Return(storage.ErrNotFound)
+				return m
+			},
+			wantErr: auth.ErrSecondFactorInvalid,
+		},
+		{
+			name:  "returns user if unused recovery code is given",
+			token: *secondFactorToken,
+			code:  "ABCD-EFGH",
+			storage: func() *mock.Storage {
+				m := new(mock.Storage)
+				m.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(user, nil)
+				m.On("TakeSecondFactorAttempt", testifyMock.Anything, uint64(100), maxSecondFactorAttempts, testifyMock.Anything).Return(nil)
+				m.On("ResetSecondFactorAttempts", testifyMock.Anything, uint64(100)).Return(nil)
+				m.On("UseRecoveryCode", testifyMock.Anything, uint64(100), hashRecoveryCode("abcdefgh")).Return(nil)
+				return m
+			},
+		},
+		{
+			name:  "returns ErrSecondFactorInvalid if code is neither totp nor unused recovery code",
+			token: *secondFactorToken,
+			code:  "000000",
+			storage: func() *mock.Storage {
+				m := new(mock.Storage)
+				m.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(user, nil)
+				m.On("TakeSecondFactorAttempt", testifyMock.Anything, uint64(100), maxSecondFactorAttempts, testifyMock.Anything).Return(nil)
+				m.On("ResetSecondFactorAttempts", testifyMock.Anything, uint64(100)).Return(nil)
+				m.On("UseRecoveryCode", testifyMock.Anything, uint64(100), testifyMock.Anything).Return(storage.ErrNotFound)
+				return m
+			},
+			wantErr: auth.ErrSecondFactorInvalid,
+		},
+		{
+			name:  "returns ErrSecondFactorLocked if user is locked out",
+			token: *secondFactorToken,
+			code:  validCode,
+			storage: func() *mock.Storage {
+				m := new(mock.Storage)
+				m.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(user, nil)
+				m.On("TakeSecondFactorAttempt", testifyMock.Anything, uint64(100), maxSecondFactorAttempts, testifyMock.Anything).Return(storage.ErrNotFound)
+				return m
+			},
+			wantErr: auth.ErrSecondFactorLocked,
+		},
+		{
+			name:  "returns ErrSecondFactorInvalid if token has been issued before lockout",
+			token: *secondFactorToken,
+			code:  validCode,
+			storage: func() *mock.Storage {
+				lockedAt := time.Now().Add(time.Second)
+				m := new(mock.Storage)
+				m.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(&model.User{ID: 100, TOTPSecret: testTOTPSecret, TOTPEnabled: true, SecondFactorLockedAt: &lockedAt}, nil)
+				return m
+			},
+			wantErr: auth.ErrSecondFactorInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAuth(tt.storage())
+
+			got, err := a.VerifySecondFactor(context.Background(), tt.token, tt.code)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, uint64(100), got.ID)
+		})
+	}
+}
+
+func TestAuth_VerifySecondFactor_RejectsReplayedCode(t *testing.T) {
+	user := &model.User{ID: 100, TOTPSecret: testTOTPSecret, TOTPEnabled: true}
+
+	// Storage keeps the last accepted step as database does
+	var lastStep uint64
+	store := new(mock.Storage)
+	store.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(user, nil)
+	store.On("UseTOTPStep", testifyMock.Anything, uint64(100), testifyMock.Anything).Return(func(_ context.Context, _ uint64, step uint64) error {
+		if step <= lastStep {
+			return storage.ErrNotFound
+		}
+		lastStep = step
+		return nil
+	})
+	store.On("UseRecoveryCode", testifyMock.Anything, uint64(100), testifyMock.Anything).Return(storage.ErrNotFound)
+	store.On("TakeSecondFactorAttempt", testifyMock.Anything, uint64(100), maxSecondFactorAttempts, testifyMock.Anything).Return(nil)
+	store.On("ResetSecondFactorAttempts", testifyMock.Anything, uint64(100)).Return(nil)
+	a := newTestAuth(store)
+
+	secondFactorToken, err := a.SecondFactorToken(context.Background(), user)
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := totp.Code(testTOTPSecret, now)
+	require.NoError(t, err)
+	previousCode, err := totp.Code(testTOTPSecret, now.Add(-totp.Period))
+	require.NoError(t, err)
+
+	_, err = a.VerifySecondFactor(context.Background(), *secondFactorToken, code)
+	require.NoError(t, err)
+
+	_, err = a.VerifySecondFactor(context.Background(), *secondFactorToken, code)
+	assert.ErrorIs(t, err, auth.ErrSecondFactorInvalid, "code is accepted once")
+
+	_, err = a.VerifySecondFactor(context.Background(), *secondFactorToken, previousCode)
+	assert.ErrorIs(t, err, auth.ErrSecondFactorInvalid, "codes of earlier steps are rejected once a later one is accepted")
+}
+
+func TestAuth_VerifySecondFactor_LocksOutAfterTooManyAttempts(t *testing.T) {
+	user := &model.User{ID: 100, TOTPSecret: testTOTPSecret, TOTPEnabled: true}
+
+	// Storage counts attempts and locks user out as database does
+	var attempts int
+	store := new(mock.Storage)
+	store.On("FetchUserByID", testifyMock.Anything, uint64(100)).Return(func(context.Context, uint64) *model.User {
+		locked := *user
+		return &locked
+	}, nil)
+	store.On("TakeSecondFactorAttempt", testifyMock.Anything, uint64(100), maxSecondFactorAttempts, testifyMock.Anything).Return(func(context.Context, uint64, int, time.Time) error {
+		if attempts >= maxSecondFactorAttempts {
+			return storage.ErrNotFound
+		}
+		attempts++
+		if attempts == maxSecondFactorAttempts {
+			lockedAt := time.Now()
+			user.SecondFactorLockedAt = &lockedAt
+		}
+		return nil
+	})
+	store.On("UseRecoveryCode", testifyMock.Anything, uint64(100), testifyMock.Anything).Return(storage.ErrNotFound)
+	a := newTestAuth(store)
+
+	secondFactorToken, err := a.SecondFactorToken(context.Background(), user)
+	require.NoError(t, err)
+
+	for i := 0; i < maxSecondFactorAttempts; i++ {
+		_, err = a.VerifySecondFactor(context.Background(), *secondFactorToken, "000000")
+		assert.ErrorIs(t, err, auth.ErrSecondFactorInvalid)
+	}
+
+	code, err := totp.Code(testTOTPSecret, time.Now())
+	require.NoError(t, err)
+
+	_, err = a.VerifySecondFactor(context.Background(), *secondFactorToken, code)
+	assert.ErrorIs(t, err, auth.ErrSecondFactorInvalid, "token is invalidated once attempts are exhausted")
+	store.AssertNumberOfCalls(t, "TakeSecondFactorAttempt", maxSecondFactorAttempts)
+}
@@ -1,10 +1,10 @@
 package balance
 
-import "errors"
+import "github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 
 var (
-	ErrNotEnoughBalance = errors.New("not enough balance")
-	ErrInvalidSum       = errors.New("withdrawal sum must be greater than zero")
-	ErrInvalidOrder     = errors.New("invalid order number")
-	ErrInternalError    = errors.New("internal error")
+	ErrNotEnoughBalance = apperr.New(apperr.CodeInsufficientFunds, "not enough balance").WithReason("insufficient_funds")
+	ErrInvalidSum       = apperr.New(apperr.CodeUnprocessable, "withdrawal sum must be greater than zero").WithReason("invalid_withdrawal_sum")
+	ErrInvalidOrder     = apperr.New(apperr.CodeUnprocessable, "invalid order number").WithReason("invalid_order_number")
+	ErrInternalError    = apperr.New(apperr.CodeInternal, "internal error")
 )
@@ -9,4 +9,5 @@ type Balance interface {
 	UserBalance(ctx context.Context, userID uint64) (*model.UserBalance, error)
 	Withdraw(ctx context.Context, userID uint64, withdrawal model.Withdrawal) error
 	Withdrawals(ctx context.Context, userID uint64) ([]model.Withdrawal, error)
+	Transactions(ctx context.Context, userID uint64) ([]model.Transaction, error)
 }
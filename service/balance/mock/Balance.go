@@ -14,6 +14,29 @@ type Balance struct {
 	mock.Mock
 }
 
+// Transactions provides a mock function with given fields: ctx, userID
+func (_m *Balance) Transactions(ctx context.Context, userID uint64) ([]model.Transaction, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []model.Transaction
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) []model.Transaction); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Transaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UserBalance provides a mock function with given fields: ctx, userID
 func (_m *Balance) UserBalance(ctx context.Context, userID uint64) (*model.UserBalance, error) {
 	ret := _m.Called(ctx, userID)
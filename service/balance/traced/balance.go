@@ -0,0 +1,50 @@
+// Package traced provides balance.Balance that records spans for calls of wrapped service
+package traced
+
+import (
+	"context"
+
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"github.com/soundrussian/go-practicum-diploma/service/balance"
+)
+
+var _ balance.Balance = (*Balance)(nil)
+
+// Balance records a span for every call of wrapped balance service
+type Balance struct {
+	next balance.Balance
+}
+
+// New wraps balance service with tracing
+func New(next balance.Balance) *Balance {
+	return &Balance{next: next}
+}
+
+func (b *Balance) UserBalance(ctx context.Context, userID uint64) (result *model.UserBalance, err error) {
+	ctx, span := tracing.Start(ctx, "balance.UserBalance", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return b.next.UserBalance(ctx, userID)
+}
+
+func (b *Balance) Withdraw(ctx context.Context, userID uint64, withdrawal model.Withdrawal) (err error) {
+	ctx, span := tracing.Start(ctx, "balance.Withdraw", tracing.UserID(userID), tracing.OrderNumber(withdrawal.Order))
+	defer func() { tracing.End(span, err) }()
+
+	return b.next.Withdraw(ctx, userID, withdrawal)
+}
+
+func (b *Balance) Withdrawals(ctx context.Context, userID uint64) (result []model.Withdrawal, err error) {
+	ctx, span := tracing.Start(ctx, "balance.Withdrawals", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return b.next.Withdrawals(ctx, userID)
+}
+
+func (b *Balance) Transactions(ctx context.Context, userID uint64) (result []model.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "balance.Transactions", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return b.next.Transactions(ctx, userID)
+}
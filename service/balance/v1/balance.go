@@ -7,7 +7,10 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/shopspring/decimal"
 	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/events"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	"github.com/soundrussian/go-practicum-diploma/pkg/metrics"
 	"github.com/soundrussian/go-practicum-diploma/service/balance"
 	"github.com/soundrussian/go-practicum-diploma/storage"
 	"github.com/theplant/luhn"
@@ -18,14 +21,19 @@ var _ balance.Balance = (*Balance)(nil)
 
 type Balance struct {
 	storage storage.Storage
+	// events receives balance updates made by the service
+	events events.Publisher
 }
 
-func New(storage storage.Storage) (*Balance, error) {
+func New(storage storage.Storage, publisher events.Publisher) (*Balance, error) {
 	if storage == nil {
 		return nil, errors.New("nil storage passed to Balance service constructor")
 	}
+	if publisher == nil {
+		return nil, errors.New("nil event publisher passed to Balance service constructor")
+	}
 
-	auth := &Balance{storage: storage}
+	auth := &Balance{storage: storage, events: publisher}
 
 	return auth, nil
 }
@@ -61,12 +69,27 @@ func (b *Balance) Withdraw(ctx context.Context, userID uint64, withdrawal model.
 		if errors.Is(err, storage.ErrNotEnoughBalance) {
 			return balance.ErrNotEnoughBalance
 		}
-		return balance.ErrInternalError
+		return apperr.Wrap(balance.ErrInternalError, err)
 	}
 
+	metrics.PointsWithdrawn.Add(withdrawal.Sum.InexactFloat64())
+	b.publishBalance(ctx, userID)
+
 	return nil
 }
 
+// publishBalance publishes current balance of the user. Withdrawal has been made
+// by then, so failure to read the balance is only logged.
+func (b *Balance) publishBalance(ctx context.Context, userID uint64) {
+	userBalance, err := b.storage.UserBalance(ctx, userID)
+	if err != nil {
+		b.Log(ctx).Err(err).Msgf("failed to fetch balance of user %d to publish", userID)
+		return
+	}
+
+	b.events.Publish(userID, events.TypeBalance, *userBalance)
+}
+
 func (b *Balance) Withdrawals(ctx context.Context, userID uint64) ([]model.Withdrawal, error) {
 	withdrawals, err := b.storage.UserWithdrawals(ctx, userID)
 	if err != nil {
@@ -76,6 +99,17 @@ func (b *Balance) Withdrawals(ctx context.Context, userID uint64) ([]model.Withd
 	return withdrawals, nil
 }
 
+// Transactions returns all ledger entries of the user, both accruals and withdrawals
+func (b *Balance) Transactions(ctx context.Context, userID uint64) ([]model.Transaction, error) {
+	transactions, err := b.storage.UserTransactions(ctx, userID)
+	if err != nil {
+		b.Log(ctx).Err(err).Msgf("failed to fetch transactions of user %d", userID)
+		return nil, apperr.Wrap(balance.ErrInternalError, err)
+	}
+
+	return transactions, nil
+}
+
 // Log returns logger with service field set.
 func (b *Balance) Log(ctx context.Context) *zerolog.Logger {
 	_, logger := logging.CtxLogger(ctx)
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/shopspring/decimal"
 	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/events"
 	"github.com/soundrussian/go-practicum-diploma/service/balance"
 	"github.com/soundrussian/go-practicum-diploma/storage"
 	storageMock "github.com/soundrussian/go-practicum-diploma/storage/mock"
@@ -15,8 +16,11 @@ import (
 )
 
 func TestNew(t *testing.T) {
+	bus := events.NewBus(0)
+
 	type args struct {
-		storage storage.Storage
+		storage   storage.Storage
+		publisher events.Publisher
 	}
 	tests := []struct {
 		name    string
@@ -26,22 +30,29 @@ func TestNew(t *testing.T) {
 	}{
 		{
 			name:    "returns error if passed storage is nil",
-			args:    args{},
+			args:    args{publisher: bus},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "returns error if passed publisher is nil",
+			args:    args{storage: new(storageMock.Storage)},
 			want:    nil,
 			wantErr: true,
 		},
 		{
 			name: "returns initialized service with storage set",
 			args: args{
-				storage: new(storageMock.Storage),
+				storage:   new(storageMock.Storage),
+				publisher: bus,
 			},
-			want:    &Balance{storage: new(storageMock.Storage)},
+			want:    &Balance{storage: new(storageMock.Storage), events: bus},
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := New(tt.args.storage)
+			got, err := New(tt.args.storage, tt.args.publisher)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -138,6 +149,8 @@ func TestBalance_Withdraw(t *testing.T) {
 		fields  fields
 		args    args
 		wantErr error
+		// wantEvent is balance published on success
+		wantEvent *model.UserBalance
 	}{
 		{
 			name: "returns error if sum is less than zero",
@@ -217,7 +230,7 @@ func TestBalance_Withdraw(t *testing.T) {
 			wantErr: balance.ErrNotEnoughBalance,
 		},
 		{
-			name: "does not return error if storage reported success",
+			name: "publishes new balance if storage reported success",
 			fields: fields{
 				storage: successfulWithdrawal(),
 			},
@@ -225,12 +238,18 @@ func TestBalance_Withdraw(t *testing.T) {
 				userID:     100,
 				withdrawal: model.Withdrawal{Order: "79927398713", Sum: decimal.NewFromInt(10)},
 			},
+			wantEvent: &model.UserBalance{Current: decimal.NewFromInt(90), Withdrawn: decimal.NewFromInt(10)},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			bus := events.NewBus(0)
+			_, sub := bus.Subscribe(tt.args.userID, 0)
+			defer sub.Close()
+
 			b := &Balance{
 				storage: tt.fields.storage,
+				events:  bus,
 			}
 			err := b.Withdraw(context.Background(), tt.args.userID, tt.args.withdrawal)
 
@@ -239,6 +258,16 @@ func TestBalance_Withdraw(t *testing.T) {
 			} else {
 				assert.ErrorIs(t, err, tt.wantErr)
 			}
+
+			select {
+			case event := <-sub.Events():
+				if assert.NotNil(t, tt.wantEvent, "unexpected event %+v", event) {
+					assert.Equal(t, events.TypeBalance, event.Type)
+					assert.Equal(t, *tt.wantEvent, event.Data)
+				}
+			default:
+				assert.Nil(t, tt.wantEvent, "balance has not been published")
+			}
 		})
 	}
 }
@@ -252,6 +281,10 @@ func failingWithdrawal() *storageMock.Storage {
 func successfulWithdrawal() *storageMock.Storage {
 	m := new(storageMock.Storage)
 	m.On("Withdraw", mock.Anything, mock.Anything, mock.Anything).Return(&model.Withdrawal{}, nil)
+	m.On("UserBalance", mock.Anything, uint64(100)).Return(
+		&model.UserBalance{Current: decimal.NewFromInt(90), Withdrawn: decimal.NewFromInt(10)},
+		nil,
+	)
 	return m
 }
 
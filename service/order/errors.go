@@ -1,9 +1,11 @@
 package order
 
-import "errors"
+import "github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 
 var (
-	ErrOrderInvalid    = errors.New("order id is invalid")
-	ErrConflict        = errors.New("order id was already uploaded by another user")
-	ErrAlreadyAccepted = errors.New("order id has been uploaded by this user earlier")
+	ErrOrderInvalid    = apperr.New(apperr.CodeUnprocessable, "order id is invalid").WithReason("invalid_order_number")
+	ErrConflict        = apperr.New(apperr.CodeConflict, "order id was already uploaded by another user").WithReason("order_uploaded_by_another_user")
+	ErrAlreadyAccepted = apperr.New(apperr.CodeConflict, "order id has been uploaded by this user earlier").WithReason("order_already_uploaded")
+	ErrOrderNotFound   = apperr.New(apperr.CodeNotFound, "order not found").WithReason("order_not_found")
+	ErrInternalError   = apperr.New(apperr.CodeInternal, "internal error")
 )
@@ -7,5 +7,7 @@ import (
 
 type Order interface {
 	AcceptOrder(ctx context.Context, userID uint64, orderID string) error
+	AcceptOrders(ctx context.Context, userID uint64, orderIDs []string) ([]model.OrderUploadResult, error)
 	UserOrders(ctx context.Context, userID uint64) ([]model.Order, error)
+	UserOrder(ctx context.Context, userID uint64, orderID string) (*model.Order, error)
 }
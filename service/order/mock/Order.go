@@ -28,6 +28,52 @@ func (_m *Order) AcceptOrder(ctx context.Context, userID uint64, orderID string)
 	return r0
 }
 
+// AcceptOrders provides a mock function with given fields: ctx, userID, orderIDs
+func (_m *Order) AcceptOrders(ctx context.Context, userID uint64, orderIDs []string) ([]model.OrderUploadResult, error) {
+	ret := _m.Called(ctx, userID, orderIDs)
+
+	var r0 []model.OrderUploadResult
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, []string) []model.OrderUploadResult); ok {
+		r0 = rf(ctx, userID, orderIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.OrderUploadResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, []string) error); ok {
+		r1 = rf(ctx, userID, orderIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserOrder provides a mock function with given fields: ctx, userID, orderID
+func (_m *Order) UserOrder(ctx context.Context, userID uint64, orderID string) (*model.Order, error) {
+	ret := _m.Called(ctx, userID, orderID)
+
+	var r0 *model.Order
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) *model.Order); ok {
+		r0 = rf(ctx, userID, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, string) error); ok {
+		r1 = rf(ctx, userID, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UserOrders provides a mock function with given fields: ctx, userID
 func (_m *Order) UserOrders(ctx context.Context, userID uint64) ([]model.Order, error) {
 	ret := _m.Called(ctx, userID)
@@ -0,0 +1,50 @@
+// Package traced provides order.Order that records spans for calls of wrapped service
+package traced
+
+import (
+	"context"
+
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"github.com/soundrussian/go-practicum-diploma/service/order"
+)
+
+var _ order.Order = (*Order)(nil)
+
+// Order records a span for every call of wrapped order service
+type Order struct {
+	next order.Order
+}
+
+// New wraps order service with tracing
+func New(next order.Order) *Order {
+	return &Order{next: next}
+}
+
+func (o *Order) AcceptOrder(ctx context.Context, userID uint64, orderID string) (err error) {
+	ctx, span := tracing.Start(ctx, "order.AcceptOrder", tracing.UserID(userID), tracing.OrderNumber(orderID))
+	defer func() { tracing.End(span, err) }()
+
+	return o.next.AcceptOrder(ctx, userID, orderID)
+}
+
+func (o *Order) AcceptOrders(ctx context.Context, userID uint64, orderIDs []string) (result []model.OrderUploadResult, err error) {
+	ctx, span := tracing.Start(ctx, "order.AcceptOrders", tracing.UserID(userID), tracing.BatchSize(len(orderIDs)))
+	defer func() { tracing.End(span, err) }()
+
+	return o.next.AcceptOrders(ctx, userID, orderIDs)
+}
+
+func (o *Order) UserOrder(ctx context.Context, userID uint64, orderID string) (result *model.Order, err error) {
+	ctx, span := tracing.Start(ctx, "order.UserOrder", tracing.UserID(userID), tracing.OrderNumber(orderID))
+	defer func() { tracing.End(span, err) }()
+
+	return o.next.UserOrder(ctx, userID, orderID)
+}
+
+func (o *Order) UserOrders(ctx context.Context, userID uint64) (result []model.Order, err error) {
+	ctx, span := tracing.Start(ctx, "order.UserOrders", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return o.next.UserOrders(ctx, userID)
+}
@@ -0,0 +1,50 @@
+package traced
+
+import (
+	"context"
+	"testing"
+
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"github.com/soundrussian/go-practicum-diploma/service/order"
+	"github.com/soundrussian/go-practicum-diploma/service/order/mock"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestOrder_AcceptOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus codes.Code
+	}{
+		{
+			name:       "records span with user and order",
+			wantStatus: codes.Unset,
+		},
+		{
+			name:       "records error of wrapped service",
+			err:        order.ErrConflict,
+			wantStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracing.RecordSpans()
+
+			m := new(mock.Order)
+			m.On("AcceptOrder", testifyMock.Anything, uint64(100), "79927398713").Return(tt.err)
+
+			err := New(m).AcceptOrder(context.Background(), 100, "79927398713")
+			assert.ErrorIs(t, err, tt.err)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "order.AcceptOrder", spans[0].Name())
+			assert.Equal(t, tt.wantStatus, spans[0].Status().Code)
+			assert.Contains(t, spans[0].Attributes(), tracing.UserID(100))
+			assert.Contains(t, spans[0].Attributes(), tracing.OrderNumber("79927398713"))
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/rs/zerolog"
 	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
 	order2 "github.com/soundrussian/go-practicum-diploma/service/order"
 	"github.com/soundrussian/go-practicum-diploma/storage"
@@ -42,12 +43,58 @@ func (o *Order) AcceptOrder(ctx context.Context, userID uint64, orderID string)
 			return order2.ErrConflict
 		}
 
-		return err
+		return apperr.Wrap(order2.ErrInternalError, err)
 	}
 
 	return nil
 }
 
+// AcceptOrders accepts batch of orders uploaded by the user and returns outcome for each
+// of orderIDs in the same order. Invalid numbers do not prevent others from being accepted,
+// and repeated numbers are reported as already uploaded after the first occurrence.
+func (o *Order) AcceptOrders(ctx context.Context, userID uint64, orderIDs []string) ([]model.OrderUploadResult, error) {
+	results := make([]model.OrderUploadResult, len(orderIDs))
+	valid := make([]string, 0, len(orderIDs))
+	seen := make(map[string]bool, len(orderIDs))
+
+	for i, orderID := range orderIDs {
+		results[i].OrderID = orderID
+
+		ord := model.Order{OrderID: orderID}
+		if err := ord.Validate(); err != nil {
+			o.Log(ctx).Info().Err(err).Msgf("skipping invalid order %s in batch", orderID)
+			results[i].Status = model.OrderUploadInvalid
+			continue
+		}
+
+		if seen[orderID] {
+			results[i].Status = model.OrderUploadAlreadyUploaded
+			continue
+		}
+
+		seen[orderID] = true
+		valid = append(valid, orderID)
+	}
+
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	statuses, err := o.storage.AcceptOrders(ctx, userID, valid)
+	if err != nil {
+		o.Log(ctx).Err(err).Msgf("error while storing batch of %d orders from user %d", len(valid), userID)
+		return nil, apperr.Wrap(order2.ErrInternalError, err)
+	}
+
+	for i := range results {
+		if results[i].Status == 0 {
+			results[i].Status = statuses[results[i].OrderID]
+		}
+	}
+
+	return results, nil
+}
+
 func (o *Order) UserOrders(ctx context.Context, userID uint64) ([]model.Order, error) {
 	var orders []model.Order
 	var err error
@@ -60,6 +107,23 @@ func (o *Order) UserOrders(ctx context.Context, userID uint64) ([]model.Order, e
 	return orders, nil
 }
 
+// UserOrder returns order uploaded by the user. Orders uploaded by other users
+// result in order.ErrOrderNotFound as well as unknown ones, so that their existence does not leak.
+func (o *Order) UserOrder(ctx context.Context, userID uint64, orderID string) (*model.Order, error) {
+	ord, err := o.storage.UserOrder(ctx, userID, orderID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			o.Log(ctx).Info().Msgf("order <%s> of user %d not found", orderID, userID)
+			return nil, order2.ErrOrderNotFound
+		}
+
+		o.Log(ctx).Err(err).Msgf("failed to fetch order <%s> of user %d", orderID, userID)
+		return nil, apperr.Wrap(order2.ErrInternalError, err)
+	}
+
+	return ord, nil
+}
+
 // Log returns logger with service field set.
 func (o *Order) Log(ctx context.Context) *zerolog.Logger {
 	_, logger := logging.CtxLogger(ctx)
@@ -1,8 +1,14 @@
 package v1
 
 import (
+	"context"
+	"errors"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/service/order"
 	"github.com/soundrussian/go-practicum-diploma/storage"
 	"github.com/soundrussian/go-practicum-diploma/storage/mock"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
 	"reflect"
 	"testing"
 )
@@ -45,3 +51,116 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestOrder_UserOrder(t *testing.T) {
+	found := &model.Order{UserID: 100, OrderID: "79927398713", Status: model.OrderNew}
+
+	tests := []struct {
+		name       string
+		storageOrd *model.Order
+		storageErr error
+		want       *model.Order
+		wantErr    error
+	}{
+		{
+			name:       "returns order of the user",
+			storageOrd: found,
+			want:       found,
+		},
+		{
+			name:       "returns ErrOrderNotFound if storage has no such order of the user",
+			storageErr: storage.ErrNotFound,
+			wantErr:    order.ErrOrderNotFound,
+		},
+		{
+			name:       "returns ErrInternalError if storage fails",
+			storageErr: errors.New("pq: connection refused"),
+			wantErr:    order.ErrInternalError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mock.Storage)
+			m.On("UserOrder", testifyMock.Anything, uint64(100), "79927398713").Return(tt.storageOrd, tt.storageErr)
+
+			o, err := New(m)
+			assert.NoError(t, err)
+
+			got, err := o.UserOrder(context.Background(), 100, "79927398713")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOrder_AcceptOrders(t *testing.T) {
+	tests := []struct {
+		name          string
+		orderIDs      []string
+		storageIDs    []string
+		storageResult map[string]model.OrderUploadStatus
+		storageErr    error
+		want          []model.OrderUploadResult
+		wantErr       error
+	}{
+		{
+			name:       "stores valid unique numbers and reports outcome of each in the same order",
+			orderIDs:   []string{"12345", "79927398713", "12345678903", "79927398713", "346436439"},
+			storageIDs: []string{"79927398713", "12345678903", "346436439"},
+			storageResult: map[string]model.OrderUploadStatus{
+				"79927398713": model.OrderUploadAccepted,
+				"12345678903": model.OrderUploadAlreadyUploaded,
+				"346436439":   model.OrderUploadConflict,
+			},
+			want: []model.OrderUploadResult{
+				{OrderID: "12345", Status: model.OrderUploadInvalid},
+				{OrderID: "79927398713", Status: model.OrderUploadAccepted},
+				{OrderID: "12345678903", Status: model.OrderUploadAlreadyUploaded},
+				{OrderID: "79927398713", Status: model.OrderUploadAlreadyUploaded},
+				{OrderID: "346436439", Status: model.OrderUploadConflict},
+			},
+		},
+		{
+			name:     "does not call storage if all numbers are invalid",
+			orderIDs: []string{"12345", "not a number"},
+			want: []model.OrderUploadResult{
+				{OrderID: "12345", Status: model.OrderUploadInvalid},
+				{OrderID: "not a number", Status: model.OrderUploadInvalid},
+			},
+		},
+		{
+			name:       "returns ErrInternalError if storage fails",
+			orderIDs:   []string{"79927398713"},
+			storageIDs: []string{"79927398713"},
+			storageErr: errors.New("pq: connection refused"),
+			wantErr:    order.ErrInternalError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(mock.Storage)
+			if tt.storageIDs != nil {
+				m.On("AcceptOrders", testifyMock.Anything, uint64(100), tt.storageIDs).Return(tt.storageResult, tt.storageErr)
+			}
+
+			o, err := New(m)
+			assert.NoError(t, err)
+
+			got, err := o.AcceptOrders(context.Background(), 100, tt.orderIDs)
+			m.AssertExpectations(t)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
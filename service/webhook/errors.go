@@ -0,0 +1,12 @@
+package webhook
+
+import "github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+
+var (
+	ErrInvalidURL       = apperr.New(apperr.CodeInvalidArgument, "webhook url must be absolute http or https url").WithReason("invalid_webhook_url")
+	ErrInvalidEvents    = apperr.New(apperr.CodeInvalidArgument, "webhook events must be non-empty list of known events").WithReason("invalid_webhook_events")
+	ErrUserNotFound     = apperr.New(apperr.CodeUnprocessable, "webhook user does not exist").WithReason("webhook_user_not_found")
+	ErrWebhookNotFound  = apperr.New(apperr.CodeNotFound, "webhook not found").WithReason("webhook_not_found")
+	ErrDeliveryNotFound = apperr.New(apperr.CodeNotFound, "webhook delivery not found").WithReason("webhook_delivery_not_found")
+	ErrInternalError    = apperr.New(apperr.CodeInternal, "internal error while managing webhooks")
+)
@@ -0,0 +1,14 @@
+package webhook
+
+import (
+	"context"
+	"github.com/soundrussian/go-practicum-diploma/model"
+)
+
+type Webhook interface {
+	CreateWebhook(ctx context.Context, userID uint64, url string, events []model.WebhookEvent) (*model.Webhook, error)
+	Webhooks(ctx context.Context) ([]model.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID uint64) error
+	Deliveries(ctx context.Context, webhookID uint64) ([]model.WebhookDelivery, error)
+	Redeliver(ctx context.Context, deliveryID uint64) error
+}
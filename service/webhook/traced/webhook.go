@@ -0,0 +1,58 @@
+// Package traced provides webhook.Webhook that records spans for calls of wrapped service
+package traced
+
+import (
+	"context"
+
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/tracing"
+	"github.com/soundrussian/go-practicum-diploma/service/webhook"
+)
+
+var _ webhook.Webhook = (*Webhook)(nil)
+
+// Webhook records a span for every call of wrapped webhook service.
+// Secrets are never added to spans.
+type Webhook struct {
+	next webhook.Webhook
+}
+
+// New wraps webhook service with tracing
+func New(next webhook.Webhook) *Webhook {
+	return &Webhook{next: next}
+}
+
+func (w *Webhook) CreateWebhook(ctx context.Context, userID uint64, url string, events []model.WebhookEvent) (result *model.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.CreateWebhook", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	return w.next.CreateWebhook(ctx, userID, url, events)
+}
+
+func (w *Webhook) Webhooks(ctx context.Context) (result []model.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.Webhooks")
+	defer func() { tracing.End(span, err) }()
+
+	return w.next.Webhooks(ctx)
+}
+
+func (w *Webhook) DeleteWebhook(ctx context.Context, webhookID uint64) (err error) {
+	ctx, span := tracing.Start(ctx, "webhook.DeleteWebhook", tracing.WebhookID(webhookID))
+	defer func() { tracing.End(span, err) }()
+
+	return w.next.DeleteWebhook(ctx, webhookID)
+}
+
+func (w *Webhook) Deliveries(ctx context.Context, webhookID uint64) (result []model.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "webhook.Deliveries", tracing.WebhookID(webhookID))
+	defer func() { tracing.End(span, err) }()
+
+	return w.next.Deliveries(ctx, webhookID)
+}
+
+func (w *Webhook) Redeliver(ctx context.Context, deliveryID uint64) (err error) {
+	ctx, span := tracing.Start(ctx, "webhook.Redeliver", tracing.WebhookDeliveryID(deliveryID))
+	defer func() { tracing.End(span, err) }()
+
+	return w.next.Redeliver(ctx, deliveryID)
+}
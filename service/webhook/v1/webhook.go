@@ -0,0 +1,143 @@
+package v1
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/rs/zerolog"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/apperr"
+	"github.com/soundrussian/go-practicum-diploma/pkg/logging"
+	webhook2 "github.com/soundrussian/go-practicum-diploma/service/webhook"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"net/url"
+)
+
+var _ webhook2.Webhook = (*Webhook)(nil)
+
+const (
+	// secretPrefix tells webhook secrets apart from other credentials
+	secretPrefix = "whsec_"
+	// secretBytes is number of random bytes in webhook secret
+	secretBytes = 32
+	// deliveriesLimit is how many latest deliveries of a webhook are shown
+	deliveriesLimit = 100
+)
+
+type Webhook struct {
+	storage storage.Storage
+}
+
+func New(storage storage.Storage) (*Webhook, error) {
+	if storage == nil {
+		return nil, errors.New("nil storage passed to Webhook service constructor")
+	}
+
+	return &Webhook{storage: storage}, nil
+}
+
+// CreateWebhook subscribes url to events of user. Secret the payloads are signed with
+// is generated and returned along with the webhook, so that it could be shared with the partner.
+func (w *Webhook) CreateWebhook(ctx context.Context, userID uint64, rawURL string, events []model.WebhookEvent) (*model.Webhook, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, webhook2.ErrInvalidURL
+	}
+
+	if len(events) == 0 {
+		return nil, webhook2.ErrInvalidEvents
+	}
+
+	for _, event := range events {
+		if !event.Valid() {
+			return nil, webhook2.ErrInvalidEvents
+		}
+	}
+
+	user, err := w.storage.FetchUserByID(ctx, userID)
+	if err != nil {
+		w.Log(ctx).Err(err).Msgf("failed to fetch user %d to create webhook for", userID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, webhook2.ErrUserNotFound
+		}
+		return nil, apperr.Wrap(webhook2.ErrInternalError, err)
+	}
+	// Deleted users have no events to send
+	if user.DeletedAt != nil {
+		return nil, webhook2.ErrUserNotFound
+	}
+
+	random := make([]byte, secretBytes)
+	if _, err := rand.Read(random); err != nil {
+		w.Log(ctx).Err(err).Msg("failed to generate webhook secret")
+		return nil, apperr.Wrap(webhook2.ErrInternalError, err)
+	}
+
+	hook, err := w.storage.CreateWebhook(ctx, model.Webhook{
+		UserID: userID,
+		URL:    rawURL,
+		Secret: secretPrefix + base64.RawURLEncoding.EncodeToString(random),
+		Events: events,
+	})
+	if err != nil {
+		w.Log(ctx).Err(err).Msgf("failed to store webhook for %s", rawURL)
+		return nil, apperr.Wrap(webhook2.ErrInternalError, err)
+	}
+
+	return hook, nil
+}
+
+func (w *Webhook) Webhooks(ctx context.Context) ([]model.Webhook, error) {
+	hooks, err := w.storage.Webhooks(ctx)
+	if err != nil {
+		w.Log(ctx).Err(err).Msg("failed to fetch webhooks")
+		return nil, apperr.Wrap(webhook2.ErrInternalError, err)
+	}
+
+	return hooks, nil
+}
+
+func (w *Webhook) DeleteWebhook(ctx context.Context, webhookID uint64) error {
+	if err := w.storage.DeleteWebhook(ctx, webhookID); err != nil {
+		w.Log(ctx).Err(err).Msgf("failed to delete webhook %d", webhookID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return webhook2.ErrWebhookNotFound
+		}
+		return apperr.Wrap(webhook2.ErrInternalError, err)
+	}
+
+	return nil
+}
+
+// Deliveries returns latest deliveries of the webhook, newest first
+func (w *Webhook) Deliveries(ctx context.Context, webhookID uint64) ([]model.WebhookDelivery, error) {
+	deliveries, err := w.storage.WebhookDeliveries(ctx, webhookID, deliveriesLimit)
+	if err != nil {
+		w.Log(ctx).Err(err).Msgf("failed to fetch deliveries of webhook %d", webhookID)
+		return nil, apperr.Wrap(webhook2.ErrInternalError, err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver sends delivery once again, e.g. after partner has fixed their endpoint
+func (w *Webhook) Redeliver(ctx context.Context, deliveryID uint64) error {
+	if err := w.storage.RedeliverWebhook(ctx, deliveryID); err != nil {
+		w.Log(ctx).Err(err).Msgf("failed to redeliver webhook delivery %d", deliveryID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return webhook2.ErrDeliveryNotFound
+		}
+		return apperr.Wrap(webhook2.ErrInternalError, err)
+	}
+
+	return nil
+}
+
+// Log returns logger with service field set.
+func (w *Webhook) Log(ctx context.Context) *zerolog.Logger {
+	_, logger := logging.CtxLogger(ctx)
+	logger = logger.With().Str(logging.ServiceNameKey, "webhook").Logger()
+
+	return &logger
+}
@@ -0,0 +1,203 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/service/webhook"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"github.com/soundrussian/go-practicum-diploma/storage/mock"
+	"github.com/stretchr/testify/assert"
+	testifyMock "github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhook_CreateWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		events     []model.WebhookEvent
+		userErr    error
+		deleted    bool
+		storageErr error
+		wantErr    error
+	}{
+		{
+			name:   "creates webhook with generated secret",
+			url:    "https://partner.example/hooks",
+			events: []model.WebhookEvent{model.WebhookOrderCredited, model.WebhookPointsWithdrawn},
+		},
+		{
+			name:    "rejects url with unsupported scheme",
+			url:     "ftp://partner.example/hooks",
+			events:  []model.WebhookEvent{model.WebhookOrderCredited},
+			wantErr: webhook.ErrInvalidURL,
+		},
+		{
+			name:    "rejects relative url",
+			url:     "/hooks",
+			events:  []model.WebhookEvent{model.WebhookOrderCredited},
+			wantErr: webhook.ErrInvalidURL,
+		},
+		{
+			name:    "rejects empty list of events",
+			url:     "https://partner.example/hooks",
+			wantErr: webhook.ErrInvalidEvents,
+		},
+		{
+			name:    "rejects unknown event",
+			url:     "https://partner.example/hooks",
+			events:  []model.WebhookEvent{"order.deleted"},
+			wantErr: webhook.ErrInvalidEvents,
+		},
+		{
+			name:    "rejects unknown user",
+			url:     "https://partner.example/hooks",
+			events:  []model.WebhookEvent{model.WebhookOrderCredited},
+			userErr: storage.ErrNotFound,
+			wantErr: webhook.ErrUserNotFound,
+		},
+		{
+			name:    "rejects deleted user",
+			url:     "https://partner.example/hooks",
+			events:  []model.WebhookEvent{model.WebhookOrderCredited},
+			deleted: true,
+			wantErr: webhook.ErrUserNotFound,
+		},
+		{
+			name:    "returns internal error if user cannot be fetched",
+			url:     "https://partner.example/hooks",
+			events:  []model.WebhookEvent{model.WebhookOrderCredited},
+			userErr: errors.New("unexpected error"),
+			wantErr: webhook.ErrInternalError,
+		},
+		{
+			name:       "returns internal error if storage fails",
+			url:        "https://partner.example/hooks",
+			events:     []model.WebhookEvent{model.WebhookOrderCredited},
+			storageErr: errors.New("unexpected error"),
+			wantErr:    webhook.ErrInternalError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mock.Storage)
+			store.On("FetchUserByID", testifyMock.Anything, uint64(100)).
+				Return(func(_ context.Context, userID uint64) *model.User {
+					if tt.userErr != nil {
+						return nil
+					}
+					user := &model.User{ID: userID}
+					if tt.deleted {
+						deletedAt := time.Now()
+						user.DeletedAt = &deletedAt
+					}
+					return user
+				}, tt.userErr)
+			store.On("CreateWebhook", testifyMock.Anything, testifyMock.Anything).
+				Return(func(_ context.Context, hook model.Webhook) *model.Webhook {
+					if tt.storageErr != nil {
+						return nil
+					}
+					hook.ID = 1
+					return &hook
+				}, tt.storageErr)
+
+			w, err := New(store)
+			require.NoError(t, err)
+
+			hook, err := w.CreateWebhook(context.Background(), 100, tt.url, tt.events)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, uint64(1), hook.ID)
+			assert.Equal(t, uint64(100), hook.UserID)
+			assert.Equal(t, tt.url, hook.URL)
+			assert.Equal(t, tt.events, hook.Events)
+			assert.True(t, strings.HasPrefix(hook.Secret, secretPrefix), "secret %q has no prefix", hook.Secret)
+			assert.Greater(t, len(hook.Secret), len(secretPrefix)+secretBytes)
+		})
+	}
+}
+
+func TestWebhook_DeleteWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		storageErr error
+		wantErr    error
+	}{
+		{
+			name: "deletes webhook",
+		},
+		{
+			name:       "returns ErrWebhookNotFound if there is no such webhook",
+			storageErr: storage.ErrNotFound,
+			wantErr:    webhook.ErrWebhookNotFound,
+		},
+		{
+			name:       "returns internal error if storage fails",
+			storageErr: errors.New("unexpected error"),
+			wantErr:    webhook.ErrInternalError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mock.Storage)
+			store.On("DeleteWebhook", testifyMock.Anything, uint64(1)).Return(tt.storageErr)
+
+			w, err := New(store)
+			require.NoError(t, err)
+
+			err = w.DeleteWebhook(context.Background(), 1)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWebhook_Redeliver(t *testing.T) {
+	tests := []struct {
+		name       string
+		storageErr error
+		wantErr    error
+	}{
+		{
+			name: "schedules delivery to be sent again",
+		},
+		{
+			name:       "returns ErrDeliveryNotFound if there is no such delivery",
+			storageErr: storage.ErrNotFound,
+			wantErr:    webhook.ErrDeliveryNotFound,
+		},
+		{
+			name:       "returns internal error if storage fails",
+			storageErr: errors.New("unexpected error"),
+			wantErr:    webhook.ErrInternalError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := new(mock.Storage)
+			store.On("RedeliverWebhook", testifyMock.Anything, uint64(2)).Return(tt.storageErr)
+
+			w, err := New(store)
+			require.NoError(t, err)
+
+			err = w.Redeliver(context.Background(), 2)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
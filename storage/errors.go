@@ -1,12 +1,12 @@
 package storage
 
-import "errors"
+import "github.com/soundrussian/go-practicum-diploma/pkg/apperr"
 
 var (
-	ErrLoginAlreadyExists = errors.New("login already exists")
-	ErrNotFound           = errors.New("not found")
-	ErrNotEnoughBalance   = errors.New("not enough balance")
+	ErrLoginAlreadyExists = apperr.New(apperr.CodeConflict, "login already exists").WithReason("login_taken")
+	ErrNotFound           = apperr.New(apperr.CodeNotFound, "not found")
+	ErrNotEnoughBalance   = apperr.New(apperr.CodeInsufficientFunds, "not enough balance").WithReason("insufficient_funds")
 
-	ErrOrderExistsSameUser    = errors.New("order has already been uploaded by the same user")
-	ErrOrderExistsAnotherUser = errors.New("order has already been uploaded by another user")
+	ErrOrderExistsSameUser    = apperr.New(apperr.CodeConflict, "order has already been uploaded by the same user").WithReason("order_already_uploaded")
+	ErrOrderExistsAnotherUser = apperr.New(apperr.CodeConflict, "order has already been uploaded by another user").WithReason("order_uploaded_by_another_user")
 )
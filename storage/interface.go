@@ -4,18 +4,48 @@ import (
 	"context"
 	"github.com/shopspring/decimal"
 	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/pkg/ratelimit"
+	"time"
 )
 
 type Storage interface {
 	CreateUser(ctx context.Context, login string, password string) (*model.User, error)
 	FetchUser(ctx context.Context, login string) (*model.User, error)
+	FetchUserByID(ctx context.Context, userID uint64) (*model.User, error)
+	UpdateUserRole(ctx context.Context, userID uint64, role model.Role) error
+	DeleteUser(ctx context.Context, userID uint64) error
+	SetTOTPSecret(ctx context.Context, userID uint64, secret string) error
+	EnableTOTP(ctx context.Context, userID uint64, recoveryCodeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uint64, hash string) error
+	UseTOTPStep(ctx context.Context, userID uint64, step uint64) error
+	TakeSecondFactorAttempt(ctx context.Context, userID uint64, maxAttempts int, lockedBefore time.Time) error
+	ResetSecondFactorAttempts(ctx context.Context, userID uint64) error
 	UserBalance(ctx context.Context, userID uint64) (*model.UserBalance, error)
 	Withdraw(ctx context.Context, userID uint64, withdrawal model.Withdrawal) (*model.Withdrawal, error)
 	UserWithdrawals(ctx context.Context, userID uint64) ([]model.Withdrawal, error)
+	UserTransactions(ctx context.Context, userID uint64) ([]model.Transaction, error)
 	AcceptOrder(ctx context.Context, userID uint64, orderID string) (*model.Order, error)
+	AcceptOrders(ctx context.Context, userID uint64, orderIDs []string) (map[string]model.OrderUploadStatus, error)
 	UserOrders(ctx context.Context, userID uint64) ([]model.Order, error)
+	UserOrder(ctx context.Context, userID uint64, orderID string) (*model.Order, error)
+	FetchOrder(ctx context.Context, orderID string) (*model.Order, error)
 	OrdersWithStatus(ctx context.Context, status model.OrderStatus, limit int) ([]string, error)
+	OrderCountsByStatus(ctx context.Context) (map[model.OrderStatus]int64, error)
 	UpdateOrderStatus(ctx context.Context, orderID string, status model.OrderStatus) error
 	AddAccrual(ctx context.Context, orderID string, status model.OrderStatus, accrual decimal.Decimal) error
+	CreateAPIKey(ctx context.Context, key model.APIKey) (*model.APIKey, error)
+	UserAPIKeys(ctx context.Context, userID uint64) ([]model.APIKey, error)
+	FetchAPIKey(ctx context.Context, hash string) (*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID uint64, keyID uint64) error
+	CreateWebhook(ctx context.Context, hook model.Webhook) (*model.Webhook, error)
+	Webhooks(ctx context.Context) ([]model.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID uint64) error
+	WebhookDeliveries(ctx context.Context, webhookID uint64, limit int) ([]model.WebhookDelivery, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error
+	RedeliverWebhook(ctx context.Context, deliveryID uint64) error
+	TakeRateLimit(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+	Ping(ctx context.Context) error
+	CheckMigrations(ctx context.Context) error
 	Close()
 }
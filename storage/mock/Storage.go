@@ -4,11 +4,12 @@ package mock
 
 import (
 	context "context"
+	time "time"
 
 	decimal "github.com/shopspring/decimal"
-	mock "github.com/stretchr/testify/mock"
-
 	model "github.com/soundrussian/go-practicum-diploma/model"
+	ratelimit "github.com/soundrussian/go-practicum-diploma/pkg/ratelimit"
+	mock "github.com/stretchr/testify/mock"
 )
 
 // Storage is an autogenerated mock type for the Storage type
@@ -39,6 +40,29 @@ func (_m *Storage) AcceptOrder(ctx context.Context, userID uint64, orderID strin
 	return r0, r1
 }
 
+// AcceptOrders provides a mock function with given fields: ctx, userID, orderIDs
+func (_m *Storage) AcceptOrders(ctx context.Context, userID uint64, orderIDs []string) (map[string]model.OrderUploadStatus, error) {
+	ret := _m.Called(ctx, userID, orderIDs)
+
+	var r0 map[string]model.OrderUploadStatus
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, []string) map[string]model.OrderUploadStatus); ok {
+		r0 = rf(ctx, userID, orderIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]model.OrderUploadStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, []string) error); ok {
+		r1 = rf(ctx, userID, orderIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AddAccrual provides a mock function with given fields: ctx, orderID, status, accrual
 func (_m *Storage) AddAccrual(ctx context.Context, orderID string, status model.OrderStatus, accrual decimal.Decimal) error {
 	ret := _m.Called(ctx, orderID, status, accrual)
@@ -53,11 +77,71 @@ func (_m *Storage) AddAccrual(ctx context.Context, orderID string, status model.
 	return r0
 }
 
+// CheckMigrations provides a mock function with given fields: ctx
+func (_m *Storage) CheckMigrations(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClaimWebhookDeliveries provides a mock function with given fields: ctx, limit, lease
+func (_m *Storage) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	ret := _m.Called(ctx, limit, lease)
+
+	var r0 []model.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration) []model.WebhookDelivery); ok {
+		r0 = rf(ctx, limit, lease)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.WebhookDelivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Duration) error); ok {
+		r1 = rf(ctx, limit, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Close provides a mock function with given fields:
 func (_m *Storage) Close() {
 	_m.Called()
 }
 
+// CreateAPIKey provides a mock function with given fields: ctx, key
+func (_m *Storage) CreateAPIKey(ctx context.Context, key model.APIKey) (*model.APIKey, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *model.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, model.APIKey) *model.APIKey); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.APIKey) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateUser provides a mock function with given fields: ctx, login, password
 func (_m *Storage) CreateUser(ctx context.Context, login string, password string) (*model.User, error) {
 	ret := _m.Called(ctx, login, password)
@@ -81,6 +165,117 @@ func (_m *Storage) CreateUser(ctx context.Context, login string, password string
 	return r0, r1
 }
 
+// CreateWebhook provides a mock function with given fields: ctx, hook
+func (_m *Storage) CreateWebhook(ctx context.Context, hook model.Webhook) (*model.Webhook, error) {
+	ret := _m.Called(ctx, hook)
+
+	var r0 *model.Webhook
+	if rf, ok := ret.Get(0).(func(context.Context, model.Webhook) *model.Webhook); ok {
+		r0 = rf(ctx, hook)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Webhook)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.Webhook) error); ok {
+		r1 = rf(ctx, hook)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteUser provides a mock function with given fields: ctx, userID
+func (_m *Storage) DeleteUser(ctx context.Context, userID uint64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWebhook provides a mock function with given fields: ctx, webhookID
+func (_m *Storage) DeleteWebhook(ctx context.Context, webhookID uint64) error {
+	ret := _m.Called(ctx, webhookID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnableTOTP provides a mock function with given fields: ctx, userID, recoveryCodeHashes
+func (_m *Storage) EnableTOTP(ctx context.Context, userID uint64, recoveryCodeHashes []string) error {
+	ret := _m.Called(ctx, userID, recoveryCodeHashes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, []string) error); ok {
+		r0 = rf(ctx, userID, recoveryCodeHashes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FetchAPIKey provides a mock function with given fields: ctx, hash
+func (_m *Storage) FetchAPIKey(ctx context.Context, hash string) (*model.APIKey, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 *model.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.APIKey); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FetchOrder provides a mock function with given fields: ctx, orderID
+func (_m *Storage) FetchOrder(ctx context.Context, orderID string) (*model.Order, error) {
+	ret := _m.Called(ctx, orderID)
+
+	var r0 *model.Order
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Order); ok {
+		r0 = rf(ctx, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FetchUser provides a mock function with given fields: ctx, login
 func (_m *Storage) FetchUser(ctx context.Context, login string) (*model.User, error) {
 	ret := _m.Called(ctx, login)
@@ -104,6 +299,52 @@ func (_m *Storage) FetchUser(ctx context.Context, login string) (*model.User, er
 	return r0, r1
 }
 
+// FetchUserByID provides a mock function with given fields: ctx, userID
+func (_m *Storage) FetchUserByID(ctx context.Context, userID uint64) (*model.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 *model.User
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) *model.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OrderCountsByStatus provides a mock function with given fields: ctx
+func (_m *Storage) OrderCountsByStatus(ctx context.Context) (map[model.OrderStatus]int64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[model.OrderStatus]int64
+	if rf, ok := ret.Get(0).(func(context.Context) map[model.OrderStatus]int64); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[model.OrderStatus]int64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // OrdersWithStatus provides a mock function with given fields: ctx, status, limit
 func (_m *Storage) OrdersWithStatus(ctx context.Context, status model.OrderStatus, limit int) ([]string, error) {
 	ret := _m.Called(ctx, status, limit)
@@ -127,6 +368,111 @@ func (_m *Storage) OrdersWithStatus(ctx context.Context, status model.OrderStatu
 	return r0, r1
 }
 
+// Ping provides a mock function with given fields: ctx
+func (_m *Storage) Ping(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RedeliverWebhook provides a mock function with given fields: ctx, deliveryID
+func (_m *Storage) RedeliverWebhook(ctx context.Context, deliveryID uint64) error {
+	ret := _m.Called(ctx, deliveryID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, deliveryID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetSecondFactorAttempts provides a mock function with given fields: ctx, userID
+func (_m *Storage) ResetSecondFactorAttempts(ctx context.Context, userID uint64) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, userID, keyID
+func (_m *Storage) RevokeAPIKey(ctx context.Context, userID uint64, keyID uint64) error {
+	ret := _m.Called(ctx, userID, keyID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) error); ok {
+		r0 = rf(ctx, userID, keyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetTOTPSecret provides a mock function with given fields: ctx, userID, secret
+func (_m *Storage) SetTOTPSecret(ctx context.Context, userID uint64, secret string) error {
+	ret := _m.Called(ctx, userID, secret)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) error); ok {
+		r0 = rf(ctx, userID, secret)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TakeRateLimit provides a mock function with given fields: ctx, key, limit
+func (_m *Storage) TakeRateLimit(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	ret := _m.Called(ctx, key, limit)
+
+	var r0 ratelimit.Result
+	if rf, ok := ret.Get(0).(func(context.Context, string, ratelimit.Limit) ratelimit.Result); ok {
+		r0 = rf(ctx, key, limit)
+	} else {
+		r0 = ret.Get(0).(ratelimit.Result)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, ratelimit.Limit) error); ok {
+		r1 = rf(ctx, key, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TakeSecondFactorAttempt provides a mock function with given fields: ctx, userID, maxAttempts, lockedBefore
+func (_m *Storage) TakeSecondFactorAttempt(ctx context.Context, userID uint64, maxAttempts int, lockedBefore time.Time) error {
+	ret := _m.Called(ctx, userID, maxAttempts, lockedBefore)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, int, time.Time) error); ok {
+		r0 = rf(ctx, userID, maxAttempts, lockedBefore)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateOrderStatus provides a mock function with given fields: ctx, orderID, status
 func (_m *Storage) UpdateOrderStatus(ctx context.Context, orderID string, status model.OrderStatus) error {
 	ret := _m.Called(ctx, orderID, status)
@@ -141,6 +487,85 @@ func (_m *Storage) UpdateOrderStatus(ctx context.Context, orderID string, status
 	return r0
 }
 
+// UpdateUserRole provides a mock function with given fields: ctx, userID, role
+func (_m *Storage) UpdateUserRole(ctx context.Context, userID uint64, role model.Role) error {
+	ret := _m.Called(ctx, userID, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, model.Role) error); ok {
+		r0 = rf(ctx, userID, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWebhookDelivery provides a mock function with given fields: ctx, delivery
+func (_m *Storage) UpdateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	ret := _m.Called(ctx, delivery)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.WebhookDelivery) error); ok {
+		r0 = rf(ctx, delivery)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseRecoveryCode provides a mock function with given fields: ctx, userID, hash
+func (_m *Storage) UseRecoveryCode(ctx context.Context, userID uint64, hash string) error {
+	ret := _m.Called(ctx, userID, hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) error); ok {
+		r0 = rf(ctx, userID, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseTOTPStep provides a mock function with given fields: ctx, userID, step
+func (_m *Storage) UseTOTPStep(ctx context.Context, userID uint64, step uint64) error {
+	ret := _m.Called(ctx, userID, step)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, uint64) error); ok {
+		r0 = rf(ctx, userID, step)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserAPIKeys provides a mock function with given fields: ctx, userID
+func (_m *Storage) UserAPIKeys(ctx context.Context, userID uint64) ([]model.APIKey, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []model.APIKey
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) []model.APIKey); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.APIKey)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UserBalance provides a mock function with given fields: ctx, userID
 func (_m *Storage) UserBalance(ctx context.Context, userID uint64) (*model.UserBalance, error) {
 	ret := _m.Called(ctx, userID)
@@ -164,6 +589,29 @@ func (_m *Storage) UserBalance(ctx context.Context, userID uint64) (*model.UserB
 	return r0, r1
 }
 
+// UserOrder provides a mock function with given fields: ctx, userID, orderID
+func (_m *Storage) UserOrder(ctx context.Context, userID uint64, orderID string) (*model.Order, error) {
+	ret := _m.Called(ctx, userID, orderID)
+
+	var r0 *model.Order
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, string) *model.Order); ok {
+		r0 = rf(ctx, userID, orderID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, string) error); ok {
+		r1 = rf(ctx, userID, orderID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UserOrders provides a mock function with given fields: ctx, userID
 func (_m *Storage) UserOrders(ctx context.Context, userID uint64) ([]model.Order, error) {
 	ret := _m.Called(ctx, userID)
@@ -187,6 +635,29 @@ func (_m *Storage) UserOrders(ctx context.Context, userID uint64) ([]model.Order
 	return r0, r1
 }
 
+// UserTransactions provides a mock function with given fields: ctx, userID
+func (_m *Storage) UserTransactions(ctx context.Context, userID uint64) ([]model.Transaction, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []model.Transaction
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) []model.Transaction); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Transaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UserWithdrawals provides a mock function with given fields: ctx, userID
 func (_m *Storage) UserWithdrawals(ctx context.Context, userID uint64) ([]model.Withdrawal, error) {
 	ret := _m.Called(ctx, userID)
@@ -210,6 +681,52 @@ func (_m *Storage) UserWithdrawals(ctx context.Context, userID uint64) ([]model.
 	return r0, r1
 }
 
+// WebhookDeliveries provides a mock function with given fields: ctx, webhookID, limit
+func (_m *Storage) WebhookDeliveries(ctx context.Context, webhookID uint64, limit int) ([]model.WebhookDelivery, error) {
+	ret := _m.Called(ctx, webhookID, limit)
+
+	var r0 []model.WebhookDelivery
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, int) []model.WebhookDelivery); ok {
+		r0 = rf(ctx, webhookID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.WebhookDelivery)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, int) error); ok {
+		r1 = rf(ctx, webhookID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Webhooks provides a mock function with given fields: ctx
+func (_m *Storage) Webhooks(ctx context.Context) ([]model.Webhook, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []model.Webhook
+	if rf, ok := ret.Get(0).(func(context.Context) []model.Webhook); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Webhook)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Withdraw provides a mock function with given fields: ctx, userID, withdrawal
 func (_m *Storage) Withdraw(ctx context.Context, userID uint64, withdrawal model.Withdrawal) (*model.Withdrawal, error) {
 	ret := _m.Called(ctx, userID, withdrawal)
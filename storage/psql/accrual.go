@@ -53,6 +53,15 @@ func (s *Storage) AddAccrual(ctx context.Context, orderID string, status model.O
 	}
 	s.Log(ctx).Debug().Msgf("inserted %d, %s, %s", userID, orderID, accrual)
 
+	err = s.enqueueWebhooks(ctx, tx, userID, model.WebhookOrderCredited, model.OrderCreditedPayload{
+		Order:      orderID,
+		Accrual:    accrual,
+		CreditedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil && err != sql.ErrTxDone {
 		s.Log(ctx).Err(err).Msg("error committing transaction")
 		return err
@@ -0,0 +1,157 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"strings"
+	"time"
+)
+
+func (s *Storage) CreateAPIKey(ctx context.Context, key model.APIKey) (*model.APIKey, error) {
+	key.CreatedAt = time.Now()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6)
+         RETURNING id`,
+		key.UserID, key.Name, key.Prefix, key.Hash, joinScopes(key.Scopes), key.CreatedAt,
+	).Scan(&key.ID)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to save api key for user %d", key.UserID)
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (s *Storage) UserAPIKeys(ctx context.Context, userID uint64) ([]model.APIKey, error) {
+	result := make([]model.APIKey, 0)
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, user_id, name, prefix, key_hash, scopes, created_at, revoked_at
+		   FROM api_keys
+		  WHERE user_id = $1
+          ORDER BY created_at DESC`,
+		userID)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to fetch api keys for user_id %d", userID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			s.Log(ctx).Err(err).Msg("failed to scan row")
+			return nil, err
+		}
+
+		result = append(result, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.Log(ctx).Err(err).Msg("error reading rows")
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *Storage) FetchAPIKey(ctx context.Context, hash string) (*model.APIKey, error) {
+	row := s.db.QueryRowContext(
+		ctx,
+		`SELECT id, user_id, name, prefix, key_hash, scopes, created_at, revoked_at
+           FROM api_keys
+          WHERE key_hash = $1
+            AND revoked_at IS NULL
+          LIMIT 1`,
+		hash,
+	)
+
+	key, err := scanAPIKey(row)
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("error fetching api key")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (s *Storage) RevokeAPIKey(ctx context.Context, userID uint64, keyID uint64) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE api_keys
+            SET revoked_at = $1
+          WHERE id = $2
+            AND user_id = $3
+            AND revoked_at IS NULL`,
+		time.Now(), keyID, userID,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to revoke api key %d of user %d", keyID, userID)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to get number of revoked keys")
+		return err
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row scanner) (*model.APIKey, error) {
+	var key model.APIKey
+	var scopes string
+	var revokedAt sql.NullTime
+
+	if err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+
+	key.Scopes = splitScopes(scopes)
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
+
+// joinScopes stores scopes as comma-separated list
+func joinScopes(scopes []model.APIKeyScope) string {
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		result = append(result, string(scope))
+	}
+
+	return strings.Join(result, ",")
+}
+
+func splitScopes(scopes string) []model.APIKeyScope {
+	result := make([]model.APIKeyScope, 0)
+	for _, scope := range strings.Split(scopes, ",") {
+		if scope != "" {
+			result = append(result, model.APIKeyScope(scope))
+		}
+	}
+
+	return result
+}
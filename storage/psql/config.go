@@ -1,41 +1,54 @@
 package psql
 
 import (
-	"flag"
-	"os"
+	"errors"
+	"net/url"
+	"regexp"
 )
 
-var databaseConnection *string
+// Config contains settings of PostgreSQL storage
+type Config struct {
+	// URI is a database connection string, either URL or key=value one
+	URI string `yaml:"uri" toml:"uri"`
+}
 
 const defaultDatabaseConnection = "port=5432 host=localhost user=postgres password=postgres dbname=my_database sslmode=disable"
 
-const databaseConnectionEnvKey = "DATABASE_URI"
-const databaseConnectionFlag = "d"
-
-func init() {
-	// Config from env is read by a separate function so that we could call it once
-	// again in tests, after setting env variable inside the test
-	readConfig()
+// maskedPassword replaces password in masked connection string
+const maskedPassword = "******"
 
-	flag.StringVar(databaseConnection, databaseConnectionFlag, *databaseConnection, "database connection")
+// DefaultConfig returns storage settings used unless they are overridden
+func DefaultConfig() Config {
+	return Config{URI: defaultDatabaseConnection}
 }
 
-// readConfig initializes config if it is not yet set,
-// then reads env variable from databaseConnectionEnvKey
-// if the variable is set and config has just been initialized
-func readConfig() {
-	// If databaseConnection has not been set yet, initialize it
-	if databaseConnection == nil {
-		defaultDSN := defaultDatabaseConnection
-		databaseConnection = &defaultDSN
+// Validate returns error if any of the settings is invalid
+func (c Config) Validate() error {
+	if c.URI == "" {
+		return errors.New("database uri is empty")
 	}
 
-	// Here we check for two things:
-	// 1. There is databaseConnectionEnvKey environment variable
-	// 2. databaseConnection equals defaultRunAddress, which means
-	// that it has just been initialized and was not overwritten previously
-	// by flag.Parse(). This is required for tests.
-	if dsn, ok := os.LookupEnv(databaseConnectionEnvKey); ok && *databaseConnection == defaultDatabaseConnection {
-		databaseConnection = &dsn
+	return nil
+}
+
+// passwordPair matches password in key=value connection string
+var passwordPair = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Masked returns copy of config with password in connection string replaced, so that it could be printed
+func (c Config) Masked() Config {
+	if u, err := url.Parse(c.URI); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), maskedPassword)
+		}
+		query := u.Query()
+		if query.Has("password") {
+			query.Set("password", maskedPassword)
+			u.RawQuery = query.Encode()
+		}
+		c.URI = u.String()
+		return c
 	}
+
+	c.URI = passwordPair.ReplaceAllString(c.URI, "${1}"+maskedPassword)
+	return c
 }
@@ -0,0 +1,13 @@
+BEGIN;
+CREATE TABLE api_keys(
+    id serial PRIMARY KEY,
+    user_id integer REFERENCES users ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) UNIQUE NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    created_at timestamp NOT NULL,
+    revoked_at timestamp
+);
+CREATE INDEX ON api_keys (user_id);
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+DROP TABLE recovery_codes;
+ALTER TABLE users DROP COLUMN second_factor_locked_at;
+ALTER TABLE users DROP COLUMN second_factor_attempts;
+ALTER TABLE users DROP COLUMN totp_last_step;
+ALTER TABLE users DROP COLUMN totp_enabled;
+ALTER TABLE users DROP COLUMN totp_secret;
+COMMIT;
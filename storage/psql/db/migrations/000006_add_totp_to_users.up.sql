@@ -0,0 +1,16 @@
+BEGIN;
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN totp_enabled boolean NOT NULL DEFAULT false;
+-- Time step of the last accepted code, codes of this and earlier steps are rejected as replays
+ALTER TABLE users ADD COLUMN totp_last_step bigint;
+-- Second factor attempts taken since the last successful one, and when they were last locked out
+ALTER TABLE users ADD COLUMN second_factor_attempts integer NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN second_factor_locked_at timestamp;
+CREATE TABLE recovery_codes(
+    id serial PRIMARY KEY,
+    user_id integer REFERENCES users ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at timestamp
+);
+CREATE INDEX ON recovery_codes (user_id);
+COMMIT;
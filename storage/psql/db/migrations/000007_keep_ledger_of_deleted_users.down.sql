@@ -0,0 +1,9 @@
+BEGIN;
+ALTER TABLE orders DROP CONSTRAINT orders_user_id_fkey;
+ALTER TABLE orders ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES users ON DELETE CASCADE;
+ALTER TABLE transactions DROP CONSTRAINT transactions_user_id_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users ON DELETE CASCADE;
+UPDATE users SET login = 'deleted-' || id WHERE login IS NULL;
+ALTER TABLE users ALTER COLUMN login SET NOT NULL;
+ALTER TABLE users DROP COLUMN deleted_at;
+COMMIT;
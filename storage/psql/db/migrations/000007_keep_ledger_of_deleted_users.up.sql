@@ -0,0 +1,11 @@
+BEGIN;
+ALTER TABLE users ADD COLUMN deleted_at timestamp;
+-- Deleted users have no login, so that it can be registered again. Unique constraint
+-- lets any number of logins be NULL, so no registered login collides with deleted users.
+ALTER TABLE users ALTER COLUMN login DROP NOT NULL;
+-- Ledger must survive removal of users, so users can only be anonymized
+ALTER TABLE transactions DROP CONSTRAINT transactions_user_id_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users ON DELETE RESTRICT;
+ALTER TABLE orders DROP CONSTRAINT orders_user_id_fkey;
+ALTER TABLE orders ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES users ON DELETE RESTRICT;
+COMMIT;
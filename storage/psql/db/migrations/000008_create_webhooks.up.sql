@@ -0,0 +1,30 @@
+BEGIN;
+-- Webhook receives events of the user it belongs to only
+CREATE TABLE webhooks(
+    id serial PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    events VARCHAR(255) NOT NULL,
+    created_at timestamp NOT NULL
+);
+CREATE INDEX ON webhooks (user_id);
+-- Outbox of webhook events: rows are written in the same transaction as the change
+-- they notify of, and then sent by dispatcher until the webhook accepts them
+CREATE TABLE webhook_deliveries(
+    id bigserial PRIMARY KEY,
+    webhook_id integer NOT NULL REFERENCES webhooks ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users,
+    event VARCHAR(64) NOT NULL,
+    payload jsonb NOT NULL,
+    status integer NOT NULL DEFAULT 1,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamp NOT NULL,
+    last_attempt_at timestamp,
+    response_status integer NOT NULL DEFAULT 0,
+    last_error text NOT NULL DEFAULT '',
+    created_at timestamp NOT NULL
+);
+CREATE INDEX ON webhook_deliveries (webhook_id, id);
+CREATE INDEX ON webhook_deliveries (next_attempt_at) WHERE status = 1;
+COMMIT;
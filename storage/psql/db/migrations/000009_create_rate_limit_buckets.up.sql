@@ -0,0 +1,10 @@
+BEGIN;
+-- Token buckets of rate limits shared by all instances, see pkg/ratelimit
+CREATE TABLE rate_limit_buckets(
+    key VARCHAR(255) PRIMARY KEY,
+    tokens double precision NOT NULL,
+    updated_at timestamp NOT NULL,
+    full_at timestamp NOT NULL
+);
+CREATE INDEX ON rate_limit_buckets (full_at);
+COMMIT;
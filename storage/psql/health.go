@@ -0,0 +1,65 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Ping checks that database is reachable
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// CheckMigrations returns error if database schema is not at the latest migration
+// or if the last migration failed halfway, leaving schema dirty
+func (s *Storage) CheckMigrations(ctx context.Context) error {
+	var version uint
+	var dirty bool
+
+	err := s.db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty", version)
+	}
+
+	if version < s.latestMigration {
+		return fmt.Errorf("schema version %d is behind latest migration %d", version, s.latestMigration)
+	}
+
+	return nil
+}
+
+// latestMigration returns version of the latest up migration in dir.
+// Migration files are named <version>_<name>.up.sql.
+func latestMigration(dir string) (uint, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+
+		prefix := strings.SplitN(name, "_", 2)[0]
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s has no version: %w", name, err)
+		}
+
+		if uint(version) > latest {
+			latest = uint(version)
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,43 @@
+package psql
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestMigration(t *testing.T) {
+	t.Run("returns version of the latest up migration", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, name := range []string{
+			"000001_create_users.up.sql",
+			"000001_create_users.down.sql",
+			"000012_add_index.up.sql",
+			"000012_add_index.down.sql",
+			"000003_create_orders.up.sql",
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+		}
+
+		got, err := latestMigration(dir)
+		require.NoError(t, err)
+		assert.Equal(t, uint(12), got)
+	})
+
+	t.Run("finds migrations of the project", func(t *testing.T) {
+		got, err := latestMigration(filepath.Join("db", "migrations"))
+		require.NoError(t, err)
+		assert.Greater(t, got, uint(0))
+	})
+
+	t.Run("returns error for migration without version", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "create_users.up.sql"), nil, 0o644))
+
+		_, err := latestMigration(dir)
+		assert.Error(t, err)
+	})
+}
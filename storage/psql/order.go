@@ -49,6 +49,122 @@ func (s *Storage) AcceptOrder(ctx context.Context, userID uint64, orderID string
 	return &order, nil
 }
 
+// AcceptOrders stores all orderIDs not uploaded before in one transaction and returns
+// outcome for each of them. orderIDs must be unique, since bulk insert cannot tell
+// a duplicate from the batch apart from the order uploaded earlier.
+func (s *Storage) AcceptOrders(ctx context.Context, userID uint64, orderIDs []string) (map[string]model.OrderUploadStatus, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("error starting transaction")
+		return nil, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.Log(ctx).Err(err).Msg("error rolling back transaction")
+		}
+	}()
+
+	result, err := s.insertOrders(ctx, tx, userID, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make([]string, 0, len(orderIDs)-len(result))
+	for _, orderID := range orderIDs {
+		if _, ok := result[orderID]; !ok {
+			existing = append(existing, orderID)
+		}
+	}
+
+	if len(existing) > 0 {
+		if err := s.existingOrders(ctx, tx, userID, existing, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Log(ctx).Err(err).Msg("error while committing transaction")
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// insertOrders bulk inserts orderIDs skipping the ones already uploaded
+// and returns accepted status for each inserted order
+func (s *Storage) insertOrders(ctx context.Context, tx *sql.Tx, userID uint64, orderIDs []string) (map[string]model.OrderUploadStatus, error) {
+	result := make(map[string]model.OrderUploadStatus, len(orderIDs))
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`INSERT INTO orders (order_id, user_id, uploaded_at)
+		 SELECT unnest($1::varchar[]), $2, $3
+		     ON CONFLICT (order_id) DO NOTHING
+		 RETURNING order_id`,
+		orderIDs, userID, time.Now(),
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("error while saving %d orders for user %d", len(orderIDs), userID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID string
+		if err := rows.Scan(&orderID); err != nil {
+			s.Log(ctx).Err(err).Msg("failed to scan row")
+			return nil, err
+		}
+		result[orderID] = model.OrderUploadAccepted
+	}
+
+	if err := rows.Err(); err != nil {
+		s.Log(ctx).Err(err).Msg("error reading rows")
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// existingOrders adds to result whether each of orderIDs was uploaded earlier
+// by the same user or by another one
+func (s *Storage) existingOrders(ctx context.Context, tx *sql.Tx, userID uint64, orderIDs []string, result map[string]model.OrderUploadStatus) error {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT order_id, user_id
+		   FROM orders
+		  WHERE order_id = ANY($1::varchar[])`,
+		orderIDs,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("error determining which users uploaded %d orders", len(orderIDs))
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID string
+		var existingUser uint64
+		if err := rows.Scan(&orderID, &existingUser); err != nil {
+			s.Log(ctx).Err(err).Msg("failed to scan row")
+			return err
+		}
+
+		result[orderID] = model.OrderUploadConflict
+		if existingUser == userID {
+			result[orderID] = model.OrderUploadAlreadyUploaded
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		s.Log(ctx).Err(err).Msg("error reading rows")
+		return err
+	}
+
+	return nil
+}
+
 func (s *Storage) OrdersWithStatus(ctx context.Context, status model.OrderStatus, limit int) ([]string, error) {
 	result := make([]string, 0)
 
@@ -87,6 +203,41 @@ func (s *Storage) OrdersWithStatus(ctx context.Context, status model.OrderStatus
 	return result, nil
 }
 
+// OrderCountsByStatus returns number of orders in each status.
+// Statuses without orders are missing from the result.
+func (s *Storage) OrderCountsByStatus(ctx context.Context) (map[model.OrderStatus]int64, error) {
+	result := make(map[model.OrderStatus]int64)
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT status, COUNT(*)
+		   FROM orders
+		  GROUP BY status`,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to count orders by status")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status int
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			s.Log(ctx).Err(err).Msg("failed to scan row")
+			return nil, err
+		}
+		result[model.OrderStatus(status)] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		s.Log(ctx).Err(err).Msg("error reading rows")
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (s *Storage) UpdateOrderStatus(ctx context.Context, orderID string, status model.OrderStatus) error {
 	_, err := s.db.ExecContext(
 		ctx,
@@ -137,6 +288,52 @@ func (s *Storage) UserOrders(ctx context.Context, userID uint64) ([]model.Order,
 	return result, nil
 }
 
+// UserOrder returns order uploaded by the user. Orders of other users
+// are not told apart from missing ones, both result in storage.ErrNotFound.
+func (s *Storage) UserOrder(ctx context.Context, userID uint64, orderID string) (*model.Order, error) {
+	var order model.Order
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT order_id, user_id, accrual, status, uploaded_at
+		   FROM orders
+		  WHERE order_id = $1
+		    AND user_id = $2`,
+		orderID, userID,
+	).Scan(&order.OrderID, &order.UserID, &order.Accrual, &order.Status, &order.UploadedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		s.Log(ctx).Err(err).Msgf("failed to fetch order <%s> of user %d", orderID, userID)
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// FetchOrder returns order regardless of the user who uploaded it, or storage.ErrNotFound
+func (s *Storage) FetchOrder(ctx context.Context, orderID string) (*model.Order, error) {
+	var order model.Order
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT order_id, user_id, accrual, status, uploaded_at
+		   FROM orders
+		  WHERE order_id = $1`,
+		orderID,
+	).Scan(&order.OrderID, &order.UserID, &order.Accrual, &order.Status, &order.UploadedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		s.Log(ctx).Err(err).Msgf("failed to fetch order <%s>", orderID)
+		return nil, err
+	}
+
+	return &order, nil
+}
+
 func (s *Storage) sameOrAnotherUser(ctx context.Context, tx *sql.Tx, orderID string, currentUserID uint64) error {
 	var existingUser uint64
 	err := tx.QueryRowContext(
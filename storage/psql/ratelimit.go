@@ -0,0 +1,87 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/soundrussian/go-practicum-diploma/pkg/ratelimit"
+	"sync/atomic"
+	"time"
+)
+
+// pruneRateLimitsEvery is how many tokens are taken between deletions of buckets that have refilled
+const pruneRateLimitsEvery = 1000
+
+// TakeRateLimit takes a token from the bucket of key, which is shared by all instances.
+// Bucket row is locked until the token is taken, so that concurrent requests
+// could not take the same token.
+func (s *Storage) TakeRateLimit(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("error starting transaction")
+		return ratelimit.Result{}, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.Log(ctx).Err(err).Msg("error rolling back transaction")
+		}
+	}()
+
+	// Missing bucket is taken as full. Requests creating the same bucket at once
+	// may each get a token of a full bucket, which is fine for the first requests of a client.
+	var bucket ratelimit.Bucket
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT tokens, updated_at
+		   FROM rate_limit_buckets
+		  WHERE key = $1
+		    FOR UPDATE`,
+		key,
+	).Scan(&bucket.Tokens, &bucket.UpdatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.Log(ctx).Err(err).Msgf("failed to fetch rate limit bucket %s", key)
+		return ratelimit.Result{}, err
+	}
+
+	result := bucket.Take(limit, time.Now())
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO rate_limit_buckets (key, tokens, updated_at, full_at)
+		      VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (key) DO UPDATE
+		         SET tokens = EXCLUDED.tokens,
+		             updated_at = EXCLUDED.updated_at,
+		             full_at = EXCLUDED.full_at`,
+		key, bucket.Tokens, bucket.UpdatedAt, bucket.FullAt(limit),
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to save rate limit bucket %s", key)
+		return ratelimit.Result{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Log(ctx).Err(err).Msg("failed to commit transaction")
+		return ratelimit.Result{}, err
+	}
+
+	if atomic.AddUint64(&s.rateLimitTakes, 1)%pruneRateLimitsEvery == 0 {
+		s.pruneRateLimits(ctx)
+	}
+
+	return result, nil
+}
+
+// pruneRateLimits deletes buckets that have refilled, as they are no different from missing ones
+func (s *Storage) pruneRateLimits(ctx context.Context) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE full_at <= $1`, time.Now())
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to prune rate limit buckets")
+		return
+	}
+
+	if pruned, err := res.RowsAffected(); err == nil {
+		s.Log(ctx).Debug().Msgf("pruned %d rate limit buckets", pruned)
+	}
+}
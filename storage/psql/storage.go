@@ -4,25 +4,37 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/XSAM/otelsql"
 	"github.com/golang-migrate/migrate"
 	"github.com/golang-migrate/migrate/database/postgres"
 	_ "github.com/golang-migrate/migrate/source/file"
 	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/soundrussian/go-practicum-diploma/pkg/metrics"
 	"github.com/soundrussian/go-practicum-diploma/storage"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
 )
 
 var _ storage.Storage = (*Storage)(nil)
 
+// migrationsDir contains migrations that are run on start
+const migrationsDir = "storage/psql/db/migrations"
+
 type Storage struct {
 	db *sql.DB
+	// latestMigration is the version of the latest migration in migrationsDir
+	latestMigration uint
+	// rateLimitTakes counts tokens taken by TakeRateLimit to prune buckets every now and then
+	rateLimitTakes uint64
 }
 
-func New() (storage.Storage, error) {
-	if databaseConnection == nil {
-		return nil, errors.New("databaseConnection config is not set")
+func New(cfg Config) (storage.Storage, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid storage config: %w", err)
 	}
 
-	db, err := sql.Open("pgx", *databaseConnection)
+	// Every SQL statement is recorded as a span, if it is run within a traced request or job
+	db, err := otelsql.Open("pgx", cfg.URI, otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize database connection: %w", err)
 	}
@@ -31,7 +43,16 @@ func New() (storage.Storage, error) {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	store := Storage{db: db}
+	if err := metrics.Register(collectors.NewDBStatsCollector(db, "postgres")); err != nil {
+		return nil, fmt.Errorf("failed to register db stats metrics: %w", err)
+	}
+
+	latest, err := latestMigration(migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	store := Storage{db: db, latestMigration: latest}
 
 	return &store, nil
 }
@@ -48,7 +69,7 @@ func runMigrations(db *sql.DB) error {
 		return err
 	}
 
-	m, err := migrate.NewWithDatabaseInstance("file://storage/psql/db/migrations", "postgres", driver)
+	m, err := migrate.NewWithDatabaseInstance("file://"+migrationsDir, "postgres", driver)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,191 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"time"
+)
+
+func (s *Storage) SetTOTPSecret(ctx context.Context, userID uint64, secret string) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE users
+            SET totp_secret = $1,
+                totp_enabled = false
+          WHERE id = $2`,
+		secret, userID,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to save totp secret for user %d", userID)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to get number of updated users")
+		return err
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Storage) EnableTOTP(ctx context.Context, userID uint64, recoveryCodeHashes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("error starting transaction")
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.Log(ctx).Err(err).Msg("error rolling back transaction")
+		}
+	}()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE users
+            SET totp_enabled = true
+          WHERE id = $1`,
+		userID,
+	); err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to enable totp for user %d", userID)
+		return err
+	}
+
+	// Codes from previous enrollments must not be usable any more
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to delete old recovery codes of user %d", userID)
+		return err
+	}
+
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO recovery_codes (user_id, code_hash)
+                  VALUES ($1, $2)`,
+			userID, hash,
+		); err != nil {
+			s.Log(ctx).Err(err).Msgf("failed to save recovery code of user %d", userID)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Log(ctx).Err(err).Msg("error committing transaction")
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) UseRecoveryCode(ctx context.Context, userID uint64, hash string) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE recovery_codes
+            SET used_at = $1
+          WHERE user_id = $2
+            AND code_hash = $3
+            AND used_at IS NULL`,
+		time.Now(), userID, hash,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to use recovery code of user %d", userID)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to get number of used recovery codes")
+		return err
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// UseTOTPStep records that TOTP code of step has been accepted for the user.
+// It returns storage.ErrNotFound if code of the same or a later step has already been accepted,
+// so that concurrent logins with the same code cannot both succeed.
+func (s *Storage) UseTOTPStep(ctx context.Context, userID uint64, step uint64) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE users
+            SET totp_last_step = $1
+          WHERE id = $2
+            AND (totp_last_step IS NULL OR totp_last_step < $1)`,
+		int64(step), userID,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to use totp step of user %d", userID)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to get number of updated users")
+		return err
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// TakeSecondFactorAttempt counts an attempt to enter second factor code for the user.
+// Taking attempt number maxAttempts locks the user out, and further attempts are refused
+// with storage.ErrNotFound until locks taken before lockedBefore have expired.
+// Attempts are taken before the code is checked, so that concurrent guesses are counted too.
+func (s *Storage) TakeSecondFactorAttempt(ctx context.Context, userID uint64, maxAttempts int, lockedBefore time.Time) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE users
+            SET second_factor_attempts = CASE WHEN second_factor_attempts >= $1 THEN 1 ELSE second_factor_attempts + 1 END,
+                second_factor_locked_at = CASE WHEN second_factor_attempts + 1 = $1 THEN $2 ELSE second_factor_locked_at END
+          WHERE id = $3
+            AND (second_factor_attempts < $1 OR second_factor_locked_at < $4)`,
+		maxAttempts, time.Now(), userID, lockedBefore,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to take second factor attempt of user %d", userID)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to get number of updated users")
+		return err
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// ResetSecondFactorAttempts forgets attempts taken by the user once a code has been accepted
+func (s *Storage) ResetSecondFactorAttempts(ctx context.Context, userID uint64) error {
+	if _, err := s.db.ExecContext(
+		ctx,
+		`UPDATE users
+            SET second_factor_attempts = 0
+          WHERE id = $1`,
+		userID,
+	); err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to reset second factor attempts of user %d", userID)
+		return err
+	}
+
+	return nil
+}
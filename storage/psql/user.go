@@ -4,23 +4,26 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx"
 	"github.com/soundrussian/go-practicum-diploma/model"
 	"github.com/soundrussian/go-practicum-diploma/storage"
+	"time"
 )
 
 func (s *Storage) CreateUser(ctx context.Context, login string, password string) (*model.User, error) {
 	var recordID uint64
+	var role model.Role
 	err := s.db.QueryRowContext(
 		ctx,
 		`INSERT INTO users(login, encrypted_password)
 				VALUES ($1, $2)
-		 RETURNING id`,
+		 RETURNING id, role`,
 		login, password,
-	).Scan(&recordID)
+	).Scan(&recordID, &role)
 	if err != nil {
-		var pgError pgx.PgError
+		// pgx/v4 driver reports errors of Postgres as *pgconn.PgError
+		var pgError *pgconn.PgError
 		if errors.As(err, &pgError) && pgerrcode.UniqueViolation == pgError.Code {
 			s.Log(ctx).Err(err).Msgf("user with login %s already exists", login)
 			return nil, storage.ErrLoginAlreadyExists
@@ -32,22 +35,23 @@ func (s *Storage) CreateUser(ctx context.Context, login string, password string)
 	user := &model.User{
 		ID:    recordID,
 		Login: login,
+		Role:  role,
 	}
 
 	return user, nil
 }
 
 func (s *Storage) FetchUser(ctx context.Context, login string) (*model.User, error) {
-	var user model.User
-
-	err := s.db.QueryRowContext(
+	row := s.db.QueryRowContext(
 		ctx,
-		`SELECT id, login, encrypted_password
+		`SELECT id, login, encrypted_password, role, totp_secret, totp_enabled, second_factor_locked_at, deleted_at
            FROM users
           WHERE login = $1
           LIMIT 1`,
 		login,
-	).Scan(&user.ID, &user.Login, &user.Password)
+	)
+
+	user, err := scanUser(row)
 	if err != nil {
 		s.Log(ctx).Err(err).Msgf("error fetching user %s", login)
 		if errors.Is(err, sql.ErrNoRows) {
@@ -56,5 +60,152 @@ func (s *Storage) FetchUser(ctx context.Context, login string) (*model.User, err
 		return nil, err
 	}
 
+	return user, nil
+}
+
+func (s *Storage) FetchUserByID(ctx context.Context, userID uint64) (*model.User, error) {
+	row := s.db.QueryRowContext(
+		ctx,
+		`SELECT id, login, encrypted_password, role, totp_secret, totp_enabled, second_factor_locked_at, deleted_at
+           FROM users
+          WHERE id = $1
+          LIMIT 1`,
+		userID,
+	)
+
+	user, err := scanUser(row)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("error fetching user %d", userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *Storage) UpdateUserRole(ctx context.Context, userID uint64, role model.Role) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE users
+            SET role = $1
+          WHERE id = $2`,
+		role, userID,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to set role %s for user %d", role, userID)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to get number of updated users")
+		return err
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+func scanUser(row scanner) (*model.User, error) {
+	var user model.User
+	var login sql.NullString
+	var totpSecret sql.NullString
+	var secondFactorLockedAt sql.NullTime
+	var deletedAt sql.NullTime
+
+	if err := row.Scan(&user.ID, &login, &user.Password, &user.Role, &totpSecret, &user.TOTPEnabled, &secondFactorLockedAt, &deletedAt); err != nil {
+		return nil, err
+	}
+	// Deleted users have no login
+	user.Login = login.String
+	user.TOTPSecret = totpSecret.String
+	if secondFactorLockedAt.Valid {
+		user.SecondFactorLockedAt = &secondFactorLockedAt.Time
+	}
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+
 	return &user, nil
 }
+
+// DeleteUser anonymizes user, revokes all their credentials and removes their webhooks.
+// Orders and transactions are kept for accounting.
+func (s *Storage) DeleteUser(ctx context.Context, userID uint64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("error starting transaction")
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			s.Log(ctx).Err(err).Msg("error rolling back transaction")
+		}
+	}()
+
+	now := time.Now()
+
+	// Login is removed, so that it can be registered again. Any number of logins
+	// may be NULL despite unique constraint. Empty password hash never matches any password.
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE users
+            SET login = NULL,
+                encrypted_password = '',
+                totp_secret = NULL,
+                totp_enabled = false,
+                deleted_at = $1
+          WHERE id = $2
+            AND deleted_at IS NULL`,
+		now, userID,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to anonymize user %d", userID)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to get number of anonymized users")
+		return err
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to delete recovery codes of user %d", userID)
+		return err
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE api_keys
+            SET revoked_at = $1
+          WHERE user_id = $2
+            AND revoked_at IS NULL`,
+		now, userID,
+	); err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to revoke api keys of user %d", userID)
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE user_id = $1`, userID); err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to delete webhooks of user %d", userID)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Log(ctx).Err(err).Msg("error committing transaction")
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_CreateUser_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "returns ErrLoginAlreadyExists on unique violation",
+			err:     fmt.Errorf("otelsql: %w", &pgconn.PgError{Code: pgerrcode.UniqueViolation}),
+			wantErr: storage.ErrLoginAlreadyExists,
+		},
+		{
+			name: "returns other errors of Postgres as they are",
+			err:  &pgconn.PgError{Code: pgerrcode.NotNullViolation},
+		},
+		{
+			name: "returns other errors as they are",
+			err:  errors.New("connection refused"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Storage{db: sql.OpenDB(failingConnector{err: tt.err})}
+			defer s.db.Close()
+
+			user, err := s.CreateUser(context.Background(), "user", "hash")
+			assert.Nil(t, user)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+				assert.NotErrorIs(t, err, storage.ErrLoginAlreadyExists)
+			}
+		})
+	}
+}
+
+// failingConnector opens connections every query of which fails with err,
+// the way errors of Postgres come through the driver
+type failingConnector struct {
+	err error
+}
+
+func (c failingConnector) Connect(context.Context) (driver.Conn, error) {
+	return failingConn(c), nil
+}
+
+func (c failingConnector) Driver() driver.Driver {
+	return nil
+}
+
+type failingConn struct {
+	err error
+}
+
+func (c failingConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return nil, c.err
+}
+
+func (c failingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, c.err
+}
+
+func (c failingConn) Close() error {
+	return nil
+}
+
+func (c failingConn) Begin() (driver.Tx, error) {
+	return nil, c.err
+}
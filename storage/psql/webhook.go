@@ -0,0 +1,271 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/soundrussian/go-practicum-diploma/model"
+	"github.com/soundrussian/go-practicum-diploma/storage"
+	"strings"
+	"time"
+)
+
+const webhookDeliveryColumns = `id, webhook_id, user_id, event, payload, status, attempts, next_attempt_at, last_attempt_at, response_status, last_error, created_at`
+
+func (s *Storage) CreateWebhook(ctx context.Context, hook model.Webhook) (*model.Webhook, error) {
+	hook.CreatedAt = time.Now()
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO webhooks (user_id, url, secret, events, created_at)
+              VALUES ($1, $2, $3, $4, $5)
+         RETURNING id`,
+		hook.UserID, hook.URL, hook.Secret, joinWebhookEvents(hook.Events), hook.CreatedAt,
+	).Scan(&hook.ID)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to save webhook for %s", hook.URL)
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+func (s *Storage) Webhooks(ctx context.Context) ([]model.Webhook, error) {
+	result := make([]model.Webhook, 0)
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, user_id, url, secret, events, created_at
+		   FROM webhooks
+          ORDER BY id`,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to fetch webhooks")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hook model.Webhook
+		var events string
+		if err := rows.Scan(&hook.ID, &hook.UserID, &hook.URL, &hook.Secret, &events, &hook.CreatedAt); err != nil {
+			s.Log(ctx).Err(err).Msg("failed to scan row")
+			return nil, err
+		}
+
+		hook.Events = splitWebhookEvents(events)
+		result = append(result, hook)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.Log(ctx).Err(err).Msg("error reading rows")
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteWebhook deletes webhook along with its deliveries, including pending ones
+func (s *Storage) DeleteWebhook(ctx context.Context, webhookID uint64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, webhookID)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to delete webhook %d", webhookID)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to get number of deleted webhooks")
+		return err
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// WebhookDeliveries returns at most limit latest deliveries of the webhook, newest first
+func (s *Storage) WebhookDeliveries(ctx context.Context, webhookID uint64, limit int) ([]model.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT `+webhookDeliveryColumns+`
+		   FROM webhook_deliveries
+		  WHERE webhook_id = $1
+		  ORDER BY id DESC
+		  LIMIT $2`,
+		webhookID, limit,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to fetch deliveries of webhook %d", webhookID)
+		return nil, err
+	}
+
+	return s.scanWebhookDeliveries(ctx, rows)
+}
+
+// ClaimWebhookDeliveries returns at most limit pending deliveries that are due, oldest first,
+// and postpones their next attempt by lease, so that they are not claimed again while being sent.
+// Deliveries claimed by another instance are skipped rather than waited for.
+func (s *Storage) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	now := time.Now()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`UPDATE webhook_deliveries
+		    SET next_attempt_at = $1
+		  WHERE id IN (
+		        SELECT id
+		          FROM webhook_deliveries
+		         WHERE status = $2
+		           AND next_attempt_at <= $3
+		         ORDER BY next_attempt_at
+		         LIMIT $4
+		           FOR UPDATE SKIP LOCKED
+		  )
+		 RETURNING `+webhookDeliveryColumns,
+		now.Add(lease), model.WebhookDeliveryPending, now, limit,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to claim webhook deliveries")
+		return nil, err
+	}
+
+	return s.scanWebhookDeliveries(ctx, rows)
+}
+
+// UpdateWebhookDelivery saves outcome of an attempt to send delivery
+func (s *Storage) UpdateWebhookDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries
+		    SET status = $1,
+		        attempts = $2,
+		        next_attempt_at = $3,
+		        last_attempt_at = $4,
+		        response_status = $5,
+		        last_error = $6
+		  WHERE id = $7`,
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt,
+		delivery.ResponseStatus, delivery.LastError, delivery.ID,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to update webhook delivery %d", delivery.ID)
+		return err
+	}
+
+	return nil
+}
+
+// RedeliverWebhook makes delivery pending again with a fresh set of attempts,
+// regardless of whether it has succeeded or failed before
+func (s *Storage) RedeliverWebhook(ctx context.Context, deliveryID uint64) error {
+	res, err := s.db.ExecContext(
+		ctx,
+		`UPDATE webhook_deliveries
+		    SET status = $1,
+		        attempts = 0,
+		        next_attempt_at = $2
+		  WHERE id = $3`,
+		model.WebhookDeliveryPending, time.Now(), deliveryID,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to redeliver webhook delivery %d", deliveryID)
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		s.Log(ctx).Err(err).Msg("failed to get number of redelivered webhooks")
+		return err
+	}
+
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
+
+// enqueueWebhooks adds delivery of event of user with payload for every webhook of the user
+// subscribed to the event. It is called within transaction making the change, so that
+// deliveries are only written if the change is committed.
+func (s *Storage) enqueueWebhooks(ctx context.Context, tx *sql.Tx, userID uint64, event model.WebhookEvent, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to encode payload of %s webhook", event)
+		return err
+	}
+
+	now := time.Now()
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, user_id, event, payload, next_attempt_at, created_at)
+		 SELECT id, user_id, $1, $2, $3, $3
+		   FROM webhooks
+		  WHERE user_id = $4
+		    AND $1 = ANY(string_to_array(events, ','))`,
+		string(event), body, now, userID,
+	)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to enqueue %s webhooks of user %d", event, userID)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) scanWebhookDeliveries(ctx context.Context, rows *sql.Rows) ([]model.WebhookDelivery, error) {
+	defer rows.Close()
+
+	result := make([]model.WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery model.WebhookDelivery
+		var event string
+		var lastAttemptAt sql.NullTime
+
+		err := rows.Scan(
+			&delivery.ID, &delivery.WebhookID, &delivery.UserID, &event, &delivery.Payload, &delivery.Status, &delivery.Attempts,
+			&delivery.NextAttemptAt, &lastAttemptAt, &delivery.ResponseStatus, &delivery.LastError, &delivery.CreatedAt,
+		)
+		if err != nil {
+			s.Log(ctx).Err(err).Msg("failed to scan row")
+			return nil, err
+		}
+
+		delivery.Event = model.WebhookEvent(event)
+		if lastAttemptAt.Valid {
+			delivery.LastAttemptAt = &lastAttemptAt.Time
+		}
+		result = append(result, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.Log(ctx).Err(err).Msg("error reading rows")
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// joinWebhookEvents stores events as comma-separated list
+func joinWebhookEvents(events []model.WebhookEvent) string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		result = append(result, string(event))
+	}
+
+	return strings.Join(result, ",")
+}
+
+func splitWebhookEvents(events string) []model.WebhookEvent {
+	result := make([]model.WebhookEvent, 0)
+	for _, event := range strings.Split(events, ",") {
+		if event != "" {
+			result = append(result, model.WebhookEvent(event))
+		}
+	}
+
+	return result
+}
@@ -74,6 +74,15 @@ func (s *Storage) Withdraw(ctx context.Context, userID uint64, withdrawal model.
 		return nil, err
 	}
 
+	err = s.enqueueWebhooks(ctx, tx, userID, model.WebhookPointsWithdrawn, model.PointsWithdrawnPayload{
+		Order:       withdrawal.Order,
+		Sum:         withdrawal.Sum,
+		ProcessedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		s.Log(ctx).Err(err).Msg("error committing transaction")
 		return nil, err
@@ -120,3 +129,35 @@ func (s *Storage) UserWithdrawals(ctx context.Context, userID uint64) ([]model.W
 
 	return result, nil
 }
+
+func (s *Storage) UserTransactions(ctx context.Context, userID uint64) ([]model.Transaction, error) {
+	result := make([]model.Transaction, 0)
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT order_id, amount, created_at
+		   FROM transactions
+		  WHERE user_id = $1
+          ORDER BY created_at`,
+		userID)
+	if err != nil {
+		s.Log(ctx).Err(err).Msgf("failed to fetch transactions for user_id %d", userID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record := model.Transaction{}
+		if err := rows.Scan(&record.OrderID, &record.Amount, &record.CreatedAt); err != nil {
+			s.Log(ctx).Err(err).Msg("failed to scan row")
+			return nil, err
+		}
+		result = append(result, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.Log(ctx).Err(err).Msg("error reading rows")
+		return nil, err
+	}
+
+	return result, nil
+}